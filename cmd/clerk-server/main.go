@@ -0,0 +1,216 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// clerk-server serves the tasks and notes of clerk through a REST API.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/csixteen/clerk/internal/config"
+	d "github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/csixteen/clerk/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+// fromConfig returns `value` or, if it's empty, the value of `key` in the
+// clerk configuration.
+func fromConfig(value string, key string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.Get(key), nil
+}
+
+// openDatabase opens the database at `dbFile` or, if it's empty, the one in
+// the clerk configuration.
+func openDatabase(dbFile string) (*sql.DB, error) {
+	dbFile, err := fromConfig(dbFile, "database")
+	if err != nil {
+		return nil, err
+	}
+
+	return d.SetupDatabase(dbFile)
+}
+
+// newServer returns a server backed by `db` or, if there's a DSN in `dsn` or
+// in the clerk configuration, one that keeps the tasks and notes in that
+// PostgreSQL database. The function returned closes the PostgreSQL database.
+func newServer(db *sql.DB, dsn string) (*server.Server, func() error, error) {
+	dsn, err := fromConfig(dsn, "postgres")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dsn == "" {
+		s := server.New(db)
+		go s.DeliverWebhooks(context.Background())
+
+		return s, func() error { return nil }, nil
+	}
+
+	pg, err := d.SetupPostgres(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return server.NewWithStore(db, models.NewPostgresStore(pg)), pg.Close, nil
+}
+
+func rootCommand() *cobra.Command {
+	var addr, dbFile, dsn string
+
+	cmd := &cobra.Command{
+		Use:   "clerk-server",
+		Short: "clerk-server serves your tasks and notes over HTTP.",
+		Long: `Serves a REST API to manage the tasks and notes in the clerk database.
+Requests must carry an API token, see "clerk-server token create".`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			db, err := openDatabase(dbFile)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			s, closeStore, err := newServer(db, dsn)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			log.Printf("Listening on %s", addr)
+
+			return http.ListenAndServe(addr, s)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	cmd.Flags().StringVar(&dsn, "postgres", "", "DSN of a PostgreSQL database for the tasks and notes, e.g. postgres://clerk@localhost/clerk (default: the one in the clerk configuration)")
+	cmd.PersistentFlags().StringVar(&dbFile, "database", "", "path of the database (default: the one in the clerk configuration)")
+
+	cmd.AddCommand(tokenCommand(&dbFile))
+
+	return cmd
+}
+
+// tokenCommand returns the `token` command, which manages the API tokens
+// stored in the database at `*dbFile`.
+func tokenCommand(dbFile *string) *cobra.Command {
+	// withDatabase runs `fn` with the database open.
+	withDatabase := func(fn func(db *sql.DB, args []string) error) func(*cobra.Command, []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			db, err := openDatabase(*dbFile)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			return fn(db, args)
+		}
+	}
+
+	token := &cobra.Command{
+		Use:   "token",
+		Short: "Manage the API tokens",
+		Long:  "Create, list and revoke the API tokens that users send to the server as bearer tokens.",
+	}
+
+	token.AddCommand(&cobra.Command{
+		Use:   "create <user>",
+		Short: "Creates an API token for a user and prints it",
+		Long: `Creates an API token for a user and prints it. Only a hash of the token
+is stored, so it can't be printed again. Users own the tasks and notes they
+add, and the ones they added locally under the same user name.`,
+		Args: cobra.ExactArgs(1),
+		RunE: withDatabase(func(db *sql.DB, args []string) error {
+			t, err := models.CreateToken(db, args[0], time.Now())
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(t)
+
+			return nil
+		}),
+	})
+
+	token.AddCommand(&cobra.Command{
+		Use:     "list",
+		Short:   "Lists the API tokens",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: withDatabase(func(db *sql.DB, args []string) error {
+			tokens, err := models.ListTokens(db)
+			if err != nil {
+				return err
+			}
+
+			for _, t := range tokens {
+				fmt.Println(t)
+			}
+
+			return nil
+		}),
+	})
+
+	token.AddCommand(&cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revokes an API token given its id",
+		Args:  cobra.ExactArgs(1),
+		RunE: withDatabase(func(db *sql.DB, args []string) error {
+			return models.RevokeToken(db, args[0], time.Now())
+		}),
+	})
+
+	return token
+}
+
+func main() {
+	if err := rootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
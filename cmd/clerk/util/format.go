@@ -0,0 +1,164 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// templateFuncs are the functions available to --format templates, besides
+// the ones predefined by text/template.
+var templateFuncs = template.FuncMap{
+	// date formats a time with the date format, or as an empty string if
+	// it's the zero time.
+	"date": func(t time.Time) string {
+		return formatDate(dateFormat, t)
+	},
+	// formatDate formats a time with a layout such as `Jan 2`.
+	"formatDate": formatDate,
+	// ago returns how long ago a time was, e.g. `3d` or `5h`.
+	"ago": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+
+		return Ago(time.Since(t))
+	},
+	// truncate shortens a string to at most `n` characters.
+	"truncate": Truncate,
+	// pad pads a string with spaces up to `n` characters.
+	"pad": func(n int, s string) string {
+		if n -= utf8.RuneCountInString(s); n > 0 {
+			s += strings.Repeat(" ", n)
+		}
+
+		return s
+	},
+	// color colors a string, e.g. {{color "red" .Name}}.
+	"color": func(name string, s string) (string, error) {
+		c, ok := colors[name]
+		if !ok {
+			return "", fmt.Errorf("unknown color %q", name)
+		}
+
+		return Colorize(s, c), nil
+	},
+	"join":  func(sep string, s []string) string { return strings.Join(s, sep) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// dateFormat is the layout of the dates in the output.
+var dateFormat = "2006-01-02 15:04:05"
+
+// SetDateFormat sets the layout of the dates in the output.
+func SetDateFormat(layout string) {
+	dateFormat = layout
+}
+
+func formatDate(layout string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(layout)
+}
+
+// Ago formats a duration in the largest unit that fits it: weeks, days,
+// hours, minutes or seconds.
+func Ago(d time.Duration) string {
+	for _, u := range []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"w", 7 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+	} {
+		if d >= u.unit {
+			return fmt.Sprintf("%d%s", d/u.unit, u.suffix)
+		}
+	}
+
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// Truncate shortens `s` to at most `n` characters, ending it with an
+// ellipsis if it's too long.
+func Truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// ParseTemplate parses a --format template, where `\t` and `\n` stand for a
+// tab and a newline.
+func ParseTemplate(format string) (*template.Template, error) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	return template.New("format").Funcs(templateFuncs).Parse(format)
+}
+
+// RenderTemplate executes `tmpl` against `v` or, if `v` is a slice, against
+// each one of its elements, writing a newline after each execution.
+func RenderTemplate(w io.Writer, tmpl *template.Template, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return executeLine(w, tmpl, v)
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		if err := executeLine(w, tmpl, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func executeLine(w io.Writer, tmpl *template.Template, v interface{}) error {
+	if err := tmpl.Execute(w, v); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
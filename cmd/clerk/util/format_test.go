@@ -0,0 +1,93 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", Truncate(10, "short"))
+	assert.Equal(t, "tru…", Truncate(4, "truncated"))
+	assert.Equal(t, "", Truncate(0, "truncated"))
+}
+
+func TestAgo(t *testing.T) {
+	assert.Equal(t, "30s", Ago(30*time.Second))
+	assert.Equal(t, "5h", Ago(5*time.Hour+10*time.Minute))
+	assert.Equal(t, "2w", Ago(15*24*time.Hour))
+}
+
+func TestRenderTemplate(t *testing.T) {
+	created := time.Date(2020, 9, 20, 15, 0, 0, 0, time.UTC)
+	items := []*Item{
+		{Id: "1", Contents: []string{"one", "two"}, CreatedAt: created},
+		{Id: "2"},
+	}
+
+	tmpl, err := ParseTemplate(`{{.Id}}\t{{date .CreatedAt}}\t{{join "," .Contents | upper}}`)
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, RenderTemplate(&b, tmpl, items))
+	assert.Equal(t, "1\t2020-09-20 15:00:00\tONE,TWO\n2\t\t\n", b.String())
+
+	tmpl, err = ParseTemplate(`{{formatDate "Jan 2" .CreatedAt}} {{color "red" .Id}}`)
+	assert.NoError(t, err)
+
+	b.Reset()
+	assert.NoError(t, RenderTemplate(&b, tmpl, items[0]))
+	assert.Equal(t, "Sep 20 \033[31m1\033[0m\n", b.String())
+
+	tmpl, err = ParseTemplate(`{{color "pink" .Id}}`)
+	assert.NoError(t, err)
+	assert.Error(t, RenderTemplate(&b, tmpl, items[0]))
+}
+
+func TestRenderTemplateTasks(t *testing.T) {
+	due := time.Date(2020, 10, 12, 18, 0, 0, 0, time.UTC)
+	tasks := []*models.TaskModel{
+		{Id: "1", Name: "groceries", Due: due},
+		{Id: "2", Name: "laundry"},
+	}
+
+	// The example of --format.
+	tmpl, err := ParseTemplate(`{{.Id}}\t{{.Name}}\t{{date .Due}}`)
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, RenderTemplate(&b, tmpl, tasks))
+	assert.Equal(t, "1\tgroceries\t2020-10-12 18:00:00\n2\tlaundry\t\n", b.String())
+
+	tmpl, err = ParseTemplate(`{{.Id}}\t{{.Name}}\t{{date .Due}}\t{{.Priority}}`)
+	assert.NoError(t, err)
+
+	b.Reset()
+	assert.NoError(t, RenderTemplate(&b, tmpl, tasks))
+	assert.Equal(t, "1\tgroceries\t2020-10-12 18:00:00\t0\n2\tlaundry\t\t0\n", b.String())
+}
@@ -0,0 +1,310 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatYAML  Format = "yaml"
+	FormatCSV   Format = "csv"
+	FormatTSV   Format = "tsv"
+	FormatTable Format = "table"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{FormatText, FormatJSON, FormatYAML, FormatCSV, FormatTSV, FormatTable}
+
+// ParseFormat returns the output format called `s`.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown output format %q", s)
+}
+
+// Options control how items are rendered.
+type Options struct {
+	// Columns are the names of the fields printed by the formats that have
+	// columns (csv, tsv and table). All of them are printed if it's empty.
+	Columns []string
+	// Width is the maximum width of tables, or 0 if there's no limit.
+	Width int
+	// RowColor, if set, returns the color of the row of each item of a
+	// table, or "" to leave it uncolored.
+	RowColor func(item interface{}) Color
+}
+
+// Render writes `v`, which is either a struct or a slice of structs (or
+// pointers to them), to `w` in a structured format. Field names are taken from
+// the `json` struct tags, so all formats share the same names. Text isn't a
+// structured format: each command prints its own text.
+func Render(w io.Writer, f Format, v interface{}, opts Options) error {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nonNil(v))
+	case FormatYAML:
+		return renderYAML(w, v)
+	case FormatCSV:
+		return renderCSV(w, ',', v, opts.Columns)
+	case FormatTSV:
+		return renderCSV(w, '\t', v, opts.Columns)
+	case FormatTable:
+		return renderTable(w, v, opts)
+	default:
+		return fmt.Errorf("can't render %q output", f)
+	}
+}
+
+// nonNil returns an empty slice if `v` is a nil slice, so that an empty list
+// is rendered as such instead of null.
+func nonNil(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	return v
+}
+
+// renderYAML goes through JSON, so that the `json` struct tags are honored
+// and fields keep their order.
+func renderYAML(w io.Writer, v interface{}) error {
+	j, err := json.Marshal(nonNil(v))
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(j, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// blockStyle drops the JSON (flow) style of a YAML document.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// renderCSV writes a header with the names of the fields followed by one
+// record per item.
+func renderCSV(w io.Writer, comma rune, v interface{}, columns []string) error {
+	header, records, err := table(v, columns, formatValue)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// items returns `v` as a slice, wrapping it in one if it's a single item.
+func items(v interface{}) reflect.Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		slice := reflect.MakeSlice(reflect.SliceOf(rv.Type()), 1, 1)
+		slice.Index(0).Set(rv)
+		rv = slice
+	}
+
+	return rv
+}
+
+// table flattens `v` into the names of the selected columns and one record
+// per item, with the values formatted by `format`. It fails if any of the
+// columns doesn't exist.
+func table(v interface{}, columns []string, format func(reflect.Value) string) ([]string, [][]string, error) {
+	rv := items(v)
+
+	header, _ := Columns(reflect.New(rv.Type().Elem()).Elem().Interface())
+	if len(columns) == 0 {
+		columns = header
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+
+	var selected []int
+	for _, c := range columns {
+		i, ok := index[c]
+		if !ok {
+			return nil, nil, fmt.Errorf(
+				"unknown column %q, expected one of: %s",
+				c,
+				strings.Join(header, ", "),
+			)
+		}
+		selected = append(selected, i)
+	}
+
+	var records [][]string
+	for i := 0; i < rv.Len(); i++ {
+		_, values := fields(rv.Index(i).Interface(), format)
+
+		record := make([]string, len(selected))
+		for j, k := range selected {
+			record[j] = values[k]
+		}
+		records = append(records, record)
+	}
+
+	return columns, records, nil
+}
+
+// Columns flattens a struct (or a pointer to one) into the names of its
+// fields, as given by their `json` tags, and their values formatted as text.
+// Fields of embedded structs are promoted.
+func Columns(v interface{}) ([]string, []string) {
+	return fields(v, formatValue)
+}
+
+// fields is Columns with the values formatted by `format`.
+func fields(v interface{}, format func(reflect.Value) string) ([]string, []string) {
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+		if rv.IsNil() {
+			rv = reflect.New(rt).Elem()
+		} else {
+			rv = rv.Elem()
+		}
+	}
+
+	var names, values []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			n, v := fields(rv.Field(i).Interface(), format)
+			names = append(names, n...)
+			values = append(values, v...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		names = append(names, name)
+		values = append(values, format(rv.Field(i)))
+	}
+
+	return names, values
+}
+
+// formatValue formats a field as text.
+func formatValue(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format(dateFormat)
+	case json.RawMessage:
+		return string(x)
+	case []byte:
+		return string(x)
+	case fmt.Stringer:
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return ""
+		}
+		return strings.TrimSpace(x.String())
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		var items []string
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, formatValue(v.Index(i)))
+		}
+		return strings.Join(items, "\n")
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.Map, reflect.Struct:
+		var b bytes.Buffer
+		json.NewEncoder(&b).Encode(v.Interface())
+		return strings.TrimSpace(b.String())
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,85 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Item struct {
+	Id        string    `json:"id"`
+	Contents  []string  `json:"contents"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type linkedItem struct {
+	*Item
+	Links []string `json:"links"`
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatYAML, f)
+
+	_, err = ParseFormat("xml")
+	assert.EqualError(t, err, `unknown output format "xml"`)
+}
+
+func TestRender(t *testing.T) {
+	created := time.Date(2020, 9, 20, 15, 0, 0, 0, time.UTC)
+	items := []*Item{{Id: "1", Contents: []string{"one", "two"}, CreatedAt: created}}
+
+	tests := []struct {
+		format   Format
+		v        interface{}
+		expected string
+	}{
+		{FormatJSON, []*Item(nil), "[]\n"},
+		{
+			FormatYAML,
+			items,
+			"- id: \"1\"\n  contents:\n  - one\n  - two\n  created_at: \"2020-09-20T15:00:00Z\"\n",
+		},
+		{
+			FormatCSV,
+			items,
+			"id,contents,created_at\n1,\"one\ntwo\",2020-09-20 15:00:00\n",
+		},
+		{
+			FormatTSV,
+			&linkedItem{&Item{Id: "2"}, []string{"a", "b"}},
+			"id\tcontents\tcreated_at\tlinks\n2\t\t\t\"a\nb\"\n",
+		},
+	}
+
+	for _, test := range tests {
+		var b bytes.Buffer
+		assert.NoError(t, Render(&b, test.format, test.v, Options{}))
+		assert.Equal(t, test.expected, b.String(), test.format)
+	}
+}
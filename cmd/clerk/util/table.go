@@ -0,0 +1,203 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// minColumnWidth is the width below which columns aren't shrunk to fit a
+// table in the terminal.
+const minColumnWidth = 8
+
+// columnGap separates the columns of a table.
+const columnGap = "  "
+
+// TerminalWidth returns the width of the terminal `f` is attached to, or
+// the value of $COLUMNS. It returns 0 if neither is known, e.g. when the
+// output is redirected to a file.
+func TerminalWidth(f *os.File) int {
+	if term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil {
+			return width
+		}
+	}
+
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+
+	return width
+}
+
+// renderTable writes the items in `v` as a table with a header and aligned
+// columns. Values that span several lines are joined, the widest columns are
+// truncated if the table is wider than `opts.Width`, and rows are colored by
+// `opts.RowColor`.
+func renderTable(w io.Writer, v interface{}, opts Options) error {
+	header, records, err := table(v, opts.Columns, formatValue)
+	if err != nil {
+		return err
+	}
+
+	for i := range header {
+		header[i] = strings.ToUpper(header[i])
+	}
+	for _, record := range records {
+		for i := range record {
+			record[i] = strings.ReplaceAll(record[i], "\n", "; ")
+		}
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, records...) {
+		for i, value := range row {
+			if n := utf8.RuneCountInString(value); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	if opts.Width > 0 {
+		fit(widths, opts.Width-len(columnGap)*(len(widths)-1))
+	}
+
+	rv := items(v)
+	for r, row := range append([][]string{header}, records...) {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			value = Truncate(widths[i], value)
+			cells[i] = value + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value))
+		}
+
+		line := strings.TrimRight(strings.Join(cells, columnGap), " ")
+		if r > 0 && opts.RowColor != nil {
+			line = Colorize(line, opts.RowColor(rv.Index(r-1).Interface()))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fit shrinks the widest columns, one character at a time, until their
+// total width is at most `width` or they can't be shrunk any further.
+func fit(widths []int, width int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+
+	for total > width {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+
+		if widths[widest] <= minColumnWidth {
+			return
+		}
+
+		widths[widest]--
+		total--
+	}
+}
+
+// sortLayout formats the dates to sort by, which are compared as text.
+const sortLayout = "2006-01-02T15:04:05.000000000"
+
+// SortItems sorts the slice `v` by the field whose `json` name is `key`, in
+// descending order if `key` starts with a '-'. Numbers and dates are compared
+// as such, and empty values come last in either order.
+func SortItems(v interface{}, key string) error {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+
+	_, records, err := table(v, []string{key}, sortValue)
+	if err != nil {
+		return err
+	}
+
+	// Sort the indexes and then permute the items accordingly.
+	perm := make([]int, len(records))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool {
+		a, b := records[perm[i]][0], records[perm[j]][0]
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		if desc {
+			a, b = b, a
+		}
+
+		return less(a, b)
+	})
+
+	sorted := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	for i, j := range perm {
+		sorted.Index(i).Set(rv.Index(j))
+	}
+	reflect.Copy(rv, sorted)
+
+	return nil
+}
+
+// sortValue formats a field to sort by: dates are formatted in UTC with a
+// layout that sorts chronologically, whatever the layout of the output.
+func sortValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok && !t.IsZero() {
+		return t.UTC().Format(sortLayout)
+	}
+
+	return formatValue(v)
+}
+
+// less compares two values, as numbers if both of them are.
+func less(a string, b string) bool {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+
+	return strings.ToLower(a) < strings.ToLower(b)
+}
@@ -0,0 +1,114 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTable(t *testing.T) {
+	items := []*Item{
+		{Id: "1", Contents: []string{"buy milk", "call the plumber"}},
+		{Id: "10", Contents: []string{"short"}},
+	}
+
+	var b bytes.Buffer
+	err := Render(&b, FormatTable, items, Options{Columns: []string{"id", "contents"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ID  CONTENTS\n1   buy milk; call the plumber\n10  short\n", b.String())
+
+	b.Reset()
+	err = Render(&b, FormatTable, items, Options{Columns: []string{"id", "contents"}, Width: 16})
+	assert.NoError(t, err)
+	assert.Equal(t, "ID  CONTENTS\n1   buy milk; c…\n10  short\n", b.String())
+
+	err = Render(&b, FormatTable, items, Options{Columns: []string{"name"}})
+	assert.EqualError(t, err, `unknown column "name", expected one of: id, contents, created_at`)
+}
+
+type task struct {
+	Id       string    `json:"id"`
+	Name     string    `json:"name"`
+	Due      time.Time `json:"due"`
+	Priority int       `json:"priority"`
+}
+
+func TestRenderTableDue(t *testing.T) {
+	tasks := []*task{
+		{Id: "1", Name: "groceries", Due: time.Date(2020, 10, 12, 18, 0, 0, 0, time.UTC), Priority: 2},
+		{Id: "2", Name: "laundry"},
+	}
+
+	var b bytes.Buffer
+	err := Render(&b, FormatTable, tasks, Options{Columns: []string{"id", "name", "due", "priority"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "ID  NAME       DUE                  PRIORITY\n"+
+		"1   groceries  2020-10-12 18:00:00  2\n"+
+		"2   laundry                         0\n", b.String())
+}
+
+func TestSortItems(t *testing.T) {
+	items := []*Item{{Id: "2"}, {Id: "10"}, {Id: "1"}}
+
+	assert.NoError(t, SortItems(items, "id"))
+	assert.Equal(t, "1", items[0].Id)
+	assert.Equal(t, "10", items[2].Id)
+
+	assert.NoError(t, SortItems(items, "-id"))
+	assert.Equal(t, "10", items[0].Id)
+
+	assert.Error(t, SortItems(items, "name"))
+}
+
+func TestSortItemsDue(t *testing.T) {
+	defer SetDateFormat(dateFormat)
+	// The dates are sorted chronologically, not as they're printed.
+	SetDateFormat("02/01/2006")
+
+	tasks := []*task{
+		{Id: "1", Due: time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC)},
+		{Id: "2"},
+		{Id: "3", Due: time.Date(2020, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{Id: "4", Due: time.Date(2020, 10, 12, 9, 0, 0, 0, time.FixedZone("", 2*60*60))},
+	}
+
+	assert.NoError(t, SortItems(tasks, "due"))
+	assert.Equal(t, []string{"3", "4", "1", "2"}, ids(tasks))
+
+	// Tasks without a due date come last either way.
+	assert.NoError(t, SortItems(tasks, "-due"))
+	assert.Equal(t, []string{"1", "4", "3", "2"}, ids(tasks))
+}
+
+func ids(tasks []*task) []string {
+	var res []string
+	for _, t := range tasks {
+		res = append(res, t.Id)
+	}
+
+	return res
+}
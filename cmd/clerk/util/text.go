@@ -24,7 +24,10 @@ package util
 
 import (
 	"fmt"
+	"os"
 	"strings"
+
+	"golang.org/x/term"
 )
 
 type Color string
@@ -40,11 +43,135 @@ const (
 	ColorWhite  Color = "\033[37m"
 )
 
+// colors maps the names of the colors, as used by themes and templates, to
+// their ANSI escape sequences.
+var colors = map[string]Color{
+	"red":    ColorRed,
+	"green":  ColorGreen,
+	"yellow": ColorYellow,
+	"blue":   ColorBlue,
+	"purple": ColorPurple,
+	"cyan":   ColorCyan,
+	"white":  ColorWhite,
+	"none":   "",
+}
+
+// Element is a kind of output whose color can be themed.
+type Element string
+
+const (
+	ElementTask      Element = "task"
+	ElementNote      Element = "note"
+	ElementTemplate  Element = "template"
+	ElementRevision  Element = "revision"
+	ElementInfo      Element = "info"
+	ElementHighlight Element = "highlight"
+	ElementAdded     Element = "added"
+	ElementRemoved   Element = "removed"
+	ElementOverdue   Element = "overdue"
+)
+
+// defaultTheme is the color of each element unless a theme says otherwise.
+var defaultTheme = map[Element]Color{
+	ElementTask:      ColorYellow,
+	ElementNote:      ColorCyan,
+	ElementTemplate:  ColorGreen,
+	ElementRevision:  ColorBlue,
+	ElementInfo:      ColorWhite,
+	ElementHighlight: ColorRed,
+	ElementAdded:     ColorGreen,
+	ElementRemoved:   ColorRed,
+	ElementOverdue:   ColorRed,
+}
+
+var (
+	theme        = copyTheme(defaultTheme)
+	colorEnabled = true
+)
+
+func copyTheme(t map[Element]Color) map[Element]Color {
+	res := make(map[Element]Color, len(t))
+	for e, c := range t {
+		res[e] = c
+	}
+
+	return res
+}
+
+// SetColorMode enables or disables colors. The mode is either `always`,
+// `never` or `auto`, in which case colors are only enabled if `out` is a
+// terminal and $NO_COLOR isn't set to a non-empty value.
+func SetColorMode(mode string, out *os.File) error {
+	switch mode {
+	case "always":
+		colorEnabled = true
+	case "never":
+		colorEnabled = false
+	case "auto":
+		noColor := os.Getenv("NO_COLOR") != ""
+		colorEnabled = !noColor && term.IsTerminal(int(out.Fd()))
+	default:
+		return fmt.Errorf("invalid color mode %q, expected auto, always or never", mode)
+	}
+
+	return nil
+}
+
+// SetTheme overrides the colors of some elements given a theme such as
+// `task=yellow:note=cyan:highlight=red`. Colors are red, green, yellow,
+// blue, purple, cyan, white or none. An empty theme restores the default
+// colors.
+func SetTheme(spec string) error {
+	t := copyTheme(defaultTheme)
+
+	for _, item := range strings.Split(spec, ":") {
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid theme entry %q, expected <element>=<color>", item)
+		}
+
+		e := Element(strings.TrimSpace(parts[0]))
+		if _, ok := defaultTheme[e]; !ok {
+			return fmt.Errorf("unknown element %q in theme", e)
+		}
+
+		c, ok := colors[strings.TrimSpace(parts[1])]
+		if !ok {
+			return fmt.Errorf("unknown color %q in theme", parts[1])
+		}
+
+		t[e] = c
+	}
+
+	theme = t
+
+	return nil
+}
+
+// Colorize wraps `s` in the escape sequences of the color `c`, unless colors
+// are disabled.
+func Colorize(s string, c Color) string {
+	if !colorEnabled || c == "" {
+		return s
+	}
+
+	return string(c) + s + string(ColorReset)
+}
+
+// ColorOf returns the color of an element in the current theme.
+func ColorOf(e Element) Color {
+	return theme[e]
+}
+
 func PrintColor(s string, c Color) {
-	var str strings.Builder
+	fmt.Println(Colorize(s, c))
+}
 
-	str.WriteString(string(c))
-	str.WriteString(s)
-	str.WriteString(string(ColorReset))
-	fmt.Println(str.String())
+// PrintElement prints `s` with the color of the element `e`.
+func PrintElement(e Element, s string) {
+	PrintColor(s, ColorOf(e))
 }
@@ -0,0 +1,100 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetColorMode(t *testing.T) {
+	defer SetColorMode("always", os.Stdout)
+
+	f, err := ioutil.TempFile("", "clerk")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// Files aren't terminals.
+	assert.NoError(t, SetColorMode("auto", f))
+	assert.Equal(t, "text", Colorize("text", ColorRed))
+
+	assert.NoError(t, SetColorMode("always", f))
+	assert.Equal(t, "\033[31mtext\033[0m", Colorize("text", ColorRed))
+
+	assert.NoError(t, SetColorMode("never", f))
+	assert.Equal(t, "text", Colorize("text", ColorRed))
+
+	assert.Error(t, SetColorMode("sometimes", f))
+}
+
+func TestSetTheme(t *testing.T) {
+	defer SetTheme("")
+
+	assert.NoError(t, SetTheme("task=purple:highlight=none"))
+	assert.Equal(t, ColorPurple, ColorOf(ElementTask))
+	assert.Equal(t, Color(""), ColorOf(ElementHighlight))
+	assert.Equal(t, ColorCyan, ColorOf(ElementNote))
+
+	assert.EqualError(t, SetTheme("task=pink"), `unknown color "pink" in theme`)
+	assert.EqualError(t, SetTheme("late=red"), `unknown element "late" in theme`)
+	assert.Error(t, SetTheme("task"))
+
+	// Failed themes don't change the colors.
+	assert.Equal(t, ColorPurple, ColorOf(ElementTask))
+
+	assert.NoError(t, SetTheme(""))
+	assert.Equal(t, ColorYellow, ColorOf(ElementTask))
+}
+
+func TestOverdueColor(t *testing.T) {
+	defer SetTheme("")
+	defer SetColorMode("always", os.Stdout)
+	SetColorMode("always", os.Stdout)
+
+	assert.Equal(t, ColorRed, ColorOf(ElementOverdue))
+	assert.NoError(t, SetTheme("overdue=purple"))
+	assert.Equal(t, ColorPurple, ColorOf(ElementOverdue))
+
+	now := time.Date(2020, 10, 12, 12, 0, 0, 0, time.UTC)
+	tasks := []*task{
+		{Id: "1", Name: "groceries", Due: now.Add(-time.Hour)},
+		{Id: "2", Name: "laundry", Due: now.Add(time.Hour)},
+	}
+	overdue := func(item interface{}) Color {
+		if item.(*task).Due.Before(now) {
+			return ColorOf(ElementOverdue)
+		}
+		return ""
+	}
+
+	var b bytes.Buffer
+	err := Render(&b, FormatTable, tasks, Options{Columns: []string{"id", "name"}, RowColor: overdue})
+	assert.NoError(t, err)
+	assert.Equal(t, "ID  NAME\n\033[35m1   groceries\033[0m\n2   laundry\n", b.String())
+}
@@ -0,0 +1,217 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/client"
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// backend is where the commands find the tasks and notes: the local database
+// or a clerk-server (see --remote). Both return the same errors.
+type backend interface {
+	models.Store
+
+	ListLinks(entityType string, id string) ([]*models.LinkModel, error)
+	AddLink(from string, to string, t time.Time) error
+	DeleteLink(from string, to string) error
+
+	ListTemplates() ([]*models.TemplateModel, error)
+	GetTemplate(template string) (*models.TemplateModel, error)
+	AddTemplate(name string, contents string, t time.Time) (int64, error)
+	DeleteTemplate(template string) error
+
+	ListTrash() ([]*models.TrashItemModel, error)
+	RestoreItem(entityType string, id string) error
+	EmptyTrash(t time.Time) (int64, error)
+
+	ListRevisions(entityType string, ref string) ([]*models.RevisionModel, error)
+	GetRevision(entityType string, ref string, rev int) (*models.RevisionModel, error)
+	CurrentRevision(entityType string, ref string) (*models.RevisionModel, error)
+	RevertItem(entityType string, ref string, rev int) error
+
+	ListShares(entityType string, ref string) ([]*models.ShareModel, error)
+	ShareItem(entityType string, ref string, user string, t time.Time) error
+	UnshareItem(entityType string, ref string, user string) error
+
+	Undo(n int, t time.Time) ([]*models.OperationModel, error)
+	Redo(n int, t time.Time) ([]*models.OperationModel, error)
+
+	ListAudit(f models.AuditFilter) ([]*models.AuditEntryModel, error)
+	Watch(ctx context.Context, after string, fn func(*models.EventModel) error) error
+
+	ListWebhooks() ([]*models.WebhookModel, error)
+	AddWebhook(event string, url string, secret string, t time.Time) (*models.WebhookModel, error)
+	DeleteWebhook(id string) error
+	ListDeliveries(webhook string) ([]*models.DeliveryModel, error)
+}
+
+var _ backend = (*client.Client)(nil)
+
+// localBackend keeps the tasks and notes in the local database.
+type localBackend struct {
+	*models.SQLiteStore
+	db    *sql.DB
+	actor models.Actor
+}
+
+// newLocalBackend returns the backend that uses the database `db`, which
+// makes the changes as `a`.
+func newLocalBackend(db *sql.DB, a models.Actor) *localBackend {
+	return &localBackend{SQLiteStore: models.NewSQLiteStore(db, a), db: db, actor: a}
+}
+
+func (b *localBackend) ListLinks(entityType string, id string) ([]*models.LinkModel, error) {
+	return models.ListLinks(b.db, entityType, id)
+}
+
+func (b *localBackend) AddLink(from string, to string, t time.Time) error {
+	return models.AddLink(b.db, b.actor, from, to, t)
+}
+
+func (b *localBackend) DeleteLink(from string, to string) error {
+	return models.DeleteLink(b.db, b.actor, from, to)
+}
+
+func (b *localBackend) ListTemplates() ([]*models.TemplateModel, error) {
+	return models.ListTemplates(b.db)
+}
+
+func (b *localBackend) GetTemplate(template string) (*models.TemplateModel, error) {
+	return models.GetTemplate(b.db, template)
+}
+
+func (b *localBackend) AddTemplate(name string, contents string, t time.Time) (int64, error) {
+	return models.AddTemplate(b.db, b.actor, name, contents, t)
+}
+
+func (b *localBackend) DeleteTemplate(template string) error {
+	return models.DeleteTemplate(b.db, b.actor, template)
+}
+
+func (b *localBackend) ListTrash() ([]*models.TrashItemModel, error) {
+	return models.ListTrash(b.db)
+}
+
+func (b *localBackend) RestoreItem(entityType string, id string) error {
+	return models.RestoreItem(b.db, b.actor, entityType, id)
+}
+
+func (b *localBackend) EmptyTrash(t time.Time) (int64, error) {
+	return models.EmptyTrash(b.db, b.actor, t)
+}
+
+func (b *localBackend) ListRevisions(entityType string, ref string) ([]*models.RevisionModel, error) {
+	return models.ListRevisions(b.db, entityType, ref)
+}
+
+func (b *localBackend) GetRevision(entityType string, ref string, rev int) (*models.RevisionModel, error) {
+	return models.GetRevision(b.db, entityType, ref, rev)
+}
+
+func (b *localBackend) CurrentRevision(entityType string, ref string) (*models.RevisionModel, error) {
+	return models.CurrentRevision(b.db, entityType, ref)
+}
+
+func (b *localBackend) RevertItem(entityType string, ref string, rev int) error {
+	return models.RevertItem(b.db, b.actor, entityType, ref, rev)
+}
+
+func (b *localBackend) ListShares(entityType string, ref string) ([]*models.ShareModel, error) {
+	return models.ListShares(b.db, entityType, ref)
+}
+
+func (b *localBackend) ShareItem(entityType string, ref string, user string, t time.Time) error {
+	return models.ShareItem(b.db, b.actor, entityType, ref, user, t)
+}
+
+func (b *localBackend) UnshareItem(entityType string, ref string, user string) error {
+	return models.UnshareItem(b.db, b.actor, entityType, ref, user)
+}
+
+func (b *localBackend) Undo(n int, t time.Time) ([]*models.OperationModel, error) {
+	return models.Undo(b.db, b.actor, n, t)
+}
+
+func (b *localBackend) Redo(n int, t time.Time) ([]*models.OperationModel, error) {
+	return models.Redo(b.db, b.actor, n, t)
+}
+
+func (b *localBackend) ListAudit(f models.AuditFilter) ([]*models.AuditEntryModel, error) {
+	return models.ListAudit(b.db, f)
+}
+
+func (b *localBackend) ListWebhooks() ([]*models.WebhookModel, error) {
+	return models.ListWebhooks(b.db)
+}
+
+func (b *localBackend) AddWebhook(event string, url string, secret string, t time.Time) (*models.WebhookModel, error) {
+	return models.AddWebhook(b.db, b.actor, event, url, secret, t)
+}
+
+func (b *localBackend) DeleteWebhook(id string) error {
+	return models.DeleteWebhook(b.db, id)
+}
+
+func (b *localBackend) ListDeliveries(webhook string) ([]*models.DeliveryModel, error) {
+	return models.ListDeliveries(b.db, webhook)
+}
+
+// watchInterval is how often Watch checks the local database for new events.
+const watchInterval = time.Second
+
+// Watch calls `fn` with the events after the one with id `after`, or from now
+// on if it's empty, as they happen, until `ctx` is done or `fn` fails.
+func (b *localBackend) Watch(ctx context.Context, after string, fn func(*models.EventModel) error) error {
+	if after == "" {
+		latest, err := models.LatestEventId(b.db)
+		if err != nil {
+			return err
+		}
+		after = latest
+	}
+
+	for {
+		events, err := models.ListEvents(b.db, after)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+			after = e.Id
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(watchInterval):
+		}
+	}
+}
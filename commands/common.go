@@ -0,0 +1,132 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+const allFlagUsage = "operate on all the items with the given name"
+
+// listOptions are the flags of the commands that list items.
+type listOptions struct {
+	columns []string
+	sort    string
+
+	// table lists the items as a table of `defaultColumns` unless another
+	// output format is given.
+	table          bool
+	defaultColumns []string
+
+	// rowColor colors the rows of the table, see u.Options.
+	rowColor func(item interface{}) u.Color
+}
+
+func (o *listOptions) addFlags(cmd *cobra.Command) {
+	usage := "comma-separated columns to print, e.g. id,name (implies --output table)"
+	if o.table {
+		usage = fmt.Sprintf("comma-separated columns to print (default %s)", strings.Join(o.defaultColumns, ","))
+	}
+
+	cmd.Flags().StringSliceVar(&o.columns, "columns", nil, usage)
+	cmd.Flags().StringVar(&o.sort, "sort", "", "column to sort by, in descending order if prefixed by a '-' (e.g. -created_at)")
+}
+
+// render prints `v` with the template given with --format or in the output
+// format chosen with --output, or calls `text` to print it as text.
+func render(v interface{}, text func()) error {
+	return renderList(v, nil, text)
+}
+
+// renderList is like render, but it also sorts the items in `v` and selects
+// the columns to print as given by `opts`.
+func renderList(v interface{}, opts *listOptions, text func()) error {
+	format := outputFormat
+	o := u.Options{Width: u.TerminalWidth(os.Stdout)}
+
+	if opts != nil {
+		if opts.sort != "" {
+			if err := u.SortItems(v, opts.sort); err != nil {
+				return err
+			}
+		} else if key := cfg.Get("list.sort"); key != "" {
+			// The default order only applies to the lists that have
+			// such a column.
+			_ = u.SortItems(v, key)
+		}
+
+		o.Columns = opts.columns
+		o.RowColor = opts.rowColor
+		if len(o.Columns) > 0 && format == u.FormatText {
+			format = u.FormatTable
+		}
+		if opts.table && !RootCmd.PersistentFlags().Changed("output") {
+			format = u.FormatTable
+		}
+		if len(o.Columns) == 0 && format == u.FormatTable {
+			o.Columns = opts.defaultColumns
+		}
+	}
+
+	if formatTemplate != nil {
+		return u.RenderTemplate(os.Stdout, formatTemplate, v)
+	}
+
+	if format == u.FormatText {
+		text()
+		return nil
+	}
+
+	return u.Render(os.Stdout, format, v, o)
+}
+
+// forEach calls `fn` with `ref`. If `all` is set, `ref` may be a name shared
+// by several items of type `entityType`, and `fn` is called with the id of
+// each one of them instead, and the changes are undone at once.
+func forEach(entityType string, ref string, all bool, fn func(ref string) error) error {
+	if !all {
+		return fn(ref)
+	}
+
+	ids, err := store.FindIds(entityType, ref)
+	if err != nil {
+		return err
+	}
+
+	end := models.StartBatch()
+	defer end()
+
+	for _, id := range ids {
+		if err := fn("#" + id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"fmt"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// Config returns the top level `config` command.
+func Config() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration",
+		Long:  configLong(),
+		Annotations: map[string]string{
+			noDatabase: "",
+		},
+	}
+
+	cmd.AddCommand(getConfig())
+	cmd.AddCommand(setConfig())
+	cmd.AddCommand(listConfig())
+	cmd.AddCommand(configPath())
+
+	return cmd
+}
+
+// configLong describes the configuration file and the available settings.
+func configLong() string {
+	s := `Gets and sets the settings kept in the configuration file. Every setting can
+be overridden with an environment variable, e.g. CLERK_LIST_SORT for list.sort.
+
+Settings:
+`
+	for _, k := range config.Keys {
+		s += fmt.Sprintf("  %-14s %s\n", k.Name, k.Usage)
+	}
+	s += fmt.Sprintf("  %-14s %s\n", "colors.<elem>", "color of an element of the output, like in $CLERK_COLORS")
+	s += fmt.Sprintf("  %-14s %s\n", "aliases.<name>", "command run by `clerk <name>`, e.g. \"task list --sort -created_at\"")
+
+	return s
+}
+
+func getConfig() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Prints the value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := cfg.Lookup(args[0])
+			if err != nil {
+				return err
+			}
+
+			return render(&s, func() {
+				fmt.Println(s.Value)
+			})
+		},
+	}
+}
+
+func setConfig() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Changes a setting",
+		Long:  "Changes a setting in the configuration file. An empty value removes the setting from the file.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cfg.Set(args[0], args[1])
+		},
+	}
+}
+
+func listConfig() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "Lists all the settings and where they come from",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings := cfg.List()
+
+			return render(settings, func() {
+				for _, s := range settings {
+					u.PrintElement(u.ElementInfo, fmt.Sprintf("%s = %q (%s)", s.Key, s.Value, s.Source))
+				}
+			})
+		},
+	}
+}
+
+func configPath() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Prints the path of the configuration file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println(cfg.Path())
+
+			return nil
+		},
+	}
+}
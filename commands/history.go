@@ -0,0 +1,127 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"fmt"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// History returns the top level `history` command.
+func History() *cobra.Command {
+	return &cobra.Command{
+		Use:     "history <task|note> <name-or-id>",
+		Short:   "Shows the revisions of a task or a note",
+		Long:    "Shows every revision of a task or a note given its name or id. The id should be prefixed by a '#'",
+		Aliases: []string{"hist"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revs, err := store.ListRevisions(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return render(revs, func() {
+				for _, r := range revs {
+					u.PrintElement(u.ElementRevision, r.String())
+				}
+			})
+		},
+	}
+}
+
+// Diff returns the top level `diff` command.
+func Diff() *cobra.Command {
+	var rev int
+
+	cmd := &cobra.Command{
+		Use:   "diff <task|note> <name-or-id>",
+		Short: "Compares a revision of a task or a note with its current version",
+		Long: `Compares a revision of a task or a note with its current version. By
+default, the revision before the latest one is used, which shows the latest change.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rev == 0 {
+				revs, err := store.ListRevisions(args[0], args[1])
+				if err != nil {
+					return err
+				}
+				if len(revs) < 2 {
+					return nil
+				}
+				rev = revs[len(revs)-2].Rev
+			}
+
+			r, err := store.GetRevision(args[0], args[1], rev)
+			if err != nil {
+				return err
+			}
+
+			current, err := store.CurrentRevision(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			u.PrintElement(u.ElementInfo, fmt.Sprintf("--- rev %d\n+++ current", r.Rev))
+			for _, l := range actions.Diff(r.Text(), current.Text()) {
+				switch l.Op {
+				case '-':
+					u.PrintElement(u.ElementRemoved, l.String())
+				case '+':
+					u.PrintElement(u.ElementAdded, l.String())
+				default:
+					fmt.Println(l.String())
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&rev, "rev", "r", 0, "revision to compare with the current version")
+
+	return cmd
+}
+
+// Revert returns the top level `revert` command.
+func Revert() *cobra.Command {
+	var rev int
+
+	cmd := &cobra.Command{
+		Use:   "revert <task|note> <name-or-id> --rev <N>",
+		Short: "Reverts a task or a note to a previous revision",
+		Long:  "Sets the name and the contents of a task or a note back to the ones of a previous revision. The revert is recorded as a new revision.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.RevertItem(args[0], args[1], rev)
+		},
+	}
+
+	cmd.Flags().IntVarP(&rev, "rev", "r", 0, "revision to revert to")
+	cmd.MarkFlagRequired("rev")
+
+	return cmd
+}
@@ -0,0 +1,124 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"strings"
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+// Journal returns the top level `journal` command.
+func Journal() *cobra.Command {
+	var date, template string
+
+	journal := &cobra.Command{
+		Use:     "journal [contents...]",
+		Aliases: []string{"j"},
+		Short:   "Shows or appends to today's journal note",
+		Long: `Shows the journal note of the day or, if contents are given, appends them
+to it. The journal note is created on first use, optionally from a template.
+Journal notes are ordinary notes named after their date (e.g. ` + actions.JournalPrefix + `2020-10-11).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			day, err := actions.ParseJournalDate(date, time.Now())
+			if err != nil {
+				return err
+			}
+
+			initial := func(name string) (string, error) {
+				if template == "" {
+					return "", nil
+				}
+				return expandTemplate(template, name, day)
+			}
+
+			if len(args) > 0 {
+				return actions.AppendJournal(
+					store,
+					day,
+					strings.Join(args, " "),
+					initial,
+				)
+			}
+
+			n, err := actions.OpenJournal(store, day, initial)
+			if err != nil {
+				return err
+			}
+
+			links, err := store.ListLinks(n.Type(), n.Id)
+			if err != nil {
+				return err
+			}
+
+			return render(&linkedNote{n, links}, func() {
+				u.PrintElement(u.ElementNote, withLinks(n.String(), links))
+			})
+		},
+	}
+
+	journal.Flags().StringVarP(&date, "date", "d", "today", "day of the journal note: today, yesterday, tomorrow or YYYY-MM-DD")
+	journal.Flags().StringVarP(&template, "template", "t", "", "name or id of the template used to create the journal note")
+
+	journal.AddCommand(listJournal())
+
+	return journal
+}
+
+func listJournal() *cobra.Command {
+	var month bool
+
+	cmd := &cobra.Command{
+		Use:     "list [YYYY-MM]",
+		Short:   "Lists the journal notes",
+		Long:    "Lists the journal notes, optionally only those of a given year (YYYY) or month (YYYY-MM).",
+		Aliases: []string{"ls"},
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var period string
+			if len(args) > 0 {
+				period = args[0]
+			} else if month {
+				period = time.Now().Format("2006-01")
+			}
+
+			notes, err := actions.ListJournal(store, period)
+			if err != nil {
+				return err
+			}
+
+			return render(notes, func() {
+				for _, n := range notes {
+					u.PrintElement(u.ElementNote, n.String())
+				}
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&month, "month", "m", false, "only list the notes of the current month")
+
+	return cmd
+}
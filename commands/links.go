@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"strings"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+const refHelp = "Items are referred to as <type>:<name> or #<type>:<id>, e.g. #task:3 or note:groceries"
+
+// Link returns the top level `link` command.
+func Link() *cobra.Command {
+	return &cobra.Command{
+		Use:     "link <ref> <ref>",
+		Short:   "Links a task or note to another task or note",
+		Long:    "Links a task or note to another task or note. " + refHelp,
+		Aliases: []string{"ln"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.AddLink(args[0], args[1], time.Now())
+		},
+	}
+}
+
+// Unlink returns the top level `unlink` command.
+func Unlink() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unlink <ref> <ref>",
+		Short: "Removes the link between two items",
+		Long:  "Removes the link between two items. " + refHelp,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.DeleteLink(args[0], args[1])
+		},
+	}
+}
+
+// linkedTask is a task along with the items linked to it.
+type linkedTask struct {
+	*models.TaskModel
+	Links []*models.LinkModel `json:"links"`
+}
+
+// linkedNote is a note along with the items linked to it.
+type linkedNote struct {
+	*models.NoteModel
+	Links []*models.LinkModel `json:"links"`
+}
+
+// withLinks appends the linked items, if there are any, to the printable
+// representation `s` of a task or a note.
+func withLinks(s string, links []*models.LinkModel) string {
+	if len(links) == 0 {
+		return s
+	}
+
+	var items []string
+	for _, l := range links {
+		items = append(items, l.String())
+	}
+
+	return strings.TrimSuffix(s, "\n") + "\n  Links: " + strings.Join(items, "; ") + "\n"
+}
@@ -0,0 +1,106 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// Log returns the top level `log` command.
+func Log() *cobra.Command {
+	var since, entity, user string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Shows the audit log of all the changes",
+		Long: `Shows who changed what, when and from where: every change to tasks,
+notes, links and templates is recorded along with the OS user, the host and
+the command that made it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if asJSON {
+				outputFormat = u.FormatJSON
+			}
+
+			f := models.AuditFilter{User: user}
+
+			if since != "" {
+				t, err := parseSince(since, time.Now())
+				if err != nil {
+					return err
+				}
+				f.Since = t
+			}
+
+			if entity != "" {
+				parts := strings.SplitN(strings.TrimPrefix(entity, "#"), ":", 2)
+				f.Type = parts[0]
+				if len(parts) == 2 {
+					f.EntityId = parts[1]
+				}
+			}
+
+			entries, err := store.ListAudit(f)
+			if err != nil {
+				return err
+			}
+
+			return render(entries, func() {
+				for _, e := range entries {
+					u.PrintElement(u.ElementInfo, e.String())
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "only show changes since a date (YYYY-MM-DD) or for a period of time (e.g. 2d, 1w, 12h)")
+	cmd.Flags().StringVarP(&entity, "entity", "e", "", "only show changes to a type of entity (task, note, link, template) or to an entity (e.g. task:3)")
+	cmd.Flags().StringVarP(&user, "user", "u", "", "only show changes made by an OS user")
+
+	// --json came before --output.
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the changes as JSON")
+	cmd.Flags().MarkDeprecated("json", "use --output json instead")
+
+	return cmd
+}
+
+// parseSince parses either a date or a period of time before `now`.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date or period of time %q", s)
+	}
+
+	return now.Add(-age), nil
+}
@@ -23,6 +23,7 @@
 package commands
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -44,49 +45,82 @@ func Notes() *cobra.Command {
 	notes.AddCommand(addNote())
 	notes.AddCommand(appendNote())
 	notes.AddCommand(showNote())
+	notes.AddCommand(renameNote())
 	notes.AddCommand(deleteNote())
 
 	return notes
 }
 
 func listNotes() *cobra.Command {
-	return &cobra.Command{
+	opts := listOptions{
+		table:          true,
+		defaultColumns: []string{"id", "name", "created_at"},
+	}
+
+	cmd := &cobra.Command{
 		Use:     "list",
 		Short:   "Lists all the existing notes",
 		Aliases: []string{"ls"},
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			notes, err := models.ListNotes(database)
+			notes, err := store.ListNotes()
 			if err != nil {
 				return err
 			}
 
-			for _, n := range notes {
-				u.PrintColor(n.String(), u.ColorCyan)
-			}
-
-			return nil
+			return renderList(notes, &opts, func() {
+				for _, n := range notes {
+					u.PrintElement(u.ElementNote, n.String())
+				}
+			})
 		},
 	}
+
+	opts.addFlags(cmd)
+
+	return cmd
 }
 
 func addNote() *cobra.Command {
-	return &cobra.Command{
+	var template string
+
+	cmd := &cobra.Command{
 		Use:     "add <name> <contents>...",
 		Short:   "Adds a new note",
+		Long:    "Adds a new note. With --template, the note starts with the expanded template and the contents are optional.",
 		Aliases: []string{"a"},
-		Args:    cobra.MinimumNArgs(2),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if template != "" {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := models.AddNote(
-				database,
-				args[0],
-				strings.Join(args[1:], " "),
-				time.Now(),
-			)
+			now := time.Now()
+			contents := strings.Join(args[1:], " ")
 
-			return err
+			if template == "" {
+				_, err := store.AddNote(args[0], contents, now)
+				return err
+			}
+
+			expanded, err := expandTemplate(template, args[0], now)
+			if err != nil {
+				return err
+			}
+
+			id, err := store.AddNote(args[0], expanded, now)
+			if err != nil || contents == "" {
+				return err
+			}
+
+			return store.AppendNote(fmt.Sprintf("#%d", id), contents)
 		},
 	}
+
+	cmd.Flags().StringVarP(&template, "template", "t", "", "name or id of the template to start the note from")
+
+	return cmd
 }
 
 func appendNote() *cobra.Command {
@@ -97,8 +131,7 @@ func appendNote() *cobra.Command {
 		Aliases: []string{"app"},
 		Args:    cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := models.AppendNote(
-				database,
+			err := store.AppendNote(
 				args[0],
 				strings.Join(args[1:], " "),
 			)
@@ -112,31 +145,59 @@ func showNote() *cobra.Command {
 	return &cobra.Command{
 		Use:     "show <name-or-id>",
 		Short:   "Shows the contents of a note",
-		Long:    "Shows the contents of a note given its name or id. The id should be prefixed by a '#'",
+		Long:    "Shows the contents of a note and its links given its name or id. The id should be prefixed by a '#'",
 		Aliases: []string{"sh"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			n, err := models.GetNote(database, args[0])
+			n, err := store.GetNote(args[0])
 			if err != nil {
 				return err
 			}
 
-			u.PrintColor(n.String(), u.ColorCyan)
+			links, err := store.ListLinks(n.Type(), n.Id)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			return render(&linkedNote{n, links}, func() {
+				u.PrintElement(u.ElementNote, withLinks(n.String(), links))
+			})
 		},
 	}
 }
 
 func deleteNote() *cobra.Command {
-	return &cobra.Command{
+	var all bool
+
+	cmd := &cobra.Command{
 		Use:     "del <name-or-id>",
-		Short:   "Deletes an existing note",
-		Long:    "Deletes an existing note given its name or id. The id should be prefixed by a '#'",
+		Short:   "Moves an existing note to the trash",
+		Long:    "Moves an existing note to the trash given its name or id. The id should be prefixed by a '#'",
 		Aliases: []string{"d"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return models.DeleteNote(database, args[0])
+			now := time.Now()
+
+			return forEach(models.NoteType, args[0], all, func(note string) error {
+				return store.DeleteNote(note, now)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, allFlagUsage)
+
+	return cmd
+}
+
+func renameNote() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rename <name-or-id> <new name>",
+		Short:   "Renames an existing note",
+		Long:    "Renames an existing note given its name or id. The id should be prefixed by a '#'. Wiki-links to the note ([[name]]) are updated as well.",
+		Aliases: []string{"mv"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.RenameNote(args[0], args[1])
 		},
 	}
 }
@@ -23,30 +23,105 @@
 package commands
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/internal/config"
 	d "github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/csixteen/clerk/pkg/client"
+	"github.com/csixteen/clerk/pkg/models"
 	"github.com/spf13/cobra"
 )
 
 var (
 	database *sql.DB
+	store    backend
+	cfg      *config.Config
+	remote   string
+
+	// lastEvent is the latest event in the local database before the
+	// command, to tell whether it changed anything.
+	lastEvent string
+
+	output         string
+	outputFormat   u.Format
+	format         string
+	formatTemplate *template.Template
+	color          string
 
 	RootCmd = &cobra.Command{
-		Use:   "clerk",
-		Short: "clerk is your command-line personal Jarvis.",
+		Use:           "clerk",
+		Short:         "clerk is your command-line personal Jarvis.",
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			outputFormat, err = u.ParseFormat(output)
+			if err != nil {
+				return err
+			}
+
+			if err := u.SetColorMode(color, os.Stdout); err != nil {
+				return err
+			}
+
+			if format != "" {
+				formatTemplate, err = u.ParseTemplate(format)
+				if err != nil {
+					return err
+				}
+			}
+
+			// The arguments are valid at this point, so errors from now
+			// on aren't usage errors.
+			cmd.SilenceUsage = true
+
+			if err := applyConfig(); err != nil {
+				return err
+			}
+
+			if !needsDatabase(cmd) {
+				return nil
+			}
+
+			if !cmd.Flags().Changed("remote") {
+				remote = cfg.Get("remote")
+			}
+
+			if remote != "" {
+				store = client.New(remote, cfg.Get("token"))
+				return nil
+			}
+
+			a := models.CurrentActor()
+			a.Command = cmd.CommandPath()
+
+			database, err = d.SetupDatabase(cfg.Get("database"))
+			if err != nil {
+				return err
+			}
+			store = newLocalBackend(database, a)
+			lastEvent, err = models.LatestEventId(database)
+
+			return err
+		},
 	}
 )
 
 func init() {
-	var err error
-
-	database, err = d.SetupDatabase()
-	if err != nil {
-		panic(err)
-	}
+	RootCmd.PersistentFlags().StringVarP(&output, "output", "o", string(u.FormatText), "output format: text, json, yaml, csv, tsv or table")
+	RootCmd.PersistentFlags().StringVar(&color, "color", "auto", "when to use colors: auto, always or never")
+	RootCmd.PersistentFlags().StringVar(&format, "format", "", "print each item with a Go template, e.g. '{{.Id}}\\t{{.Name}}\\t{{date .Due}}'")
+	RootCmd.PersistentFlags().StringVar(&remote, "remote", "", "URL of a clerk-server to use instead of the local database, e.g. http://localhost:8080")
 
 	addCommands()
 }
@@ -55,12 +130,116 @@ func addCommands() {
 	RootCmd.AddCommand(Notes())
 	RootCmd.AddCommand(Tasks())
 	RootCmd.AddCommand(Search())
+	RootCmd.AddCommand(Link())
+	RootCmd.AddCommand(Unlink())
+	RootCmd.AddCommand(Templates())
+	RootCmd.AddCommand(Journal())
+	RootCmd.AddCommand(Trash())
+	RootCmd.AddCommand(History())
+	RootCmd.AddCommand(Diff())
+	RootCmd.AddCommand(Revert())
+	RootCmd.AddCommand(Undo())
+	RootCmd.AddCommand(Redo())
+	RootCmd.AddCommand(Log())
+	RootCmd.AddCommand(Share())
+	RootCmd.AddCommand(Watch())
+	RootCmd.AddCommand(Webhook())
+	RootCmd.AddCommand(Config())
+}
+
+// noDatabase is the annotation of the commands that use neither the database
+// nor a clerk-server.
+const noDatabase = "no-database"
+
+func needsDatabase(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[noDatabase]; ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyConfig applies the settings that affect all the commands.
+func applyConfig() error {
+	models.SetDateFormat(cfg.Get("date_format"))
+	u.SetDateFormat(cfg.Get("date_format"))
+
+	// The colors in the configuration come first, so that $CLERK_COLORS
+	// overrides them.
+	var theme []string
+	for element, color := range cfg.Prefixed("colors.") {
+		theme = append(theme, element+"="+color)
+	}
+	sort.Strings(theme)
+	theme = append(theme, os.Getenv("CLERK_COLORS"))
+
+	return u.SetTheme(strings.Join(theme, ":"))
+}
+
+// expandAlias replaces the first argument with its definition if it's an
+// alias. Commands can't be redefined by aliases.
+func expandAlias(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	for _, c := range RootCmd.Commands() {
+		if c.Name() == args[0] || c.HasAlias(args[0]) {
+			return args
+		}
+	}
+
+	alias, ok := cfg.Prefixed("aliases.")[args[0]]
+	if !ok {
+		return args
+	}
+
+	return append(strings.Fields(alias), args[1:]...)
+}
+
+// webhookTimeout is how long the commands wait for the receivers of
+// webhooks, all of them together.
+const webhookTimeout = 5 * time.Second
+
+// deliverWebhooks sends the events to the webhooks of the local database,
+// and the deliveries that failed before if it's time to try again, but only
+// if the command changed something: the others shouldn't wait for webhooks.
+func deliverWebhooks() error {
+	latest, err := models.LatestEventId(database)
+	if err != nil || latest == lastEvent {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	return actions.DeliverWebhooks(ctx, database, new(sync.Mutex), http.DefaultClient, time.Now())
 }
 
 func Execute() {
-	defer database.Close()
-	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
+	path, err := config.Path()
+	if err == nil {
+		cfg, err = config.Load(path)
+	}
+
+	if err == nil {
+		RootCmd.SetArgs(expandAlias(os.Args[1:]))
+		err = RootCmd.Execute()
+	}
+
+	if database != nil {
+		// Without a server, the commands send the events to the webhooks.
+		if werr := deliverWebhooks(); werr != nil {
+			fmt.Fprintln(os.Stderr, "Warning: can't deliver the webhooks:", werr)
+		}
+
+		database.Close()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
 	}
 }
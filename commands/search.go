@@ -24,11 +24,10 @@ package commands
 
 import (
 	"fmt"
-	"log"
 	"strings"
 
 	u "github.com/csixteen/clerk/cmd/clerk/util"
-	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/csixteen/clerk/pkg/models"
 	"github.com/spf13/cobra"
 )
 
@@ -36,28 +35,62 @@ func highlightText(s string, query string) string {
 	return strings.ReplaceAll(
 		s,
 		query,
-		string(u.ColorRed)+query+string(u.ColorReset),
+		u.Colorize(query, u.ColorOf(u.ElementHighlight)),
 	)
 }
 
+// searchResult is a task or a note found by `search`.
+type searchResult struct {
+	Type     string   `json:"type"`
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Contents []string `json:"contents"`
+
+	result models.Result
+}
+
+func searchResults(results []models.Result) []*searchResult {
+	var res []*searchResult
+	for _, r := range results {
+		switch x := r.(type) {
+		case *models.TaskModel:
+			res = append(res, &searchResult{x.Type(), x.Id, x.Name, []string{x.Contents}, r})
+		case *models.NoteModel:
+			res = append(res, &searchResult{x.Type(), x.Id, x.Name, x.Contents, r})
+		}
+	}
+
+	return res
+}
+
 func Search() *cobra.Command {
-	return &cobra.Command{
+	var opts listOptions
+
+	cmd := &cobra.Command{
 		Use:     "search <query string...>",
 		Short:   "Search against all your notes and tasks",
 		Long:    "Quickly retrieve any notes and tasks that contain your search string",
 		Aliases: []string{"s"},
 		Args:    cobra.MinimumNArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			query := strings.Join(args, " ")
-			results, err := actions.Search(database, query)
+			results, err := store.Search(query)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 
-			for _, res := range results {
-				u.PrintColor(res.Type(), u.ColorCyan)
-				fmt.Println(highlightText(res.String(), query))
-			}
+			found := searchResults(results)
+
+			return renderList(found, &opts, func() {
+				for _, res := range found {
+					u.PrintElement(u.Element(res.Type), res.Type)
+					fmt.Println(highlightText(res.result.String(), query))
+				}
+			})
 		},
 	}
+
+	opts.addFlags(cmd)
+
+	return cmd
 }
@@ -0,0 +1,90 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/spf13/cobra"
+)
+
+// Share returns the top level `share` command.
+func Share() *cobra.Command {
+	share := &cobra.Command{
+		Use:   "share",
+		Short: "Manage who can see your tasks and notes on a clerk-server",
+		Long: `Share tasks and notes with other users of a clerk-server, who can then see
+and change them. Only the owner of an item can share it.`,
+	}
+
+	share.AddCommand(listShares())
+	share.AddCommand(addShare())
+	share.AddCommand(removeShare())
+
+	return share
+}
+
+func listShares() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list <task|note> <name-or-id>",
+		Short:   "Lists the users a task or a note is shared with",
+		Aliases: []string{"ls"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shares, err := store.ListShares(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			return render(shares, func() {
+				for _, s := range shares {
+					u.PrintElement(u.ElementInfo, s.String())
+				}
+			})
+		},
+	}
+}
+
+func addShare() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <task|note> <name-or-id> <user>",
+		Short: "Shares a task or a note with a user",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.ShareItem(args[0], args[1], args[2], time.Now())
+		},
+	}
+}
+
+func removeShare() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <task|note> <name-or-id> <user>",
+		Short:   "Stops sharing a task or a note with a user",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.UnshareItem(args[0], args[1], args[2])
+		},
+	}
+}
@@ -23,6 +23,8 @@
 package commands
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -41,94 +43,356 @@ func Tasks() *cobra.Command {
 	}
 
 	notes.AddCommand(listTasks())
+	notes.AddCommand(showTask())
 	notes.AddCommand(addTask())
 	notes.AddCommand(editTask())
+	notes.AddCommand(renameTask())
 	notes.AddCommand(deleteTask())
 	notes.AddCommand(completeTask())
+	notes.AddCommand(scheduleTask())
+	notes.AddCommand(prioritizeTask())
 
 	return notes
 }
 
 func listTasks() *cobra.Command {
-	return &cobra.Command{
+	opts := listOptions{
+		table:          true,
+		defaultColumns: []string{"id", "name", "contents", "due", "priority", "completed_at"},
+		rowColor:       taskRowColor,
+	}
+	var pending bool
+
+	cmd := &cobra.Command{
 		Use:     "list",
 		Short:   "Lists all the existing tasks",
 		Aliases: []string{"ls"},
 		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			tasks, err := models.ListTasks(database)
+			if !cmd.Flags().Changed("pending") {
+				var err error
+				pending, err = cfg.GetBool("list.pending")
+				if err != nil {
+					return err
+				}
+			}
+
+			tasks, err := store.ListTasks()
 			if err != nil {
 				return err
 			}
 
-			for _, t := range tasks {
-				u.PrintColor(t.String(), u.ColorYellow)
+			if pending {
+				tasks = pendingTasks(tasks)
 			}
 
-			return nil
+			now := time.Now()
+
+			return renderList(tasks, &opts, func() {
+				for _, t := range tasks {
+					u.PrintElement(taskElement(t, now), t.String())
+				}
+			})
 		},
 	}
+
+	opts.addFlags(cmd)
+	cmd.Flags().BoolVar(&pending, "pending", false, "only list the tasks that aren't completed (default from list.pending)")
+
+	return cmd
 }
 
-func addTask() *cobra.Command {
+// taskElement returns the element `t` is printed as: overdue tasks stand
+// out from the rest.
+func taskElement(t *models.TaskModel, now time.Time) u.Element {
+	if t.Overdue(now) {
+		return u.ElementOverdue
+	}
+
+	return u.ElementTask
+}
+
+// taskRowColor colors the overdue tasks of a table.
+func taskRowColor(item interface{}) u.Color {
+	if t, ok := item.(*models.TaskModel); ok && t.Overdue(time.Now()) {
+		return u.ColorOf(u.ElementOverdue)
+	}
+
+	return ""
+}
+
+// pendingTasks returns the tasks that aren't completed.
+func pendingTasks(tasks []*models.TaskModel) []*models.TaskModel {
+	var res []*models.TaskModel
+	for _, t := range tasks {
+		if t.CompletedAt.IsZero() {
+			res = append(res, t)
+		}
+	}
+
+	return res
+}
+
+func showTask() *cobra.Command {
 	return &cobra.Command{
+		Use:     "show <name-or-id>",
+		Short:   "Shows a task",
+		Long:    "Shows a task and its links given its name or id. The id should be prefixed by a '#'",
+		Aliases: []string{"sh"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := store.GetTask(args[0])
+			if err != nil {
+				return err
+			}
+
+			links, err := store.ListLinks(t.Type(), t.Id)
+			if err != nil {
+				return err
+			}
+
+			return render(&linkedTask{t, links}, func() {
+				u.PrintElement(taskElement(t, time.Now()), withLinks(t.String(), links))
+			})
+		},
+	}
+}
+
+func addTask() *cobra.Command {
+	var due string
+	var priority int
+
+	cmd := &cobra.Command{
 		Use:     "add <name> <contents>...",
 		Short:   "Adds a new task",
 		Aliases: []string{"a"},
 		Args:    cobra.MinimumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := models.AddTask(
-				database,
+			now := time.Now()
+
+			var dueAt time.Time
+			if due != "" {
+				var err error
+				if dueAt, err = parseDue(due, now); err != nil {
+					return err
+				}
+			}
+
+			// The due date and the priority are undone with the task.
+			end := models.StartBatch()
+			defer end()
+
+			id, err := store.AddTask(
 				args[0],
 				strings.Join(args[1:], " "),
-				time.Now(),
+				now,
 			)
+			if err != nil {
+				return err
+			}
 
-			return err
+			task := fmt.Sprintf("#%d", id)
+			if !dueAt.IsZero() {
+				if err := store.SetTaskDue(task, dueAt); err != nil {
+					return err
+				}
+			}
+			if priority != 0 {
+				return store.SetTaskPriority(task, priority)
+			}
+
+			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&due, "due", "", "when the task is due, e.g. 2020-10-12, \"2020-10-12 18:00\" or 3d")
+	cmd.Flags().IntVarP(&priority, "priority", "p", 0, "priority of the task, the higher the more urgent")
+
+	return cmd
+}
+
+// dueLayouts are the layouts of the dates understood by parseDue.
+var dueLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// parseDue parses a due date given as a local date, with or without the time
+// of the day, or as a duration from `now` as understood by parseAge. A task
+// due on a day is due at the end of that day.
+func parseDue(s string, now time.Time) (time.Time, error) {
+	for _, layout := range dueLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			t = t.Add(24*time.Hour - time.Minute)
+		}
+
+		return t, nil
+	}
+
+	d, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date %q", s)
+	}
+
+	return now.Add(d), nil
 }
 
 func editTask() *cobra.Command {
-	return &cobra.Command{
-		Use:     "edit <name-or-id> <new contents>...",
-		Short:   "Replace the contents of a task",
-		Long:    "Replaces the contents of an existing task given its name or id. The id should be prefixed by a '#'",
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "edit <name-or-id> [<new contents>...]",
+		Short: "Replace the contents of a task",
+		Long: `Replaces the contents of an existing task given its name or id. The id should be prefixed by a '#'.
+Without new contents, the task is opened in the editor set in the configuration, $VISUAL or $EDITOR.`,
 		Aliases: []string{"e"},
-		Args:    cobra.MinimumNArgs(2),
+		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := models.EditTask(
-				database,
-				args[0],
-				strings.Join(args[1:], " "),
-			)
+			contents := strings.Join(args[1:], " ")
+
+			if len(args) == 1 {
+				if all {
+					return fmt.Errorf("the new contents are required with --all")
+				}
 
-			return err
+				t, err := store.GetTask(args[0])
+				if err != nil {
+					return err
+				}
+
+				contents, err = editText(t.Contents)
+				if err != nil {
+					return err
+				}
+
+				if contents == t.Contents {
+					return nil
+				}
+			}
+
+			return forEach(models.TaskType, args[0], all, func(task string) error {
+				return store.EditTask(task, contents)
+			})
 		},
 	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, allFlagUsage)
+
+	return cmd
 }
 
 func deleteTask() *cobra.Command {
-	return &cobra.Command{
+	var all bool
+
+	cmd := &cobra.Command{
 		Use:     "del <name-or-id>",
-		Short:   "Deletes an existing task",
-		Long:    "Deletes an existing task given its name or id. The id should be prefixed by a '#'",
+		Short:   "Moves an existing task to the trash",
+		Long:    "Moves an existing task to the trash given its name or id. The id should be prefixed by a '#'",
 		Aliases: []string{"d"},
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return models.DeleteTask(database, args[0])
+			now := time.Now()
+
+			return forEach(models.TaskType, args[0], all, func(task string) error {
+				return store.DeleteTask(task, now)
+			})
 		},
 	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, allFlagUsage)
+
+	return cmd
 }
 
 func completeTask() *cobra.Command {
-	return &cobra.Command{
+	var all bool
+
+	cmd := &cobra.Command{
 		Use:   "done <name-or-id>",
 		Short: "Marks an existing task as completed",
 		Long:  "Marks an existing task as completed given its name or id. The id should be prefixed by a '#'",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return models.CompleteTask(database, args[0], time.Now())
+			now := time.Now()
+
+			return forEach(models.TaskType, args[0], all, func(task string) error {
+				return store.CompleteTask(task, now)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, allFlagUsage)
+
+	return cmd
+}
+
+func renameTask() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rename <name-or-id> <new name>",
+		Short:   "Renames an existing task",
+		Long:    "Renames an existing task given its name or id. The id should be prefixed by a '#'.",
+		Aliases: []string{"mv"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.RenameTask(args[0], args[1])
+		},
+	}
+}
+
+func scheduleTask() *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "due <name-or-id> <date>",
+		Short: "Sets when an existing task is due",
+		Long: `Sets when an existing task is due given its name or id. The id should be prefixed by a '#'.
+The date is either a day (2020-10-12), a day and a time (2020-10-12 18:00) or a duration from now (3d, 1w, 12h).
+"none" clears the due date.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var due time.Time
+			if args[1] != "none" {
+				var err error
+				if due, err = parseDue(args[1], time.Now()); err != nil {
+					return err
+				}
+			}
+
+			return forEach(models.TaskType, args[0], all, func(task string) error {
+				return store.SetTaskDue(task, due)
+			})
 		},
 	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, allFlagUsage)
+
+	return cmd
+}
+
+func prioritizeTask() *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "priority <name-or-id> <priority>",
+		Short: "Sets the priority of an existing task",
+		Long: `Sets the priority of an existing task given its name or id. The id should be prefixed by a '#'.
+The higher the priority, the more urgent the task. 0 means no priority.`,
+		Aliases: []string{"prio"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			priority, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid priority %q", args[1])
+			}
+
+			return forEach(models.TaskType, args[0], all, func(task string) error {
+				return store.SetTaskPriority(task, priority)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, allFlagUsage)
+
+	return cmd
 }
@@ -0,0 +1,161 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// Templates returns the top level `template` command.
+func Templates() *cobra.Command {
+	templates := &cobra.Command{
+		Use:     "template",
+		Aliases: []string{"tpl"},
+		Short:   "Manage your note templates",
+		Long: `Add, list, show or delete note templates. Templates may contain the
+following placeholders:
+
+  {{date}}             today's date, optionally with a Go layout: {{date "Jan 2"}}
+  {{time}}             the current time
+  {{name}}             the name of the note being created
+  {{input "Prompt"}}   asks for a value when the note is created`,
+	}
+
+	templates.AddCommand(listTemplates())
+	templates.AddCommand(addTemplate())
+	templates.AddCommand(showTemplate())
+	templates.AddCommand(deleteTemplate())
+
+	return templates
+}
+
+func listTemplates() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "Lists all the existing templates",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			templates, err := store.ListTemplates()
+			if err != nil {
+				return err
+			}
+
+			return render(templates, func() {
+				for _, t := range templates {
+					u.PrintElement(u.ElementTemplate, t.String())
+				}
+			})
+		},
+	}
+}
+
+func addTemplate() *cobra.Command {
+	return &cobra.Command{
+		Use:     "add <name> [contents...]",
+		Short:   "Adds a new template",
+		Long:    "Adds a new template. If no contents are given, they're read from the standard input.",
+		Aliases: []string{"a"},
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			contents := strings.Join(args[1:], " ")
+			if contents == "" {
+				b, err := ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				contents = strings.TrimSuffix(string(b), "\n")
+			}
+
+			_, err := store.AddTemplate(args[0], contents, time.Now())
+
+			return err
+		},
+	}
+}
+
+func showTemplate() *cobra.Command {
+	return &cobra.Command{
+		Use:     "show <name-or-id>",
+		Short:   "Shows the contents of a template",
+		Long:    "Shows the contents of a template given its name or id. The id should be prefixed by a '#'",
+		Aliases: []string{"sh"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := store.GetTemplate(args[0])
+			if err != nil {
+				return err
+			}
+
+			return render(t, func() {
+				u.PrintElement(u.ElementTemplate, t.String())
+			})
+		},
+	}
+}
+
+func deleteTemplate() *cobra.Command {
+	return &cobra.Command{
+		Use:     "del <name-or-id>",
+		Short:   "Deletes an existing template",
+		Long:    "Deletes an existing template given its name or id. The id should be prefixed by a '#'",
+		Aliases: []string{"d"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.DeleteTemplate(args[0])
+		},
+	}
+}
+
+// promptInput asks for the value of an {{input}} placeholder.
+func promptInput(prompt string) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	s, err := stdin.ReadString('\n')
+	if err != nil && s == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(s, "\r\n"), nil
+}
+
+// expandTemplate expands the template with the given name or id for a new
+// note called `name`.
+func expandTemplate(template string, name string, t time.Time) (string, error) {
+	tmpl, err := store.GetTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	return actions.ExpandTemplate(tmpl.Contents, name, t, promptInput)
+}
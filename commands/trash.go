@@ -0,0 +1,134 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/spf13/cobra"
+)
+
+// Trash returns the top level `trash` command.
+func Trash() *cobra.Command {
+	trash := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage deleted tasks and notes",
+		Long:  "List, restore or permanently delete the tasks and notes in the trash.",
+	}
+
+	trash.AddCommand(listTrash())
+	trash.AddCommand(restoreTrash())
+	trash.AddCommand(emptyTrash())
+
+	return trash
+}
+
+func listTrash() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the tasks and notes in the trash",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			items, err := store.ListTrash()
+			if err != nil {
+				return err
+			}
+
+			return render(items, func() {
+				for _, t := range items {
+					u.PrintElement(u.ElementInfo, t.String())
+				}
+			})
+		},
+	}
+}
+
+func restoreTrash() *cobra.Command {
+	return &cobra.Command{
+		Use:     "restore <task|note> <id>",
+		Short:   "Restores a task or a note from the trash",
+		Aliases: []string{"r"},
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.RestoreItem(args[0], args[1])
+		},
+	}
+}
+
+func emptyTrash() *cobra.Command {
+	var olderThan string
+
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently deletes the tasks and notes in the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var before time.Time
+			if olderThan != "" {
+				age, err := parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+				before = time.Now().Add(-age)
+			}
+
+			n, err := store.EmptyTrash(before)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%d item(s) permanently deleted\n", n)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "only delete items that have been in the trash for longer than this (e.g. 30d, 2w, 12h)")
+
+	return cmd
+}
+
+// parseAge parses a duration that, besides the units understood by
+// time.ParseDuration, may be expressed in days (30d) or weeks (2w).
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,105 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/spf13/cobra"
+)
+
+// Undo returns the top level `undo` command.
+func Undo() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [N]",
+		Short: "Undoes the last N changes (1 by default)",
+		Long: `Undoes the last N changes made to tasks and notes (adding, editing,
+appending, renaming, deleting, completing, ...). All of them are undone
+at once, or none is.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := operationCount(args)
+			if err != nil {
+				return err
+			}
+
+			ops, err := store.Undo(n, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return render(ops, func() {
+				fmt.Println("Undone:")
+				for _, o := range ops {
+					u.PrintElement(u.ElementInfo, o.String())
+				}
+			})
+		},
+	}
+}
+
+// Redo returns the top level `redo` command.
+func Redo() *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo [N]",
+		Short: "Redoes the last N changes that were undone (1 by default)",
+		Long: `Redoes the last N changes that were undone. Changes can't be redone
+after making new ones.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := operationCount(args)
+			if err != nil {
+				return err
+			}
+
+			ops, err := store.Redo(n, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return render(ops, func() {
+				fmt.Println("Redone:")
+				for _, o := range ops {
+					u.PrintElement(u.ElementInfo, o.String())
+				}
+			})
+		},
+	}
+}
+
+// operationCount returns the number of changes to undo or redo.
+func operationCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of changes %q", args[0])
+	}
+
+	return n, nil
+}
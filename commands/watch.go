@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"context"
+	"strings"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// Watch returns the top level `watch` command.
+func Watch() *cobra.Command {
+	var from string
+	var events []string
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Prints the changes to tasks and notes as they happen",
+		Long: `Prints the changes to tasks and notes as they happen, until interrupted.
+Events are named after the type of the item and what happened to it:
+task.created, task.updated, task.completed, task.deleted, note.created, and
+so on. With --remote, it tails the event stream of the server, and only shows
+the items the user can access.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.Watch(context.Background(), from, func(e *models.EventModel) error {
+				if !matchEvent(e, events) {
+					return nil
+				}
+
+				return render(e, func() {
+					u.PrintElement(u.ElementInfo, e.String())
+				})
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "start after the event with this id, instead of with the next one")
+	cmd.Flags().StringSliceVarP(&events, "event", "e", nil, "only show these events (e.g. task.completed) or the events about a type of item (task, note)")
+
+	return cmd
+}
+
+// matchEvent reports whether `e` is one of `events`, which are either event
+// names or types of items. An empty list matches all the events.
+func matchEvent(e *models.EventModel, events []string) bool {
+	if len(events) == 0 {
+		return true
+	}
+
+	for _, name := range events {
+		if e.Is(strings.TrimSpace(name)) {
+			return true
+		}
+	}
+
+	return false
+}
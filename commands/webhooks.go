@@ -0,0 +1,138 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package commands
+
+import (
+	"time"
+
+	u "github.com/csixteen/clerk/cmd/clerk/util"
+	"github.com/spf13/cobra"
+)
+
+// Webhook returns the top level `webhook` command.
+func Webhook() *cobra.Command {
+	webhook := &cobra.Command{
+		Use:   "webhook",
+		Short: "Manage the webhooks that are sent the changes to tasks and notes",
+		Long: `Webhooks are URLs that are sent the events about tasks and notes, as
+"clerk watch" prints them, in JSON POST requests. Each request is signed with
+the secret of the webhook: the X-Clerk-Signature header is "sha256=" followed
+by the HMAC-SHA256 of the body in hex. Failed deliveries are attempted again
+later, up to 6 times.
+
+With --remote, the server sends the events. Otherwise, clerk sends them after
+each command.`,
+	}
+
+	webhook.AddCommand(listWebhooks())
+	webhook.AddCommand(addWebhook())
+	webhook.AddCommand(removeWebhook())
+	webhook.AddCommand(listDeliveries())
+
+	return webhook
+}
+
+func listWebhooks() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the webhooks",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			webhooks, err := store.ListWebhooks()
+			if err != nil {
+				return err
+			}
+
+			return render(webhooks, func() {
+				for _, w := range webhooks {
+					u.PrintElement(u.ElementInfo, w.String())
+				}
+			})
+		},
+	}
+}
+
+func addWebhook() *cobra.Command {
+	var event, url, secret string
+
+	cmd := &cobra.Command{
+		Use:   "add --event <event> --url <url>",
+		Short: "Adds a webhook and prints its secret",
+		Long: `Adds a webhook that is sent an event (e.g. task.completed), the events
+about a type of item (task or note) or all of them (*), from now on. The
+secret that signs the requests is printed, unless it's given with --secret.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w, err := store.AddWebhook(event, url, secret, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return render(w, func() {
+				u.PrintElement(u.ElementInfo, w.String())
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&event, "event", "e", "", "event to send, e.g. task.completed, task or *")
+	cmd.Flags().StringVarP(&url, "url", "u", "", "URL to send the events to")
+	cmd.Flags().StringVar(&secret, "secret", "", "secret that signs the requests (default: a random one)")
+	cmd.MarkFlagRequired("event")
+	cmd.MarkFlagRequired("url")
+
+	return cmd
+}
+
+func removeWebhook() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <id>",
+		Short:   "Removes a webhook and its deliveries",
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return store.DeleteWebhook(args[0])
+		},
+	}
+}
+
+func listDeliveries() *cobra.Command {
+	return &cobra.Command{
+		Use:   "deliveries <id>",
+		Short: "Shows the deliveries of a webhook",
+		Long:  "Shows the deliveries of a webhook, oldest first, and their status: pending,\ndelivered or failed.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deliveries, err := store.ListDeliveries(args[0])
+			if err != nil {
+				return err
+			}
+
+			return render(deliveries, func() {
+				for _, d := range deliveries {
+					u.PrintElement(u.ElementInfo, d.String())
+				}
+			})
+		},
+	}
+}
@@ -0,0 +1,333 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package config loads and saves the settings of clerk, which are kept in a
+// TOML file and can be overridden with environment variables.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Key is a setting.
+type Key struct {
+	Name    string
+	Default string
+	Usage   string
+	Bool    bool
+}
+
+// Keys lists the settings. Besides these, `colors.<element>` sets the color
+// of an element of the output and `aliases.<name>` defines an alias.
+var Keys = []Key{
+	{Name: "database", Usage: "path of the database (default: ~/.clerk.db)"},
+	{Name: "remote", Usage: "URL of a clerk-server to use instead of the database"},
+	{Name: "token", Usage: "API token of the clerk-server, see clerk-server token create"},
+	{Name: "postgres", Usage: "DSN of a PostgreSQL database for the tasks and notes of clerk-server"},
+	{Name: "date_format", Default: "2006-01-02 15:04:05", Usage: "Go layout of the dates in the output"},
+	{Name: "editor", Usage: "command used to edit tasks (default: $VISUAL or $EDITOR)"},
+	{Name: "list.sort", Usage: "default --sort of task list, note list and search"},
+	{Name: "list.pending", Default: "false", Usage: "only list the pending tasks by default", Bool: true},
+}
+
+// Prefixes of the settings whose names are chosen by the user.
+var Prefixes = []string{"colors.", "aliases."}
+
+// Sources of the values of the settings.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Setting is the value of a setting and where it comes from.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Config holds the settings read from a configuration file.
+type Config struct {
+	path   string
+	values map[string]string
+}
+
+// Path returns the path of the configuration file: $CLERK_CONFIG if it's set,
+// or config.toml in the clerk directory under $XDG_CONFIG_HOME (~/.config by
+// default).
+func Path() (string, error) {
+	if path := os.Getenv("CLERK_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "clerk", "config.toml"), nil
+}
+
+// Load reads the configuration file at `path`. A missing file is the same
+// as an empty one.
+func Load(path string) (*Config, error) {
+	c := &Config{path: path, values: map[string]string{}}
+
+	var tree map[string]interface{}
+	_, err := toml.DecodeFile(path, &tree)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	flatten("", tree, c.values)
+
+	for name := range c.values {
+		if err := checkKey(name); err != nil {
+			return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+		}
+	}
+
+	return c, nil
+}
+
+// flatten turns nested tables into dotted keys.
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for k, v := range tree {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			flatten(prefix+k+".", x, values)
+		case []interface{}:
+			var items []string
+			for _, item := range x {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[prefix+k] = strings.Join(items, ",")
+		default:
+			values[prefix+k] = fmt.Sprint(x)
+		}
+	}
+}
+
+// Path returns the path of the configuration file.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// lookupKey returns the definition of the setting called `name`, or nil if
+// its name is chosen by the user.
+func lookupKey(name string) *Key {
+	for i := range Keys {
+		if Keys[i].Name == name {
+			return &Keys[i]
+		}
+	}
+
+	return nil
+}
+
+func checkKey(name string) error {
+	if lookupKey(name) != nil {
+		return nil
+	}
+
+	for _, p := range Prefixes {
+		if strings.HasPrefix(name, p) && len(name) > len(p) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown setting %q", name)
+}
+
+// EnvName returns the environment variable that overrides a setting, e.g.
+// CLERK_LIST_SORT for `list.sort`.
+func EnvName(name string) string {
+	return "CLERK_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+// Lookup returns the value of a setting, which is taken from the environment,
+// the configuration file or the default value, in this order.
+func (c *Config) Lookup(name string) (Setting, error) {
+	if err := checkKey(name); err != nil {
+		return Setting{}, err
+	}
+
+	if v, ok := os.LookupEnv(EnvName(name)); ok {
+		return Setting{name, v, SourceEnv}, nil
+	}
+
+	if v, ok := c.values[name]; ok {
+		return Setting{name, v, SourceFile}, nil
+	}
+
+	s := Setting{Key: name, Source: SourceDefault}
+	if k := lookupKey(name); k != nil {
+		s.Value = k.Default
+	}
+
+	return s, nil
+}
+
+// Get returns the value of a setting, or an empty string if it's unknown.
+func (c *Config) Get(name string) string {
+	s, _ := c.Lookup(name)
+
+	return s.Value
+}
+
+// GetBool returns the value of a boolean setting.
+func (c *Config) GetBool(name string) (bool, error) {
+	v := c.Get(name)
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for %s, expected true or false", v, name)
+	}
+
+	return b, nil
+}
+
+// Prefixed returns the settings whose names start with `prefix`, without the
+// prefix, e.g. all the aliases.
+func (c *Config) Prefixed(prefix string) map[string]string {
+	res := map[string]string{}
+	for name, v := range c.values {
+		if strings.HasPrefix(name, prefix) {
+			res[strings.TrimPrefix(name, prefix)] = v
+		}
+	}
+
+	envPrefix := EnvName(prefix)
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if strings.HasPrefix(parts[0], envPrefix) && len(parts[0]) > len(envPrefix) {
+			res[strings.ToLower(strings.TrimPrefix(parts[0], envPrefix))] = parts[1]
+		}
+	}
+
+	return res
+}
+
+// List returns all the settings, sorted by name.
+func (c *Config) List() []Setting {
+	names := map[string]bool{}
+	for _, k := range Keys {
+		names[k.Name] = true
+	}
+	for name := range c.values {
+		names[name] = true
+	}
+	for _, p := range Prefixes {
+		for name := range c.Prefixed(p) {
+			names[p+name] = true
+		}
+	}
+
+	var res []Setting
+	for name := range names {
+		s, _ := c.Lookup(name)
+		res = append(res, s)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+
+	return res
+}
+
+// Set changes a setting and saves the configuration file, creating it if it
+// doesn't exist. An empty value removes the setting from the file.
+func (c *Config) Set(name string, value string) error {
+	if err := checkKey(name); err != nil {
+		return err
+	}
+
+	if k := lookupKey(name); k != nil && k.Bool && value != "" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s, expected true or false", value, name)
+		}
+	}
+
+	if value == "" {
+		delete(c.values, name)
+	} else {
+		c.values[name] = value
+	}
+
+	return c.save()
+}
+
+// save writes the settings to the configuration file.
+func (c *Config) save() error {
+	tree := map[string]interface{}{}
+	for name, v := range c.values {
+		var value interface{} = v
+		if k := lookupKey(name); k != nil && k.Bool {
+			value, _ = strconv.ParseBool(v)
+		}
+
+		// Nest the dotted keys, e.g. list.sort becomes `sort` in [list].
+		parts := strings.Split(name, ".")
+		t := tree
+		for _, p := range parts[:len(parts)-1] {
+			sub, ok := t[p].(map[string]interface{})
+			if !ok {
+				sub = map[string]interface{}{}
+				t[p] = sub
+			}
+			t = sub
+		}
+		t[parts[len(parts)-1]] = value
+	}
+
+	// The file holds the API token, so only its owner can read it.
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(tree); err != nil {
+		return err
+	}
+
+	// WriteFile only sets the permissions of new files, so the ones of files
+	// written by older versions are tightened first.
+	if err := os.Chmod(c.path, 0600); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, []byte(b.String()), 0600)
+}
@@ -0,0 +1,143 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "config.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2006-01-02 15:04:05", c.Get("date_format"))
+	assert.Equal(t, "", c.Get("editor"))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := ioutil.WriteFile(path, []byte(`
+database = "~/clerk.db"
+
+[list]
+sort = "-created_at"
+pending = true
+
+[aliases]
+tl = "task list"
+`), 0644)
+	assert.NoError(t, err)
+
+	c, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "~/clerk.db", c.Get("database"))
+	assert.Equal(t, "-created_at", c.Get("list.sort"))
+	assert.Equal(t, map[string]string{"tl": "task list"}, c.Prefixed("aliases."))
+
+	pending, err := c.GetBool("list.pending")
+	assert.NoError(t, err)
+	assert.True(t, pending)
+
+	s, err := c.Lookup("list.sort")
+	assert.NoError(t, err)
+	assert.Equal(t, Setting{"list.sort", "-created_at", SourceFile}, s)
+}
+
+func TestLoadUnknownSetting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("[list]\norder = \"id\"\n"), 0644))
+
+	_, err := Load(path)
+	assert.EqualError(t, err, `invalid configuration file `+path+`: unknown setting "list.order"`)
+}
+
+func TestEnvOverride(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "config.toml"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "CLERK_LIST_SORT", EnvName("list.sort"))
+
+	os.Setenv("CLERK_LIST_SORT", "name")
+	os.Setenv("CLERK_COLORS_TASK", "red")
+	defer os.Unsetenv("CLERK_LIST_SORT")
+	defer os.Unsetenv("CLERK_COLORS_TASK")
+
+	s, err := c.Lookup("list.sort")
+	assert.NoError(t, err)
+	assert.Equal(t, Setting{"list.sort", "name", SourceEnv}, s)
+	assert.Equal(t, map[string]string{"task": "red"}, c.Prefixed("colors."))
+}
+
+func TestSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clerk", "config.toml")
+	c, err := Load(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Set("list.pending", "true"))
+	assert.NoError(t, c.Set("aliases.tl", "task list"))
+	assert.NoError(t, c.Set("editor", "nano"))
+	assert.NoError(t, c.Set("editor", ""))
+
+	assert.EqualError(t, c.Set("list.pending", "maybe"), `invalid value "maybe" for list.pending, expected true or false`)
+	assert.EqualError(t, c.Set("unknown", "value"), `unknown setting "unknown"`)
+	assert.EqualError(t, c.Set("aliases.", "value"), `unknown setting "aliases."`)
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "[aliases]\n  tl = \"task list\"\n\n[list]\n  pending = true\n", string(b))
+
+	c, err = Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "true", c.Get("list.pending"))
+	assert.Equal(t, "", c.Get("editor"))
+	assert.Equal(t, "task list", c.Prefixed("aliases.")["tl"])
+}
+
+func TestSavePermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "clerk")
+	path := filepath.Join(dir, "config.toml")
+	c, err := Load(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Set("token", "secret"))
+
+	info, err := os.Stat(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Files written by older versions are tightened too.
+	assert.NoError(t, os.Chmod(path, 0644))
+	assert.NoError(t, c.Set("editor", "nano"))
+
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
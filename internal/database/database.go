@@ -1,24 +1,44 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"os/user"
 	"path"
-
-	_ "github.com/mattn/go-sqlite3"
+	"strings"
 )
 
-func SetupDatabase() (*sql.DB, error) {
-	var err error
-	var dbFile string
-
+// DefaultPath returns the path of the database unless configured otherwise.
+func DefaultPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	return path.Join(homeDir, ".clerk.db"), nil
+}
+
+// SetupDatabase opens the database at `dbFile`, or at the default path if
+// it's empty, creating it and its tables if needed. A leading `~/` stands for
+// the home directory.
+func SetupDatabase(dbFile string) (*sql.DB, error) {
+	var err error
+
+	if dbFile == "" {
+		dbFile, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	} else if strings.HasPrefix(dbFile, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dbFile = path.Join(homeDir, dbFile[2:])
 	}
-	dbFile = path.Join(homeDir, ".clerk.db")
 
 	if _, err := os.Stat(dbFile); os.IsNotExist(err) {
 		log.Println("Database not found. Creating...")
@@ -29,15 +49,17 @@ func SetupDatabase() (*sql.DB, error) {
 		file.Close()
 	}
 
-	db, err := sql.Open(
-		"sqlite3",
-		fmt.Sprintf("%s?_foreign_keys=true", dbFile),
-	)
+	db := sql.OpenDB(&timedConnector{
+		dsn:    fmt.Sprintf("%s?_foreign_keys=true", dbFile),
+		driver: new(timedDriver),
+	})
+
+	err = createTables(db)
 	if err != nil {
 		return nil, err
 	}
 
-	err = createTables(db)
+	err = migrateTables(db)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +67,19 @@ func SetupDatabase() (*sql.DB, error) {
 	return db, nil
 }
 
+// Check checks that the tables of the tasks and notes in `db` can be read.
+func Check(ctx context.Context, db *sql.DB) error {
+	for _, table := range []string{"tasks", "notes"} {
+		var n int
+		err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("can't read the %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
 func createTables(db *sql.DB) error {
 	// Tasks table
 	createTasksTable := `CREATE TABLE IF NOT EXISTS tasks (
@@ -52,7 +87,11 @@ func createTables(db *sql.DB) error {
 		name VARCHAR(64),
 		contents TEXT,
 		created_at VARCHAR(64),
-		completed_at VARCHAR(64)
+		completed_at VARCHAR(64),
+		deleted_at VARCHAR(64),
+		owner VARCHAR(64),
+		due VARCHAR(64),
+		priority INTEGER NOT NULL DEFAULT 0
 	);`
 
 	stmt, err := db.Prepare(createTasksTable)
@@ -68,7 +107,9 @@ func createTables(db *sql.DB) error {
 	createNotesTable := `CREATE TABLE IF NOT EXISTS notes (
 		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
 		name VARCHAR(64),
-		created_at VARCHAR(64)
+		created_at VARCHAR(64),
+		deleted_at VARCHAR(64),
+		owner VARCHAR(64)
 	);`
 
 	stmt, err = db.Prepare(createNotesTable)
@@ -95,6 +136,352 @@ func createTables(db *sql.DB) error {
 	}
 
 	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Templates table
+	createTemplatesTable := `CREATE TABLE IF NOT EXISTS templates (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(64) NOT NULL UNIQUE,
+		contents TEXT,
+		created_at VARCHAR(64)
+	);`
+
+	stmt, err = db.Prepare(createTemplatesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Revisions table. It's append-only and keeps the history of items
+	// that have been permanently deleted.
+	createRevisionsTable := `CREATE TABLE IF NOT EXISTS revisions (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		entity_type VARCHAR(16) NOT NULL,
+		entity_id INTEGER NOT NULL,
+		rev INTEGER NOT NULL,
+		operation VARCHAR(16) NOT NULL,
+		name VARCHAR(64),
+		contents TEXT,
+		completed_at VARCHAR(64),
+		deleted_at VARCHAR(64),
+		created_at VARCHAR(64),
+		due VARCHAR(64),
+		priority INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (entity_type, entity_id, rev)
+	);`
+
+	stmt, err = db.Prepare(createRevisionsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Operations table. It's the journal of the changes made to tasks and
+	// notes, which can be undone and redone. Operations made in the same
+	// change share the same batch.
+	createOperationsTable := `CREATE TABLE IF NOT EXISTS operations (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		batch INTEGER NOT NULL,
+		entity_type VARCHAR(16) NOT NULL,
+		entity_id INTEGER NOT NULL,
+		operation VARCHAR(16) NOT NULL,
+		before TEXT,
+		after TEXT,
+		undone INTEGER NOT NULL DEFAULT 0,
+		created_at VARCHAR(64),
+		user VARCHAR(64)
+	);`
+
+	stmt, err = db.Prepare(createOperationsTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Audit table. It's append-only and records who changed what, and
+	// from where.
+	createAuditTable := `CREATE TABLE IF NOT EXISTS audit (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		created_at VARCHAR(64) NOT NULL,
+		user VARCHAR(64),
+		host VARCHAR(255),
+		command VARCHAR(255),
+		entity_type VARCHAR(16) NOT NULL,
+		entity_id INTEGER NOT NULL,
+		operation VARCHAR(16) NOT NULL,
+		before TEXT,
+		after TEXT
+	);`
+
+	stmt, err = db.Prepare(createAuditTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Links table. Links can't reference tasks and notes through foreign
+	// keys, so the triggers below clean them up when either side is deleted.
+	createLinksTable := `CREATE TABLE IF NOT EXISTS links (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		from_type VARCHAR(16) NOT NULL,
+		from_id INTEGER NOT NULL,
+		to_type VARCHAR(16) NOT NULL,
+		to_id INTEGER NOT NULL,
+		created_at VARCHAR(64),
+		UNIQUE (from_type, from_id, to_type, to_id)
+	);`
+
+	stmt, err = db.Prepare(createLinksTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Tokens table. Only the SHA-256 hashes of the API tokens of
+	// clerk-server are stored.
+	createTokensTable := `CREATE TABLE IF NOT EXISTS tokens (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		user VARCHAR(64) NOT NULL,
+		hash VARCHAR(64) NOT NULL UNIQUE,
+		created_at VARCHAR(64),
+		revoked_at VARCHAR(64)
+	);`
+
+	stmt, err = db.Prepare(createTokensTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Shares table. Tasks and notes can be seen by their owner and by the
+	// users they're shared with.
+	createSharesTable := `CREATE TABLE IF NOT EXISTS shares (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		entity_type VARCHAR(16) NOT NULL,
+		entity_id INTEGER NOT NULL,
+		user VARCHAR(64) NOT NULL,
+		created_at VARCHAR(64),
+		UNIQUE (entity_type, entity_id, user)
+	);`
+
+	stmt, err = db.Prepare(createSharesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Webhooks table. Each webhook keeps the id of the last event it has
+	// queued deliveries for.
+	createWebhooksTable := `CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		event VARCHAR(64) NOT NULL,
+		url TEXT NOT NULL,
+		secret VARCHAR(128) NOT NULL,
+		owner VARCHAR(64),
+		last_event_id INTEGER NOT NULL DEFAULT 0,
+		created_at VARCHAR(64)
+	);`
+
+	stmt, err = db.Prepare(createWebhooksTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	// Webhook deliveries table. It's both the queue of the events to send
+	// to the webhooks and the log of the deliveries.
+	createDeliveriesTable := `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL,
+		event_id INTEGER NOT NULL,
+		event VARCHAR(64) NOT NULL,
+		payload TEXT NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_code INTEGER,
+		error TEXT,
+		created_at VARCHAR(64),
+		next_attempt_at VARCHAR(64),
+		delivered_at VARCHAR(64),
+		FOREIGN KEY (webhook_id)
+			REFERENCES webhooks (id)
+				ON DELETE CASCADE
+	);`
+
+	stmt, err = db.Prepare(createDeliveriesTable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.Exec()
+	if err != nil {
+		return err
+	}
+
+	for _, entity := range []string{"task", "note"} {
+		createLinksTrigger := fmt.Sprintf(
+			`CREATE TRIGGER IF NOT EXISTS delete_%[1]s_links
+			AFTER DELETE ON %[1]ss
+			BEGIN
+				DELETE FROM links
+				WHERE (from_type = '%[1]s' AND from_id = OLD.id)
+					OR (to_type = '%[1]s' AND to_id = OLD.id);
+			END;`,
+			entity,
+		)
+
+		stmt, err = db.Prepare(createLinksTrigger)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.Exec()
+		if err != nil {
+			return err
+		}
+
+		createSharesTrigger := fmt.Sprintf(
+			`CREATE TRIGGER IF NOT EXISTS delete_%[1]s_shares
+			AFTER DELETE ON %[1]ss
+			BEGIN
+				DELETE FROM shares
+				WHERE entity_type = '%[1]s' AND entity_id = OLD.id;
+			END;`,
+			entity,
+		)
+
+		stmt, err = db.Prepare(createSharesTrigger)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.Exec()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateTables brings the tables of databases created by older versions of
+// clerk up to date.
+func migrateTables(db *sql.DB) error {
+	// Soft delete
+	for _, table := range []string{"tasks", "notes"} {
+		_, err := addColumn(db, table, "deleted_at", "VARCHAR(64)")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Owners. The existing tasks and notes belong to the user who upgrades
+	// the database.
+	for _, table := range []string{"tasks", "notes"} {
+		added, err := addColumn(db, table, "owner", "VARCHAR(64)")
+		if err != nil {
+			return err
+		}
+		if !added {
+			continue
+		}
+
+		_, err = db.Exec(fmt.Sprintf(`UPDATE %s SET owner = ?`, table), currentUser())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Undo and redo per user. Like the items, the existing operations belong
+	// to the user who upgrades the database.
+	added, err := addColumn(db, "operations", "user", "VARCHAR(64)")
+	if err != nil {
+		return err
+	}
+	if added {
+		_, err = db.Exec(`UPDATE operations SET user = ?`, currentUser())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Due dates and priorities of tasks
+	if _, err := addColumn(db, "tasks", "due", "VARCHAR(64)"); err != nil {
+		return err
+	}
+	if _, err := addColumn(db, "tasks", "priority", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn(db, "revisions", "due", "VARCHAR(64)"); err != nil {
+		return err
+	}
+	if _, err := addColumn(db, "revisions", "priority", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Template names are unique, whichever schema created the table
+	_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS templates_name ON templates (name)`)
 
 	return err
 }
+
+// currentUser returns the name of the OS user running clerk.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
+
+// addColumn adds a column to an existing table, unless it already exists. It
+// reports whether the column was added.
+func addColumn(db *sql.DB, table string, column string, definition string) (bool, error) {
+	var count int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`,
+		table,
+		column,
+	).Scan(&count)
+	if err != nil || count > 0 {
+		return false, err
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+
+	return err == nil, err
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// QueryObserver is told how long each query took. `statement` is the first
+// keyword of the query in lowercase, e.g. `select` or `insert`.
+type QueryObserver func(statement string, elapsed time.Duration)
+
+// ObserveQueries makes the database call `fn` after each query, e.g. to
+// export their durations. It does nothing to databases that weren't opened by
+// SetupDatabase. Queries are timed until they return, not until their rows
+// are read.
+func ObserveQueries(db *sql.DB, fn QueryObserver) {
+	if d, ok := db.Driver().(*timedDriver); ok {
+		d.observer.Store(fn)
+	}
+}
+
+// timedDriver is the SQLite driver, with connections that time the queries.
+// Each database has its own, so that it can have its own observer.
+type timedDriver struct {
+	sqlite3.SQLiteDriver
+	observer atomic.Value // QueryObserver
+}
+
+func (d *timedDriver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &timedConn{SQLiteConn: c.(*sqlite3.SQLiteConn), driver: d}, nil
+}
+
+// observe tells the observer, if any, that `query` took since `start`.
+func (d *timedDriver) observe(query string, start time.Time) {
+	fn, _ := d.observer.Load().(QueryObserver)
+	if fn == nil {
+		return
+	}
+
+	statement := ""
+	if fields := strings.Fields(query); len(fields) > 0 {
+		statement = strings.ToLower(fields[0])
+	}
+
+	fn(statement, time.Since(start))
+}
+
+// timedConnector opens the connections to a database with a timedDriver.
+type timedConnector struct {
+	dsn    string
+	driver *timedDriver
+}
+
+func (c *timedConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *timedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type timedConn struct {
+	*sqlite3.SQLiteConn
+	driver *timedDriver
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	defer c.driver.observe(query, time.Now())
+
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	defer c.driver.observe(query, time.Now())
+
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	s, err := c.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &timedStmt{SQLiteStmt: s.(*sqlite3.SQLiteStmt), query: query, driver: c.driver}, nil
+}
+
+type timedStmt struct {
+	*sqlite3.SQLiteStmt
+	query  string
+	driver *timedDriver
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer s.driver.observe(s.query, time.Now())
+
+	return s.SQLiteStmt.ExecContext(ctx, args)
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer s.driver.observe(s.query, time.Now())
+
+	return s.SQLiteStmt.QueryContext(ctx, args)
+}
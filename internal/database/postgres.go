@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresMigrations are the changes to the schema of the PostgreSQL
+// databases, in order. Migration i+1 takes a database to version i+1, so
+// migrations are only ever appended.
+var postgresMigrations = []string{
+	// Tasks and notes, with the documents of the full-text search.
+	// Generated columns need PostgreSQL 12.
+	`CREATE TABLE tasks (
+		id BIGSERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		contents TEXT NOT NULL DEFAULT '',
+		owner TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		completed_at TIMESTAMPTZ,
+		deleted_at TIMESTAMPTZ,
+		search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', name || ' ' || contents)) STORED
+	);
+	CREATE INDEX tasks_name ON tasks (name) WHERE deleted_at IS NULL;
+	CREATE INDEX tasks_search ON tasks USING GIN (search);
+
+	CREATE TABLE notes (
+		id BIGSERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		owner TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		deleted_at TIMESTAMPTZ,
+		search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', name)) STORED
+	);
+	CREATE INDEX notes_name ON notes (name) WHERE deleted_at IS NULL;
+	CREATE INDEX notes_search ON notes USING GIN (search);
+
+	CREATE TABLE notes_contents (
+		id BIGSERIAL PRIMARY KEY,
+		note_id BIGINT NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+		contents TEXT NOT NULL,
+		search TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', contents)) STORED
+	);
+	CREATE INDEX notes_contents_note ON notes_contents (note_id);
+	CREATE INDEX notes_contents_search ON notes_contents USING GIN (search);`,
+
+	// Due dates and priorities of tasks.
+	`ALTER TABLE tasks ADD COLUMN due TIMESTAMPTZ;
+	ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;`,
+
+	// Names are unique per owner outside the trash, even when servers add
+	// the same name at once. It fails if some are taken twice already.
+	`DROP INDEX tasks_name;
+	CREATE UNIQUE INDEX tasks_name ON tasks (owner, name) WHERE deleted_at IS NULL;
+	DROP INDEX notes_name;
+	CREATE UNIQUE INDEX notes_name ON notes (owner, name) WHERE deleted_at IS NULL;`,
+}
+
+// migrationsLock is the key of the advisory lock that servers sharing a
+// database hold while they migrate it.
+const migrationsLock = 0x636c65726b // "clerk"
+
+// SetupPostgres opens the PostgreSQL database at `dsn`, e.g.
+// `postgres://clerk@localhost/clerk`, and migrates it to the latest version
+// of the schema.
+func SetupPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migratePostgres(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// migratePostgres applies the migrations that `db` is missing, recording
+// the version of the schema in the `schema_migrations` table.
+func migratePostgres(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationsLock); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	var version int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version > len(postgresMigrations) {
+		return fmt.Errorf(
+			"the database is at version %d of the schema, which is newer than this version of clerk (%d)",
+			version, len(postgresMigrations),
+		)
+	}
+
+	for i := version; i < len(postgresMigrations); i++ {
+		if _, err := tx.Exec(postgresMigrations[i]); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
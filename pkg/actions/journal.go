@@ -0,0 +1,122 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	m "github.com/csixteen/clerk/pkg/models"
+)
+
+// JournalPrefix is the prefix of the names of journal notes. The rest of the
+// name is the date of the journal entry, e.g. `journal-2020-10-11`.
+const JournalPrefix = "journal-"
+
+// JournalName returns the name of the journal note for the day of `t`.
+func JournalName(t time.Time) string {
+	return JournalPrefix + t.Format(defaultDateLayout)
+}
+
+// ParseJournalDate parses `today`, `yesterday`, `tomorrow` or a date in the
+// YYYY-MM-DD format, relative to `now`.
+func ParseJournalDate(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "", "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), nil
+	}
+
+	t, err := time.ParseInLocation(defaultDateLayout, s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"invalid date %q, expected today, yesterday, tomorrow or YYYY-MM-DD",
+			s,
+		)
+	}
+
+	return t, nil
+}
+
+// OpenJournal returns the journal note for the day of `t`. If the note
+// doesn't exist yet, it's created with the contents returned by `initial`,
+// which is given the name of the new note.
+func OpenJournal(notes m.NoteStore, t time.Time, initial func(name string) (string, error)) (*m.NoteModel, error) {
+	name := JournalName(t)
+
+	n, err := notes.GetNote(name)
+	if !errors.Is(err, m.ErrNotFound) {
+		return n, err
+	}
+
+	contents, err := initial(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := notes.AddNote(name, contents, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return notes.GetNote(name)
+}
+
+// AppendJournal appends `contents` to the journal note for the day of `t`,
+// creating it first if needed.
+func AppendJournal(notes m.NoteStore, t time.Time, contents string, initial func(name string) (string, error)) error {
+	n, err := OpenJournal(notes, t, initial)
+	if err != nil {
+		return err
+	}
+
+	return notes.AppendNote("#"+n.Id, contents)
+}
+
+// ListJournal returns the journal notes whose date starts with `period`,
+// which is either empty (all the notes), a year (YYYY) or a month (YYYY-MM).
+// The notes are ordered by date.
+func ListJournal(notes m.NoteStore, period string) ([]*m.NoteModel, error) {
+	all, err := notes.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*m.NoteModel
+	for _, n := range all {
+		if strings.HasPrefix(n.Name, JournalPrefix+period) {
+			res = append(res, n)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res, nil
+}
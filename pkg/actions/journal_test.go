@@ -0,0 +1,80 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"testing"
+	"time"
+
+	m "github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJournalDate(t *testing.T) {
+	now := time.Date(2020, 5, 14, 9, 30, 0, 0, time.UTC)
+
+	cases := map[string]string{
+		"":           "journal-2020-05-14",
+		"today":      "journal-2020-05-14",
+		"Yesterday":  "journal-2020-05-13",
+		"tomorrow":   "journal-2020-05-15",
+		"2020-02-29": "journal-2020-02-29",
+	}
+	for s, expected := range cases {
+		d, err := ParseJournalDate(s, now)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, JournalName(d))
+	}
+
+	_, err := ParseJournalDate("last week", now)
+	assert.Error(t, err)
+}
+
+func TestAppendJournal(t *testing.T) {
+	notes := m.NewMemoryStore()
+	day := time.Date(2020, 5, 14, 9, 30, 0, 0, time.UTC)
+	initial := func(name string) (string, error) { return "# " + name, nil }
+
+	assert.NoError(t, AppendJournal(notes, day, "wrote tests", initial))
+	assert.NoError(t, AppendJournal(notes, day, "fixed them", initial))
+
+	n, err := OpenJournal(notes, day, initial)
+	assert.NoError(t, err)
+	assert.Equal(t, "journal-2020-05-14", n.Name)
+	assert.Equal(t, []string{"# journal-2020-05-14", "wrote tests", "fixed them"}, n.Contents)
+}
+
+func TestListJournal(t *testing.T) {
+	notes := m.NewMemoryStore()
+	for _, name := range []string{"journal-2020-06-01", "journal-2020-05-14", "ideas", "journal-2019-12-31"} {
+		notes.AddNote(name, "", time.Now())
+	}
+
+	var names []string
+	journal, err := ListJournal(notes, "2020")
+	assert.NoError(t, err)
+	for _, n := range journal {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"journal-2020-05-14", "journal-2020-06-01"}, names)
+}
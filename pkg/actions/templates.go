@@ -0,0 +1,73 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultDateLayout = "2006-01-02"
+
+// InputFunc asks the user for the value of a placeholder, given its prompt.
+type InputFunc func(prompt string) (string, error)
+
+// ExpandTemplate expands the placeholders of a note template. The following
+// placeholders are available:
+//
+//	{{date}}             the date of `t`, optionally with a layout: {{date "Jan 2"}}
+//	{{time}}             the time of `t`
+//	{{name}}             the name of the note being created
+//	{{input "Prompt"}}   the value returned by `input` for the given prompt
+func ExpandTemplate(contents string, name string, t time.Time, input InputFunc) (string, error) {
+	funcs := template.FuncMap{
+		"date": func(layout ...string) string {
+			if len(layout) > 0 {
+				return t.Format(layout[0])
+			}
+			return t.Format(defaultDateLayout)
+		},
+		"time": func() string {
+			return t.Format("15:04")
+		},
+		"name": func() string {
+			return name
+		},
+		"input": func(prompt string) (string, error) {
+			return input(prompt)
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(contents)
+	if err != nil {
+		return "", err
+	}
+
+	var s strings.Builder
+	if err := tmpl.Execute(&s, nil); err != nil {
+		return "", err
+	}
+
+	return s.String(), nil
+}
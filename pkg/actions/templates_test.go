@@ -0,0 +1,53 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	now := time.Date(2020, 5, 14, 9, 30, 0, 0, time.UTC)
+	var prompts []string
+	input := func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "alice, bob", nil
+	}
+
+	s, err := ExpandTemplate(
+		`# {{name}} - {{date}} {{time}} ({{date "Mon"}})
+Attendees: {{input "Attendees"}}`,
+		"standup-0514",
+		now,
+		input,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "# standup-0514 - 2020-05-14 09:30 (Thu)\nAttendees: alice, bob", s)
+	assert.Equal(t, []string{"Attendees"}, prompts)
+
+	_, err = ExpandTemplate("{{unknown}}", "test", now, input)
+	assert.Error(t, err)
+}
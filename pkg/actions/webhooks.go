@@ -0,0 +1,142 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// Headers of the requests sent to the webhooks.
+const (
+	// EventHeader is the name of the event, e.g. task.completed.
+	EventHeader = "X-Clerk-Event"
+	// DeliveryHeader is the id of the delivery, which is the same for all
+	// the attempts to send it.
+	DeliveryHeader = "X-Clerk-Delivery"
+	// SignatureHeader is the signature of the body, see Sign.
+	SignatureHeader = "X-Clerk-Signature"
+)
+
+// Sign returns the signature of the body of a request sent to a webhook: the
+// HMAC-SHA256 of the body with the secret of the webhook, in hex, prefixed by
+// `sha256=`.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether `signature` is the signature of `body`
+// with `secret`, for the receivers of webhooks.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// DeliverWebhooks queues the new events for the webhooks that match them and
+// sends the deliveries that are due at `t` with `c`, recording how each
+// attempt went. Each delivery is claimed before it's sent, so that it's only
+// sent once even if other processes deliver the webhooks too. `mu` is held
+// while the database is used, but not while the requests are sent. Once `ctx`
+// is done, the deliveries that are left wait for the next call. It only fails
+// if the database does.
+func DeliverWebhooks(ctx context.Context, db *sql.DB, mu sync.Locker, c *http.Client, t time.Time) error {
+	start := time.Now()
+
+	mu.Lock()
+	err := models.QueueDeliveries(db, t)
+	var due []*models.PendingDelivery
+	if err == nil {
+		due, err = models.DueDeliveries(db, t)
+	}
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// The lease of the claim starts when the delivery is sent, not
+		// when the deliveries were due.
+		mu.Lock()
+		claimed, err := models.ClaimDelivery(db, d, t.Add(time.Since(start)))
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		code, err := send(ctx, c, d)
+
+		mu.Lock()
+		err = models.RecordAttempt(db, d.Id, code, err, t)
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// send posts a delivery to its webhook and returns the status of the
+// response.
+func send(ctx context.Context, c *http.Client, d *models.PendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "clerk-webhook")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, d.Id)
+	req.Header.Set(SignatureHeader, Sign(d.Secret, d.Payload))
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Draining the body lets the connection be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
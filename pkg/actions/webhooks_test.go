@@ -0,0 +1,293 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	d "github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// receiver is a stand-in for the receiver of a webhook, which responds with
+// the given statuses in turn, and then with 200.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+// testActor makes the changes in the tests.
+var testActor = models.Actor{User: "alice"}
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := d.SetupDatabase(filepath.Join(t.TempDir(), "clerk.db"))
+	if err != nil {
+		t.Fatalf("An error occurred when creating the database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := "sha256=6146142a2ce0159e84c0767881e4ec80bc397da62526e7d19f70795eb79460c0"
+
+	assert.Equal(t, signature, Sign("secret", body))
+	assert.True(t, VerifySignature("secret", body, signature))
+	assert.False(t, VerifySignature("other", body, signature))
+	assert.False(t, VerifySignature("secret", []byte(`{"id":"2"}`), signature))
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	db := newTestDB(t)
+	r := newReceiver(t)
+	now := time.Now()
+
+	_, err := models.AddTask(db, testActor, "before", "", now)
+	assert.NoError(t, err)
+
+	w, err := models.AddWebhook(db, testActor, "task.completed", r.URL+"/hook", "secret", now)
+	assert.NoError(t, err)
+
+	_, err = models.AddTask(db, testActor, "test", "", now)
+	assert.NoError(t, err)
+	assert.NoError(t, models.CompleteTask(db, testActor, "test", now))
+	assert.NoError(t, models.CompleteTask(db, testActor, "before", now))
+
+	var mu sync.Mutex
+	assert.NoError(t, DeliverWebhooks(context.Background(), db, &mu, http.DefaultClient, now))
+
+	// Only the events after the webhook was added that match it
+	assert.Equal(t, 2, len(r.requests))
+	for i, name := range []string{"test", "before"} {
+		req, body := r.requests[i], r.bodies[i]
+		assert.Equal(t, "/hook", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "task.completed", req.Header.Get(EventHeader))
+		assert.True(t, VerifySignature("secret", body, req.Header.Get(SignatureHeader)))
+
+		var e models.EventModel
+		assert.NoError(t, json.Unmarshal(body, &e))
+		assert.Equal(t, "task.completed", e.Event)
+
+		var item struct {
+			Name string `json:"name"`
+		}
+		assert.NoError(t, json.Unmarshal(e.Item, &item))
+		assert.Equal(t, name, item.Name)
+	}
+
+	deliveries, err := models.ListDeliveries(db, w.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(deliveries))
+	for _, d := range deliveries {
+		assert.Equal(t, models.DeliveryDelivered, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Equal(t, http.StatusOK, d.ResponseCode)
+	}
+
+	// Nothing is sent twice
+	assert.NoError(t, DeliverWebhooks(context.Background(), db, &mu, http.DefaultClient, now))
+	assert.Equal(t, 2, len(r.requests))
+}
+
+func TestDeliverWebhooksRetries(t *testing.T) {
+	db := newTestDB(t)
+	r := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	now := time.Date(2020, 9, 20, 15, 0, 0, 0, time.Local)
+
+	w, err := models.AddWebhook(db, testActor, "task", r.URL, "secret", now)
+	assert.NoError(t, err)
+	_, err = models.AddTask(db, testActor, "test", "", now)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	deliver := func(after time.Duration) *models.DeliveryModel {
+		assert.NoError(t, DeliverWebhooks(context.Background(), db, &mu, http.DefaultClient, now.Add(after)))
+
+		deliveries, err := models.ListDeliveries(db, w.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(deliveries))
+
+		return deliveries[0]
+	}
+
+	d := deliver(0)
+	assert.Equal(t, models.DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, d.ResponseCode)
+	assert.Equal(t, "15:00:30", d.NextAttemptAt.Format("15:04:05"))
+
+	// It isn't attempted again before the backoff
+	d = deliver(10 * time.Second)
+	assert.Equal(t, 1, d.Attempts)
+
+	d = deliver(30 * time.Second)
+	assert.Equal(t, 2, d.Attempts)
+	assert.Equal(t, "unexpected status 502", d.Error)
+	assert.Equal(t, "15:01:30", d.NextAttemptAt.Format("15:04:05"))
+
+	d = deliver(90 * time.Second)
+	assert.Equal(t, models.DeliveryDelivered, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, 3, len(r.requests))
+
+	// All the attempts are the same delivery
+	for _, req := range r.requests {
+		assert.Equal(t, d.Id, req.Header.Get(DeliveryHeader))
+	}
+}
+
+func TestDeliverWebhooksGiveUp(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Date(2020, 9, 20, 15, 0, 0, 0, time.Local)
+
+	// Nothing listens on the URL of a closed server
+	r := newReceiver(t)
+	r.Close()
+
+	w, err := models.AddWebhook(db, testActor, "*", r.URL, "", now)
+	assert.NoError(t, err)
+	_, err = models.AddNote(db, testActor, "test", "", now)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, DeliverWebhooks(context.Background(), db, &mu, http.DefaultClient, now.Add(time.Duration(i)*time.Hour)))
+	}
+
+	deliveries, err := models.ListDeliveries(db, w.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, 6, deliveries[0].Attempts)
+	assert.Contains(t, deliveries[0].Error, "connection refused")
+}
+
+func TestDeliverWebhooksDeadline(t *testing.T) {
+	db := newTestDB(t)
+	r := newReceiver(t)
+	now := time.Now()
+
+	w, err := models.AddWebhook(db, testActor, "task", r.URL, "", now)
+	assert.NoError(t, err)
+	_, err = models.AddTask(db, testActor, "test", "", now)
+	assert.NoError(t, err)
+
+	// Nothing is sent once the time is up, and the delivery waits for the
+	// next time.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var mu sync.Mutex
+	assert.NoError(t, DeliverWebhooks(ctx, db, &mu, http.DefaultClient, now))
+	assert.Equal(t, 0, len(r.requests))
+
+	deliveries, err := models.ListDeliveries(db, w.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deliveries))
+	assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+	assert.Equal(t, 0, deliveries[0].Attempts)
+
+	assert.NoError(t, DeliverWebhooks(context.Background(), db, &mu, http.DefaultClient, now))
+	assert.Equal(t, 1, len(r.requests))
+}
+
+func TestDeliverWebhooksConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clerk.db")
+	r := newReceiver(t)
+	now := time.Now()
+
+	// Like a command and a server, each with its own connection to the
+	// database.
+	var dbs []*sql.DB
+	for i := 0; i < 2; i++ {
+		db, err := d.SetupDatabase(path)
+		if err != nil {
+			t.Fatalf("An error occurred when opening the database: %s", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		dbs = append(dbs, db)
+	}
+
+	_, err := models.AddWebhook(dbs[0], testActor, "task", r.URL, "", now)
+	assert.NoError(t, err)
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := models.AddTask(dbs[0], testActor, name, "", now)
+		assert.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(db *sql.DB) {
+			defer wg.Done()
+			assert.NoError(t, DeliverWebhooks(context.Background(), db, new(sync.Mutex), http.DefaultClient, now))
+		}(dbs[i%2])
+	}
+	wg.Wait()
+
+	// Each event is sent once
+	assert.Equal(t, 3, len(r.requests))
+	sent := map[string]bool{}
+	for _, req := range r.requests {
+		sent[req.Header.Get(DeliveryHeader)] = true
+	}
+	assert.Equal(t, 3, len(sent))
+}
@@ -0,0 +1,50 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// ListAudit returns the entries of the audit log selected by `f`, oldest
+// first.
+func (c *Client) ListAudit(f models.AuditFilter) ([]*models.AuditEntryModel, error) {
+	query := url.Values{}
+	if !f.Since.IsZero() {
+		query.Set("since", f.Since.Format(time.RFC3339))
+	}
+	for k, v := range map[string]string{"type": f.Type, "entity_id": f.EntityId, "user": f.User} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+
+	var entries []*models.AuditEntryModel
+	err := c.do(http.MethodGet, "/audit", query, nil, &entries)
+
+	return entries, err
+}
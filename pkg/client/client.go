@@ -0,0 +1,210 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package client talks to a clerk-server. Its methods mirror the functions of
+// pkg/models and pkg/actions, and return the same errors, so that clerk works
+// the same way with a server as with the local database.
+//
+// It's the client that other tools should use to talk to a clerk-server, e.g.
+//
+//	c := client.New("http://localhost:8080", token)
+//	tasks, err := c.ListTasks()
+//
+// Its methods cover all the operations of the OpenAPI document served at
+// /openapi.json.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/csixteen/clerk/pkg/server"
+)
+
+// Client is a client of a clerk-server.
+type Client struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+// New returns a client of the server at `url`, e.g. http://localhost:8080,
+// that authenticates with the API token `token`.
+func New(url string, token string) *Client {
+	return &Client{url: strings.TrimSuffix(url, "/"), token: token, http: http.DefaultClient}
+}
+
+// SetHTTPClient makes the client send its requests with `h`, e.g. to set a
+// timeout, instead of http.DefaultClient.
+func (c *Client) SetHTTPClient(h *http.Client) {
+	c.http = h
+}
+
+// Error is an error returned by the server. It matches the errors of
+// pkg/models that correspond to its code, e.g. models.ErrNotFound.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return codes[e.Code] == target && target != nil
+}
+
+// codes maps the error codes of the server to the errors of pkg/models.
+var codes = map[string]error{
+	server.CodeNotFound:       models.ErrNotFound,
+	server.CodeAmbiguous:      models.ErrAmbiguous,
+	server.CodeInvalidID:      models.ErrInvalidID,
+	server.CodeInvalidName:    models.ErrInvalidName,
+	server.CodeNameTaken:      models.ErrNameTaken,
+	server.CodeUnauthorized:   models.ErrUnauthorized,
+	server.CodeForbidden:      models.ErrForbidden,
+	server.CodeInvalidWebhook: models.ErrInvalidWebhook,
+	server.CodeNothingToUndo:  models.ErrNothingToUndo,
+	server.CodeNothingToRedo:  models.ErrNothingToRedo,
+	server.CodeInvalidCount:   models.ErrInvalidCount,
+	server.CodeConflict:       models.ErrConflict,
+}
+
+// do sends a request with `body`, unless it's nil, encoded as JSON and
+// decodes the response into `res`, unless it's nil.
+func (c *Client) do(method string, path string, query url.Values, body interface{}, res interface{}) error {
+	u := c.url + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return responseError(method, path, resp)
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return fmt.Errorf("invalid response to %s %s: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// responseError returns the error in the response to a failed request.
+func responseError(method string, path string, resp *http.Response) error {
+	var e server.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+		return &Error{StatusCode: resp.StatusCode, Message: fmt.Sprintf("%s %s: %s", method, path, resp.Status)}
+	}
+
+	return &Error{StatusCode: resp.StatusCode, Code: e.Code, Message: e.Error}
+}
+
+// collection returns the path of the collection of the items of type
+// `entityType`.
+func collection(entityType string) (string, error) {
+	switch entityType {
+	case models.TaskType, models.NoteType:
+		return "/" + entityType + "s", nil
+	default:
+		return "", fmt.Errorf("unknown type %q", entityType)
+	}
+}
+
+// FindIds returns the ids of all the items of type `entityType` referred to
+// by `ref`, which is either a name or an id prefixed by a '#'.
+func (c *Client) FindIds(entityType string, ref string) ([]string, error) {
+	path, err := collection(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []struct {
+		Id string `json:"id"`
+	}
+	if err := c.do(http.MethodGet, path, url.Values{"ref": {ref}}, nil, &items); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, i := range items {
+		ids = append(ids, i.Id)
+	}
+
+	return ids, nil
+}
+
+// itemPath returns the path of the item of type `entityType` referred to by
+// `ref`. It fails if `ref` is a name shared by several items.
+func (c *Client) itemPath(entityType string, ref string) (string, error) {
+	ids, err := c.FindIds(entityType, ref)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", &models.NotFoundError{Type: entityType, Ref: ref}
+	case 1:
+		path, _ := collection(entityType)
+		return path + "/" + ids[0], nil
+	default:
+		return "", &models.AmbiguousNameError{Type: entityType, Name: ref, Ids: ids}
+	}
+}
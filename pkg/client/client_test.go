@@ -0,0 +1,112 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	d "github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/csixteen/clerk/pkg/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a client of a server backed by a new database.
+func newTestClient(t *testing.T) *Client {
+	db, err := d.SetupDatabase(filepath.Join(t.TempDir(), "clerk.db"))
+	if err != nil {
+		t.Fatalf("An error occurred when creating the database: %s", err)
+	}
+
+	token, err := models.CreateToken(db, "alice", time.Now())
+	if err != nil {
+		t.Fatalf("An error occurred when creating a token: %s", err)
+	}
+
+	ts := httptest.NewServer(server.New(db))
+	t.Cleanup(func() {
+		ts.Close()
+		db.Close()
+	})
+
+	return New(ts.URL+"/", token)
+}
+
+func TestErrors(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.GetTask("unknown")
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+	assert.EqualError(t, err, "task unknown not found")
+
+	_, err = c.GetNote("#0")
+	assert.True(t, errors.Is(err, models.ErrInvalidID))
+	assert.EqualError(t, err, `invalid id: "#0"`)
+
+	_, err = c.AddTask("#1", "", time.Now())
+	assert.True(t, errors.Is(err, models.ErrInvalidName))
+
+	_, err = c.AddTask("test", "", time.Now())
+	assert.NoError(t, err)
+	_, err = c.AddTask("test", "", time.Now())
+	assert.True(t, errors.Is(err, models.ErrNameTaken))
+	assert.False(t, errors.Is(err, models.ErrNotFound))
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, http.StatusConflict, e.StatusCode)
+
+	_, err = c.ListRevisions("unknown", "test")
+	assert.EqualError(t, err, `unknown type "unknown"`)
+}
+
+func TestUnauthorized(t *testing.T) {
+	c := newTestClient(t)
+	c.token = "clerk_unknown"
+
+	_, err := c.ListTasks()
+	assert.True(t, errors.Is(err, models.ErrUnauthorized))
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, http.StatusUnauthorized, e.StatusCode)
+}
+
+func TestUnreachableServer(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	c := New(ts.URL, "")
+
+	_, err := c.ListTasks()
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, "GET /tasks: 404 Not Found", e.Error())
+
+	ts.Close()
+	_, err = c.ListTasks()
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// reconnectDelay is how long Watch waits before reconnecting, unless the
+// server says otherwise.
+var reconnectDelay = time.Second
+
+// Watch calls `fn` with the changes made to the tasks and notes after the
+// event with id `after`, or from now on if it's empty, as they happen. It
+// reconnects whenever the stream is interrupted, resuming after the last
+// event, and only returns when `ctx` is done, when `fn` fails or when the
+// server rejects the request.
+func (c *Client) Watch(ctx context.Context, after string, fn func(*models.EventModel) error) error {
+	delay := reconnectDelay
+	for {
+		retry, err := c.stream(ctx, &after, &delay, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retry {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// stream reads the events from a single connection, updating `after` with
+// the id of each event and `delay` with the reconnection delay sent by the
+// server. It reports whether Watch should reconnect.
+func (c *Client) stream(ctx context.Context, after *string, delay *time.Duration, fn func(*models.EventModel) error) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/events", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if *after != "" {
+		req.Header.Set("Last-Event-ID", *after)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode >= 500, responseError(http.MethodGet, "/events", resp)
+	}
+
+	// Fields of the event being read, which ends with an empty line.
+	var id, data string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data != "" {
+				e := new(models.EventModel)
+				if err := json.Unmarshal([]byte(data), e); err != nil {
+					return false, fmt.Errorf("invalid event %s: %w", id, err)
+				}
+				if err := fn(e); err != nil {
+					return false, err
+				}
+			}
+			if id != "" {
+				*after = id
+			}
+			id, data = "", ""
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				*delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	return true, scanner.Err()
+}
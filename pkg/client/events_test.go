@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.AddTask("first", "", time.Now())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := errors.New("done")
+	var events []string
+	go func() {
+		// Changes made while the stream is open
+		time.Sleep(100 * time.Millisecond)
+		c.AddNote("test", "", time.Now())
+		c.CompleteTask("first", time.Now())
+	}()
+
+	err = c.Watch(ctx, "", func(e *models.EventModel) error {
+		events = append(events, e.Event+" "+e.EntityId)
+		if len(events) == 2 {
+			return done
+		}
+		return nil
+	})
+	assert.Equal(t, done, err)
+	assert.Equal(t, []string{"note.created 1", "task.completed 1"}, events)
+
+	// Resuming after an event
+	events = nil
+	err = c.Watch(ctx, "1", func(e *models.EventModel) error {
+		events = append(events, e.Event)
+		if len(events) == 2 {
+			return done
+		}
+		return nil
+	})
+	assert.Equal(t, done, err)
+	assert.Equal(t, []string{"note.created", "task.completed"}, events)
+}
+
+func TestWatchUnauthorized(t *testing.T) {
+	c := newTestClient(t)
+	c.token = "clerk_invalid"
+
+	err := c.Watch(context.Background(), "", func(*models.EventModel) error { return nil })
+	assert.True(t, errors.Is(err, models.ErrUnauthorized))
+}
@@ -0,0 +1,56 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// ListLinks returns the items linked to the item of type `entityType` with
+// the given id.
+func (c *Client) ListLinks(entityType string, id string) ([]*models.LinkModel, error) {
+	path, err := collection(entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*models.LinkModel
+	err = c.do(http.MethodGet, path+"/"+url.PathEscape(id)+"/links", nil, nil, &links)
+
+	return links, err
+}
+
+// AddLink links two items given their references (e.g. `#task:3` and
+// `note:groceries`). The server sets the creation time, so `t` is ignored.
+func (c *Client) AddLink(from string, to string, t time.Time) error {
+	return c.do(http.MethodPost, "/links", nil, map[string]string{"from": from, "to": to}, nil)
+}
+
+// DeleteLink removes the link between two items given their references.
+func (c *Client) DeleteLink(from string, to string) error {
+	return c.do(http.MethodDelete, "/links", url.Values{"from": {from}, "to": {to}}, nil, nil)
+}
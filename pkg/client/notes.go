@@ -0,0 +1,104 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// ListNotes returns all the notes ordered by id, without their contents.
+func (c *Client) ListNotes() ([]*models.NoteModel, error) {
+	var notes []*models.NoteModel
+	err := c.do(http.MethodGet, "/notes", nil, nil, &notes)
+
+	return notes, err
+}
+
+// GetNote returns a note and its contents given its name or id. If `note`
+// starts with a '#', then it refers to the note id.
+func (c *Client) GetNote(note string) (*models.NoteModel, error) {
+	path, err := c.itemPath(models.NoteType, note)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(models.NoteModel)
+	if err := c.do(http.MethodGet, path, nil, nil, n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// AddNote adds a new note and returns its id. If `contents` is empty, the
+// note is created without any contents. The server sets the creation time,
+// so `t` is ignored.
+func (c *Client) AddNote(name string, contents string, t time.Time) (int64, error) {
+	req := map[string]interface{}{"name": name}
+	if contents != "" {
+		req["contents"] = []string{contents}
+	}
+
+	var n models.NoteModel
+	if err := c.do(http.MethodPost, "/notes", nil, req, &n); err != nil {
+		return -1, err
+	}
+
+	return strconv.ParseInt(n.Id, 10, 64)
+}
+
+// AppendNote appends contents to a note given its name or id.
+func (c *Client) AppendNote(note string, contents string) error {
+	path, err := c.itemPath(models.NoteType, note)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodPost, path+"/contents", nil, map[string]string{"contents": contents}, nil)
+}
+
+// RenameNote renames a note given its name or id. The server rewrites the
+// wiki-links to the note.
+func (c *Client) RenameNote(note string, name string) error {
+	path, err := c.itemPath(models.NoteType, note)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodPatch, path, nil, map[string]string{"name": name}, nil)
+}
+
+// DeleteNote moves a note to the trash. The server sets the deletion time,
+// so `t` is ignored.
+func (c *Client) DeleteNote(note string, t time.Time) error {
+	path, err := c.itemPath(models.NoteType, note)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodDelete, path, nil, nil, nil)
+}
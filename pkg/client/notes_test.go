@@ -0,0 +1,128 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotes(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.AddNote("test", "", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, c.AppendNote("test", "milk"))
+
+	_, err = c.AddTask("groceries", "see [[test]]", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, c.RenameNote("test", "shopping"))
+	assert.NoError(t, c.AddLink("task:groceries", "note:shopping", time.Now()))
+
+	n, err := c.GetNote("shopping")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"milk"}, n.Contents)
+
+	links, err := c.ListLinks("note", n.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.LinkModel{{Type: "task", Id: "1", Name: "groceries"}}, links)
+	assert.NoError(t, c.DeleteLink("task:groceries", "note:shopping"))
+
+	revs, err := c.ListRevisions("task", "groceries")
+	assert.NoError(t, err)
+	assert.Len(t, revs, 2)
+	assert.Equal(t, []string{"see [[shopping]]"}, revs[1].Contents)
+
+	assert.NoError(t, c.RevertItem("task", "groceries", 1))
+	current, err := c.CurrentRevision("task", "groceries")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"see [[test]]"}, current.Contents)
+
+	results, err := c.Search("milk")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Result{&models.NoteModel{Id: "1", Name: "shopping", Contents: []string{"milk"}}}, results)
+
+	assert.NoError(t, c.DeleteNote("shopping", time.Now()))
+	notes, err := c.ListNotes()
+	assert.NoError(t, err)
+	assert.Empty(t, notes)
+}
+
+func TestJournal(t *testing.T) {
+	c := newTestClient(t)
+
+	day := time.Date(2020, 10, 11, 0, 0, 0, 0, time.Local)
+	initial := func(name string) (string, error) { return "# " + name, nil }
+	assert.NoError(t, actions.AppendJournal(c, day, "hello", initial))
+
+	notes, err := actions.ListJournal(c, "2020-10")
+	assert.NoError(t, err)
+	assert.Len(t, notes, 1)
+
+	n, err := c.GetNote(notes[0].Name)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"# journal-2020-10-11", "hello"}, n.Contents)
+}
+
+func TestTemplates(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.AddTemplate("standup", "# {{name}}", time.Now())
+	assert.NoError(t, err)
+
+	tmpl, err := c.GetTemplate("standup")
+	assert.NoError(t, err)
+	assert.Equal(t, "# {{name}}", tmpl.Contents)
+
+	templates, err := c.ListTemplates()
+	assert.NoError(t, err)
+	assert.Len(t, templates, 1)
+
+	assert.NoError(t, c.DeleteTemplate("standup"))
+	_, err = c.GetTemplate("standup")
+	assert.EqualError(t, err, "template standup not found")
+}
+
+func TestShares(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.AddNote("groceries", "milk", time.Now())
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.ShareItem(models.NoteType, "groceries", "bob", time.Now()))
+	assert.NoError(t, c.ShareItem(models.NoteType, "groceries", "carol", time.Now()))
+
+	shares, err := c.ListShares(models.NoteType, "groceries")
+	assert.NoError(t, err)
+	assert.Len(t, shares, 2)
+	assert.Equal(t, "bob", shares[0].User)
+
+	assert.NoError(t, c.UnshareItem(models.NoteType, "#1", "bob"))
+	err = c.UnshareItem(models.NoteType, "#1", "bob")
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+}
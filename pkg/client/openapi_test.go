@@ -0,0 +1,139 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// operational are the operations meant for other tools, e.g. monitoring,
+// which Client doesn't call.
+var operational = map[string]bool{
+	"getOpenAPI":   true,
+	"getMetrics":   true,
+	"getHealth":    true,
+	"getReadiness": true,
+}
+
+// methods maps the operations of the OpenAPI document to the methods of
+// Client that call them.
+var methods = map[string]string{
+	"listTasks":  "ListTasks",
+	"addTask":    "AddTask",
+	"getTask":    "GetTask",
+	"updateTask": "EditTask",
+	"deleteTask": "DeleteTask",
+
+	"listNotes":  "ListNotes",
+	"addNote":    "AddNote",
+	"getNote":    "GetNote",
+	"updateNote": "RenameNote",
+	"deleteNote": "DeleteNote",
+	"appendNote": "AppendNote",
+
+	"listTaskLinks": "ListLinks",
+	"listNoteLinks": "ListLinks",
+	"addLink":       "AddLink",
+	"deleteLink":    "DeleteLink",
+
+	"listTaskRevisions":   "ListRevisions",
+	"listNoteRevisions":   "ListRevisions",
+	"currentTaskRevision": "CurrentRevision",
+	"currentNoteRevision": "CurrentRevision",
+	"getTaskRevision":     "GetRevision",
+	"getNoteRevision":     "GetRevision",
+	"revertTask":          "RevertItem",
+	"revertNote":          "RevertItem",
+
+	"listTaskShares": "ListShares",
+	"listNoteShares": "ListShares",
+	"shareTask":      "ShareItem",
+	"shareNote":      "ShareItem",
+	"unshareTask":    "UnshareItem",
+	"unshareNote":    "UnshareItem",
+
+	"listTemplates":  "ListTemplates",
+	"addTemplate":    "AddTemplate",
+	"getTemplate":    "GetTemplate",
+	"deleteTemplate": "DeleteTemplate",
+
+	"listTrash":   "ListTrash",
+	"emptyTrash":  "EmptyTrash",
+	"restoreItem": "RestoreItem",
+
+	"undo": "Undo",
+	"redo": "Redo",
+
+	"listAudit": "ListAudit",
+
+	"search": "Search",
+
+	"streamEvents": "Watch",
+
+	"listWebhooks":   "ListWebhooks",
+	"addWebhook":     "AddWebhook",
+	"getWebhook":     "GetWebhook",
+	"deleteWebhook":  "DeleteWebhook",
+	"listDeliveries": "ListDeliveries",
+}
+
+func TestOpenAPICoverage(t *testing.T) {
+	c := newTestClient(t)
+
+	resp, err := http.Get(c.url + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationId string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	client := reflect.TypeOf(c)
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if operational[op.OperationId] {
+				continue
+			}
+
+			name, ok := methods[op.OperationId]
+			if !assert.True(t, ok, "no method calls %s %s (%s)", method, path, op.OperationId) {
+				continue
+			}
+
+			_, ok = client.MethodByName(name)
+			assert.True(t, ok, "Client has no method %s", name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// revisionsPath returns the path of the revisions of the item referred to by
+// `ref`.
+func (c *Client) revisionsPath(entityType string, ref string) (string, error) {
+	path, err := c.itemPath(entityType, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return path + "/revisions", nil
+}
+
+// ListRevisions returns the history of a task or a note, given its type and
+// its name or id, ordered by revision.
+func (c *Client) ListRevisions(entityType string, ref string) ([]*models.RevisionModel, error) {
+	path, err := c.revisionsPath(entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var revs []*models.RevisionModel
+	err = c.do(http.MethodGet, path, nil, nil, &revs)
+
+	return revs, err
+}
+
+// GetRevision returns a revision of a task or a note, given its type, its
+// name or id and the revision number.
+func (c *Client) GetRevision(entityType string, ref string, rev int) (*models.RevisionModel, error) {
+	path, err := c.revisionsPath(entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(models.RevisionModel)
+	if err := c.do(http.MethodGet, path+"/"+strconv.Itoa(rev), nil, nil, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// CurrentRevision returns the current state of a task or a note as an
+// unsaved revision.
+func (c *Client) CurrentRevision(entityType string, ref string) (*models.RevisionModel, error) {
+	path, err := c.revisionsPath(entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(models.RevisionModel)
+	if err := c.do(http.MethodGet, path+"/current", nil, nil, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// RevertItem sets the name and the contents of a task or a note back to the
+// ones of a previous revision.
+func (c *Client) RevertItem(entityType string, ref string, rev int) error {
+	path, err := c.itemPath(entityType, ref)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodPost, path+"/revert", nil, map[string]int{"rev": rev}, nil)
+}
@@ -0,0 +1,56 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/csixteen/clerk/pkg/server"
+)
+
+// Search returns the tasks and the notes that contain `query`, as
+// models.Search does.
+func (c *Client) Search(query string) ([]models.Result, error) {
+	var found []*server.SearchResult
+	if err := c.do(http.MethodGet, "/search", url.Values{"q": {query}}, nil, &found); err != nil {
+		return nil, err
+	}
+
+	var res []models.Result
+	for _, r := range found {
+		switch r.Type {
+		case models.TaskType:
+			var contents string
+			if len(r.Contents) > 0 {
+				contents = r.Contents[0]
+			}
+			res = append(res, &models.TaskModel{Id: r.Id, Name: r.Name, Contents: contents})
+		case models.NoteType:
+			res = append(res, &models.NoteModel{Id: r.Id, Name: r.Name, Contents: r.Contents})
+		}
+	}
+
+	return res, nil
+}
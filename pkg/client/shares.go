@@ -0,0 +1,77 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// sharesPath returns the path of the shares of the item referred to by
+// `ref`.
+func (c *Client) sharesPath(entityType string, ref string) (string, error) {
+	path, err := c.itemPath(entityType, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return path + "/shares", nil
+}
+
+// ListShares returns the users that a task or a note is shared with, given
+// its type and its name or id.
+func (c *Client) ListShares(entityType string, ref string) ([]*models.ShareModel, error) {
+	path, err := c.sharesPath(entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []*models.ShareModel
+	err = c.do(http.MethodGet, path, nil, nil, &shares)
+
+	return shares, err
+}
+
+// ShareItem shares a task or a note with `user`. Only the owner of an item
+// can share it. The server sets the creation time, so `t` is ignored.
+func (c *Client) ShareItem(entityType string, ref string, user string, t time.Time) error {
+	path, err := c.sharesPath(entityType, ref)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodPost, path, nil, map[string]string{"user": user}, nil)
+}
+
+// UnshareItem stops sharing a task or a note with `user`.
+func (c *Client) UnshareItem(entityType string, ref string, user string) error {
+	path, err := c.sharesPath(entityType, ref)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodDelete, path+"/"+url.PathEscape(user), nil, nil, nil)
+}
@@ -0,0 +1,117 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// ListTasks returns all the tasks ordered by id.
+func (c *Client) ListTasks() ([]*models.TaskModel, error) {
+	var tasks []*models.TaskModel
+	err := c.do(http.MethodGet, "/tasks", nil, nil, &tasks)
+
+	return tasks, err
+}
+
+// GetTask returns a task given its name or id. If `task` starts with a '#',
+// then it refers to the task id.
+func (c *Client) GetTask(task string) (*models.TaskModel, error) {
+	path, err := c.itemPath(models.TaskType, task)
+	if err != nil {
+		return nil, err
+	}
+
+	t := new(models.TaskModel)
+	if err := c.do(http.MethodGet, path, nil, nil, t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// AddTask adds a new task and returns its id. The server sets the creation
+// time, so `t` is ignored.
+func (c *Client) AddTask(name string, contents string, t time.Time) (int64, error) {
+	var task models.TaskModel
+	err := c.do(http.MethodPost, "/tasks", nil, map[string]string{
+		"name":     name,
+		"contents": contents,
+	}, &task)
+	if err != nil {
+		return -1, err
+	}
+
+	return strconv.ParseInt(task.Id, 10, 64)
+}
+
+// updateTask changes the fields of a task given in `fields`.
+func (c *Client) updateTask(task string, fields map[string]interface{}) error {
+	path, err := c.itemPath(models.TaskType, task)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodPatch, path, nil, fields, nil)
+}
+
+// EditTask sets the contents of a task.
+func (c *Client) EditTask(task string, contents string) error {
+	return c.updateTask(task, map[string]interface{}{"contents": contents})
+}
+
+// RenameTask renames a task given its name or id.
+func (c *Client) RenameTask(task string, name string) error {
+	return c.updateTask(task, map[string]interface{}{"name": name})
+}
+
+// CompleteTask marks a task as completed at `t`.
+func (c *Client) CompleteTask(task string, t time.Time) error {
+	return c.updateTask(task, map[string]interface{}{"completed_at": t})
+}
+
+// SetTaskDue sets when a task is due, or clears it if `due` is the zero
+// time.
+func (c *Client) SetTaskDue(task string, due time.Time) error {
+	return c.updateTask(task, map[string]interface{}{"due": due})
+}
+
+// SetTaskPriority sets the priority of a task.
+func (c *Client) SetTaskPriority(task string, priority int) error {
+	return c.updateTask(task, map[string]interface{}{"priority": priority})
+}
+
+// DeleteTask moves a task to the trash. The server sets the deletion time,
+// so `t` is ignored.
+func (c *Client) DeleteTask(task string, t time.Time) error {
+	path, err := c.itemPath(models.TaskType, task)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodDelete, path, nil, nil, nil)
+}
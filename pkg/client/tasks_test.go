@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTasks(t *testing.T) {
+	c := newTestClient(t)
+
+	id, err := c.AddTask("test", "test contents", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	assert.NoError(t, c.EditTask("test", "new contents"))
+	assert.NoError(t, c.RenameTask("#1", "renamed"))
+	due := time.Date(2020, 10, 12, 18, 0, 0, 0, time.Local)
+	assert.NoError(t, c.SetTaskDue("renamed", due))
+	assert.NoError(t, c.SetTaskPriority("renamed", 3))
+	assert.NoError(t, c.CompleteTask("renamed", time.Now()))
+
+	task, err := c.GetTask("renamed")
+	assert.NoError(t, err)
+	assert.Equal(t, "new contents", task.Contents)
+	assert.False(t, task.CompletedAt.IsZero())
+	assert.True(t, due.Equal(task.Due))
+	assert.Equal(t, 3, task.Priority)
+
+	ids, err := c.FindIds("task", "renamed")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids)
+
+	assert.NoError(t, c.DeleteTask("renamed", time.Now()))
+
+	tasks, err := c.ListTasks()
+	assert.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	items, err := c.ListTrash()
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	assert.NoError(t, c.RestoreItem("task", "1"))
+
+	ops, err := c.Undo(1, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, ops, 1)
+
+	n, err := c.EmptyTrash(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	entries, err := c.ListAudit(models.AuditFilter{Type: "task", Since: time.Now().Add(-time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
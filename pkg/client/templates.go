@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// ListTemplates returns all the templates ordered by id, without their
+// contents.
+func (c *Client) ListTemplates() ([]*models.TemplateModel, error) {
+	var templates []*models.TemplateModel
+	err := c.do(http.MethodGet, "/templates", nil, nil, &templates)
+
+	return templates, err
+}
+
+// GetTemplate returns a template given its name or id. If `template` starts
+// with a '#', then it refers to the template id.
+func (c *Client) GetTemplate(template string) (*models.TemplateModel, error) {
+	var templates []*models.TemplateModel
+	err := c.do(http.MethodGet, "/templates", url.Values{"ref": {template}}, nil, &templates)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(templates) == 0 {
+		return nil, &models.NotFoundError{Type: "template", Ref: template}
+	}
+
+	return templates[0], nil
+}
+
+// AddTemplate adds a new template and returns its id. The server sets the
+// creation time, so `t` is ignored.
+func (c *Client) AddTemplate(name string, contents string, t time.Time) (int64, error) {
+	var tmpl models.TemplateModel
+	err := c.do(http.MethodPost, "/templates", nil, map[string]string{
+		"name":     name,
+		"contents": contents,
+	}, &tmpl)
+	if err != nil {
+		return -1, err
+	}
+
+	return strconv.ParseInt(tmpl.Id, 10, 64)
+}
+
+// DeleteTemplate deletes a template given its name or id.
+func (c *Client) DeleteTemplate(template string) error {
+	tmpl, err := c.GetTemplate(template)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodDelete, "/templates/"+tmpl.Id, nil, nil, nil)
+}
@@ -0,0 +1,65 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/csixteen/clerk/pkg/server"
+)
+
+// ListTrash returns the tasks and notes in the trash, the most recently
+// deleted first.
+func (c *Client) ListTrash() ([]*models.TrashItemModel, error) {
+	var items []*models.TrashItemModel
+	err := c.do(http.MethodGet, "/trash", nil, nil, &items)
+
+	return items, err
+}
+
+// RestoreItem takes a task or a note out of the trash given its type and id,
+// with or without the '#' prefix.
+func (c *Client) RestoreItem(entityType string, id string) error {
+	return c.do(http.MethodPost, "/trash/restore", nil, map[string]string{
+		"type": entityType,
+		"id":   id,
+	}, nil)
+}
+
+// EmptyTrash permanently deletes the tasks and notes that were moved to the
+// trash before `t`, or all of them if `t` is the zero time. It returns the
+// number of deleted items.
+func (c *Client) EmptyTrash(t time.Time) (int64, error) {
+	query := url.Values{}
+	if !t.IsZero() {
+		query.Set("before", t.Format(time.RFC3339))
+	}
+
+	var res server.EmptyTrashResponse
+	err := c.do(http.MethodDelete, "/trash", query, nil, &res)
+
+	return res.Deleted, err
+}
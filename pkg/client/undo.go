@@ -0,0 +1,49 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// Undo reverses the last `n` changes. The server sets the time of the
+// change, so `t` is ignored.
+func (c *Client) Undo(n int, t time.Time) ([]*models.OperationModel, error) {
+	return c.replay("/undo", n)
+}
+
+// Redo applies again the last `n` changes that were undone. The server sets
+// the time of the change, so `t` is ignored.
+func (c *Client) Redo(n int, t time.Time) ([]*models.OperationModel, error) {
+	return c.replay("/redo", n)
+}
+
+func (c *Client) replay(path string, n int) ([]*models.OperationModel, error) {
+	var ops []*models.OperationModel
+	err := c.do(http.MethodPost, path, nil, map[string]int{"count": n}, &ops)
+
+	return ops, err
+}
@@ -0,0 +1,109 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// webhookPath returns the path of a webhook given its id, with or without the
+// '#' prefix.
+func webhookPath(id string) (string, error) {
+	id = strings.TrimPrefix(id, "#")
+	if n, err := strconv.ParseInt(id, 10, 64); err != nil || n <= 0 {
+		return "", fmt.Errorf("%w: %q", models.ErrInvalidID, "#"+id)
+	}
+
+	return "/webhooks/" + id, nil
+}
+
+// ListWebhooks returns the webhooks of the user ordered by id, without their
+// secrets.
+func (c *Client) ListWebhooks() ([]*models.WebhookModel, error) {
+	var webhooks []*models.WebhookModel
+	err := c.do(http.MethodGet, "/webhooks", nil, nil, &webhooks)
+
+	return webhooks, err
+}
+
+// GetWebhook returns a webhook of the user, without its secret.
+func (c *Client) GetWebhook(id string) (*models.WebhookModel, error) {
+	path, err := webhookPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var wh models.WebhookModel
+	if err := c.do(http.MethodGet, path, nil, nil, &wh); err != nil {
+		return nil, err
+	}
+
+	return &wh, nil
+}
+
+// AddWebhook adds a webhook and returns it along with its secret, which is
+// random if `secret` is empty. The server sets the creation time, so `t` is
+// ignored.
+func (c *Client) AddWebhook(event string, u string, secret string, t time.Time) (*models.WebhookModel, error) {
+	var wh models.WebhookModel
+	err := c.do(http.MethodPost, "/webhooks", nil, map[string]string{
+		"event":  event,
+		"url":    u,
+		"secret": secret,
+	}, &wh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wh, nil
+}
+
+// DeleteWebhook deletes a webhook of the user and its deliveries.
+func (c *Client) DeleteWebhook(id string) error {
+	path, err := webhookPath(id)
+	if err != nil {
+		return err
+	}
+
+	return c.do(http.MethodDelete, path, nil, nil, nil)
+}
+
+// ListDeliveries returns the deliveries of a webhook of the user, oldest
+// first.
+func (c *Client) ListDeliveries(webhook string) ([]*models.DeliveryModel, error) {
+	path, err := webhookPath(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*models.DeliveryModel
+	err = c.do(http.MethodGet, path+"/deliveries", nil, nil, &deliveries)
+
+	return deliveries, err
+}
@@ -0,0 +1,59 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks(t *testing.T) {
+	c := newTestClient(t)
+
+	wh, err := c.AddWebhook("note.created", "https://example.com/hook", "secret", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", wh.Secret)
+
+	_, err = c.AddWebhook("note.created", "example.com", "", time.Now())
+	assert.True(t, errors.Is(err, models.ErrInvalidWebhook))
+
+	webhooks, err := c.ListWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(webhooks))
+	assert.Equal(t, "note.created", webhooks[0].Event)
+
+	deliveries, err := c.ListDeliveries("#" + wh.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	assert.NoError(t, c.DeleteWebhook(wh.Id))
+	_, err = c.GetWebhook(wh.Id)
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+
+	_, err = c.ListDeliveries("hook")
+	assert.True(t, errors.Is(err, models.ErrInvalidID))
+}
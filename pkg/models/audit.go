@@ -0,0 +1,194 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// Actor describes who makes a change. Besides the audit log, the user owns
+// the items they add and can only undo their own changes.
+type Actor struct {
+	User    string
+	Host    string
+	Command string
+}
+
+// CurrentActor returns the OS user and the host running clerk, who make the
+// changes of the commands.
+func CurrentActor() Actor {
+	a := Actor{User: os.Getenv("USER")}
+	if u, err := user.Current(); err == nil {
+		a.User = u.Username
+	}
+
+	a.Host, _ = os.Hostname()
+
+	return a
+}
+
+// AuditEntryModel struct representation of a row in `audit` table
+type AuditEntryModel struct {
+	Id        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	User      string          `json:"user"`
+	Host      string          `json:"host"`
+	Command   string          `json:"command"`
+	Type      string          `json:"type"`
+	EntityId  string          `json:"entity_id"`
+	Operation string          `json:"operation"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
+
+// String returns a printable representation of an audit entry
+func (a *AuditEntryModel) String() string {
+	return fmt.Sprintf(
+		"- %s | %s@%s | %s | %s %s #%s\n",
+		a.CreatedAt.Format(displayLayout),
+		a.User,
+		a.Host,
+		a.Command,
+		a.Operation,
+		a.Type,
+		a.EntityId,
+	)
+}
+
+// AuditFilter selects entries of the audit log. Empty fields match any
+// entry.
+type AuditFilter struct {
+	Since    time.Time
+	Type     string
+	EntityId string
+	User     string
+}
+
+// toJSON encodes `v` as JSON, or as NULL if `v` is nil.
+func toJSON(v interface{}) (sql.NullString, error) {
+	j, err := json.Marshal(v)
+	if err != nil || string(j) == "null" {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(j), Valid: true}, nil
+}
+
+// audit records a change in the audit log, along with the state of the
+// changed entity before and after it. Either state is nil if the entity
+// didn't exist.
+func audit(q querier, a Actor, entityType string, id string, op string, before interface{}, after interface{}, t time.Time) error {
+	beforeJSON, err := toJSON(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := toJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`INSERT INTO audit
+		(created_at, user, host, command, entity_type, entity_id, operation, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.Format(dateLayout), a.User, a.Host, a.Command,
+		entityType, id, op, beforeJSON, afterJSON,
+	)
+
+	return err
+}
+
+// ListAudit returns the entries of the audit log selected by `f`, oldest
+// first.
+func ListAudit(db *sql.DB, f AuditFilter) ([]*AuditEntryModel, error) {
+	var conditions []string
+	var args []interface{}
+	for _, c := range []struct {
+		condition string
+		value     string
+	}{
+		{"created_at >= ?", formatTime(f.Since)},
+		{"entity_type = ?", f.Type},
+		{"entity_id = ?", f.EntityId},
+		{"user = ?", f.User},
+	} {
+		if c.value != "" {
+			conditions = append(conditions, c.condition)
+			args = append(args, c.value)
+		}
+	}
+
+	query := `SELECT id, created_at, user, host, command, entity_type, entity_id,
+		operation, COALESCE(before,''), COALESCE(after,'') FROM audit`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*AuditEntryModel
+	for rows.Next() {
+		var createdAt, before, after string
+		a := new(AuditEntryModel)
+		err := rows.Scan(
+			&a.Id, &createdAt, &a.User, &a.Host, &a.Command, &a.Type, &a.EntityId,
+			&a.Operation, &before, &after,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		a.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+		if before != "" {
+			a.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			a.After = json.RawMessage(after)
+		}
+
+		res = append(res, a)
+	}
+
+	return res, rows.Err()
+}
+
+// formatTime formats `t` as stored in the database, or returns an empty
+// string if `t` is the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(dateLayout)
+}
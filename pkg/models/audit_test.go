@@ -0,0 +1,70 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectAudit expects a change to be recorded in the audit log.
+// testActor makes the changes in the tests.
+var testActor = Actor{User: "alice", Host: "localhost", Command: "clerk test"}
+
+func expectAudit(mock sqlmock.Sqlmock, entityType string, id string, op string) {
+	mock.ExpectExec("INSERT INTO audit").
+		WithArgs(
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			entityType, id, op, sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestListAudit(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	since := time.Date(2020, 9, 20, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT(.+)FROM audit WHERE created_at >= \\? AND entity_type = \\? AND user = \\? ORDER BY id").
+		WithArgs("2020-09-20 00:00:00", "task", "alice").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "created_at", "user", "host", "command", "entity_type", "entity_id",
+			"operation", "before", "after",
+		}).AddRow(
+			"1", "2020-09-20 15:00:00", "alice", "laptop", "clerk task add", "task", "1",
+			"add", "", `{"name":"test"}`,
+		))
+
+	entries, err := ListAudit(db, AuditFilter{Since: since, Type: "task", User: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Nil(t, entries[0].Before)
+	assert.Equal(t, `{"name":"test"}`, string(entries[0].After))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -22,12 +22,263 @@
 
 package models
 
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
 const dateLayout = "2006-01-02 15:04:05"
 
-func getIdFieldAndValue(id string) (string, string) {
+// displayLayout is the layout of the dates in the printable representation of
+// the models.
+var displayLayout = dateLayout
+
+// SetDateFormat sets the layout of the dates printed by the `String` methods
+// of the models. An empty layout restores the default one.
+func SetDateFormat(layout string) {
+	if layout == "" {
+		layout = dateLayout
+	}
+
+	displayLayout = layout
+}
+
+// Entity types, as returned by the `Type` method of the models.
+const (
+	TaskType = "task"
+	NoteType = "note"
+)
+
+// tables maps each entity type to the table where it's stored.
+var tables = map[string]string{
+	TaskType: "tasks",
+	NoteType: "notes",
+}
+
+// optionalTime returns nil for the zero time, so that unset dates are
+// encoded as null.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// getIdFieldAndValue returns the column and the value referred to by `id`,
+// which is either a name or an id prefixed by a '#'.
+func getIdFieldAndValue(id string) (string, string, error) {
+	if id == "" {
+		return "", "", fmt.Errorf("%w: empty name or id", ErrInvalidID)
+	}
+
 	if id[0] == '#' {
-		return "id", id[1:]
+		if n, err := strconv.ParseInt(id[1:], 10, 64); err != nil || n <= 0 {
+			return "", "", fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+
+		return "id", id[1:], nil
+	}
+
+	return "name", id, nil
+}
+
+// checkAffected returns a NotFoundError if `res` didn't affect any rows.
+func checkAffected(res sql.Result, table string, ref string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return &NotFoundError{Type: entityType(table), Ref: ref}
+	}
+
+	return nil
+}
+
+// entityType returns the type of the entities stored in `table`.
+func entityType(table string) string {
+	return strings.TrimSuffix(table, "s")
+}
+
+// findIds returns the ids of all the rows in `table` referred to by `ref`,
+// which is either a name or an id prefixed by a '#'. Items in the trash are
+// ignored.
+func findIds(db *sql.DB, table string, ref string) ([]string, error) {
+	field, value, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id FROM %s WHERE %s = ? AND deleted_at IS NULL ORDER BY id`,
+		table,
+		field,
+	)
+
+	return queryIds(db, query, value)
+}
+
+// queryIds returns the ids selected by `query`.
+func queryIds(q querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
 	}
 
-	return "name", id
+	return ids, rows.Err()
+}
+
+// FindIds returns the ids of all the items of type `entityType` referred to
+// by `ref`. Unlike the functions that operate on a single item, it doesn't
+// fail when a name refers to several items, which allows bulk operations.
+func FindIds(db *sql.DB, entityType string, ref string) ([]string, error) {
+	table, ok := tables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", entityType)
+	}
+
+	ids, err := findIds(db, table, ref)
+	if err == nil && len(ids) == 0 {
+		return nil, &NotFoundError{Type: entityType, Ref: ref}
+	}
+
+	return ids, err
+}
+
+// lookupId returns the id of the row in `table` referred to by `ref`, which
+// is either a name or an id prefixed by a '#'. It fails if `ref` is a name
+// shared by several rows.
+func lookupId(db *sql.DB, table string, ref string) (string, error) {
+	ids, err := findIds(db, table, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return oneId(ids, table, ref)
+}
+
+// oneId returns the only id in `ids`, the ids of the rows in `table` referred
+// to by `ref`. It fails if there's none or several.
+func oneId(ids []string, table string, ref string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", &NotFoundError{Type: entityType(table), Ref: ref}
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &AmbiguousNameError{Type: entityType(table), Name: ref, Ids: ids}
+	}
+}
+
+// validateName checks that `name` can be used as the name of a task or a
+// note, so that it can't be mistaken for an id.
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.HasPrefix(name, "#") {
+		return &InvalidNameError{Name: name}
+	}
+
+	return nil
+}
+
+// validatePriority checks that `priority` can be the priority of a task.
+func validatePriority(priority int) error {
+	if priority < 0 {
+		return fmt.Errorf("invalid priority %d, it can't be negative", priority)
+	}
+
+	return nil
+}
+
+// nameTaken reports whether there's already a row in `table` called `name`
+// other than the one with id `id`, ignoring the items in the trash. Names
+// are unique among the items of each owner, so that they don't reveal the
+// items of other users: new items, whose id is empty, are checked against
+// the items of `owner`, and the others against the ones of their owner.
+func nameTaken(db *sql.DB, table string, name string, id string, owner string) (bool, error) {
+	ownerQuery, args := "?", []interface{}{name, id, owner}
+	if id != "" {
+		ownerQuery = fmt.Sprintf(`(SELECT COALESCE(owner,'') FROM %s WHERE id = ?)`, table)
+		args[2] = id
+	}
+
+	query := fmt.Sprintf(
+		`SELECT COUNT(*) FROM %s WHERE name = ? AND deleted_at IS NULL AND id != ?
+		AND COALESCE(owner,'') = %s`,
+		table,
+		ownerQuery,
+	)
+
+	var count int
+	err := db.QueryRow(query, args...).Scan(&count)
+
+	return count > 0, err
+}
+
+// uniqueViolation reports whether `err` is the violation of a UNIQUE
+// constraint, in SQLite or PostgreSQL, which happens when two clerks insert
+// the same name at once.
+func uniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+
+	return false
+}
+
+// updateItem runs `query`, which updates the task or note in `table`
+// referred to by `ref`, and records the change as `op` in the item's history.
+// The id of the item is appended to `args`.
+func updateItem(db *sql.DB, a Actor, table string, ref string, op string, query string, args ...interface{}) error {
+	id, err := lookupId(db, table, ref)
+	if err != nil {
+		return err
+	}
+
+	return updateRow(db, a, table, id, ref, op, query, args...)
+}
+
+// updateRow is like updateItem, given the id of the item that `ref` refers
+// to.
+func updateRow(db *sql.DB, a Actor, table string, id string, ref string, op string, query string, args ...interface{}) error {
+	_, err := mutate(db, a, entityType(table), id, op, func(tx *change) (string, error) {
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return "", err
+		}
+
+		res, err := stmt.Exec(append(args, id)...)
+		if err != nil {
+			return "", err
+		}
+
+		return id, checkAffected(res, table, ref)
+	})
+
+	return err
 }
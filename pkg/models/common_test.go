@@ -0,0 +1,81 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIdFieldAndValue(t *testing.T) {
+	field, value, err := getIdFieldAndValue("#12")
+	assert.NoError(t, err)
+	assert.Equal(t, "id", field)
+	assert.Equal(t, "12", value)
+
+	field, value, err = getIdFieldAndValue("groceries")
+	assert.NoError(t, err)
+	assert.Equal(t, "name", field)
+	assert.Equal(t, "groceries", value)
+
+	for _, id := range []string{"", "#", "#abc", "#0", "#-1"} {
+		_, _, err = getIdFieldAndValue(id)
+		assert.True(t, errors.Is(err, ErrInvalidID), id)
+	}
+}
+
+func TestLookupIdNotFound(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "notes", "name", "missing")
+
+	_, err := lookupId(db, "notes", "missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "note missing not found")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCheckAffected(t *testing.T) {
+	assert.NoError(t, checkAffected(sqlmock.NewResult(0, 1), "tasks", "test"))
+
+	err := checkAffected(sqlmock.NewResult(0, 0), "tasks", "test")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestUniqueViolation(t *testing.T) {
+	assert.True(t, uniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}))
+	assert.False(t, uniqueViolation(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}))
+	assert.True(t, uniqueViolation(fmt.Errorf("inserting: %w", &pq.Error{Code: "23505"})))
+	assert.False(t, uniqueViolation(&pq.Error{Code: "23503"}))
+	assert.False(t, uniqueViolation(errors.New("UNIQUE constraint failed")))
+}
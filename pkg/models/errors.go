@@ -0,0 +1,167 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a name or id doesn't refer to any item.
+	ErrNotFound = errors.New("not found")
+
+	// ErrAmbiguous is returned when a name refers to more than one item.
+	ErrAmbiguous = errors.New("ambiguous name")
+
+	// ErrInvalidID is returned when an id isn't a positive integer.
+	ErrInvalidID = errors.New("invalid id")
+
+	// ErrInvalidName is returned when a name can't be used for a task or
+	// a note.
+	ErrInvalidName = errors.New("invalid name")
+
+	// ErrNameTaken is returned when a name is already used by another item
+	// of the same type.
+	ErrNameTaken = errors.New("name taken")
+
+	// ErrUnauthorized is returned when an API token is unknown or has been
+	// revoked.
+	ErrUnauthorized = errors.New("invalid or revoked token")
+
+	// ErrForbidden is returned when a user can see an item but isn't
+	// allowed to make a given change, such as sharing someone else's item.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrInvalidWebhook is returned when a webhook has an unknown event or
+	// an invalid URL.
+	ErrInvalidWebhook = errors.New("invalid webhook")
+
+	// ErrNothingToUndo is returned when there are no changes left to undo.
+	ErrNothingToUndo = errors.New("nothing to undo")
+
+	// ErrNothingToRedo is returned when there are no undone changes left to
+	// redo.
+	ErrNothingToRedo = errors.New("nothing to redo")
+
+	// ErrConflict is returned when a change can't be undone or redone
+	// because the item it changed has been changed again since.
+	ErrConflict = errors.New("conflict")
+
+	// ErrInvalidCount is returned when the number of changes to undo or redo
+	// isn't positive.
+	ErrInvalidCount = errors.New("invalid number of operations")
+)
+
+// NotFoundError is returned when a name or id doesn't refer to any item. It
+// matches ErrNotFound.
+type NotFoundError struct {
+	Type string
+	Ref  string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Type, e.Ref)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// AmbiguousNameError is returned when a name refers to more than one task or
+// note, which can happen with databases created before names were unique. It
+// matches ErrAmbiguous.
+type AmbiguousNameError struct {
+	Type string
+	Name string
+	Ids  []string
+}
+
+func (e *AmbiguousNameError) Error() string {
+	return fmt.Sprintf(
+		"%q matches %d %ss (#%s), use an id instead",
+		e.Name,
+		len(e.Ids),
+		e.Type,
+		strings.Join(e.Ids, ", #"),
+	)
+}
+
+func (e *AmbiguousNameError) Is(target error) bool {
+	return target == ErrAmbiguous
+}
+
+// InvalidNameError is returned when a name is empty or could be mistaken for
+// an id. It matches ErrInvalidName.
+type InvalidNameError struct {
+	Name string
+}
+
+func (e *InvalidNameError) Error() string {
+	if strings.TrimSpace(e.Name) == "" {
+		return "the name can't be empty"
+	}
+
+	return fmt.Sprintf("the name %q can't start with a '#'", e.Name)
+}
+
+func (e *InvalidNameError) Is(target error) bool {
+	return target == ErrInvalidName
+}
+
+// NameTakenError is returned when adding, renaming or restoring an item would
+// give it the name of another item of the same type. It matches ErrNameTaken.
+type NameTakenError struct {
+	Type string
+	Name string
+}
+
+func (e *NameTakenError) Error() string {
+	return fmt.Sprintf("there's already a %s called %q", e.Type, e.Name)
+}
+
+func (e *NameTakenError) Is(target error) bool {
+	return target == ErrNameTaken
+}
+
+// ConflictError is returned when undoing or redoing an operation would
+// overwrite a later change of the item. It matches ErrConflict.
+type ConflictError struct {
+	Type string
+	Id   string
+	Op   string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf(
+		"can't %s: %s #%s has changed since, undo its later changes first",
+		e.Op,
+		e.Type,
+		e.Id,
+	)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -0,0 +1,161 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Kinds of events, which are named after the type of the item, e.g.
+// `task.completed`.
+const (
+	EventCreated   = "created"
+	EventUpdated   = "updated"
+	EventDeleted   = "deleted"
+	EventCompleted = "completed"
+)
+
+// EventModel is a change made to a task or a note. Events are read from the
+// audit log, so their ids are the ids of the audit entries and only grow.
+type EventModel struct {
+	Id        string          `json:"id"`
+	Event     string          `json:"event"`
+	Type      string          `json:"type"`
+	EntityId  string          `json:"entity_id"`
+	Operation string          `json:"operation"`
+	User      string          `json:"user"`
+	CreatedAt time.Time       `json:"created_at"`
+	Item      json.RawMessage `json:"item,omitempty"`
+}
+
+// String returns a printable representation of an event
+func (e *EventModel) String() string {
+	return fmt.Sprintf(
+		"- %s | %s | %s #%s | %s by %s\n",
+		e.CreatedAt.Format(displayLayout),
+		e.Event,
+		e.Type,
+		e.EntityId,
+		e.Operation,
+		e.User,
+	)
+}
+
+// Is reports whether the event matches `name`, which is either the name of
+// an event, e.g. `task.completed`, the type of the item, e.g. `task`, or `*`
+// for any event.
+func (e *EventModel) Is(name string) bool {
+	return name == "*" || name == e.Event || name == e.Type
+}
+
+// eventKind returns the kind of event of a change to a task or a note, given
+// its state before and after the change. Restoring an item from the trash
+// creates it again. It returns an empty string for changes to items in the
+// trash, which aren't events.
+func eventKind(before string, after string) string {
+	var b, a snapshot
+	if before != "" {
+		json.Unmarshal([]byte(before), &b)
+	}
+	if after != "" {
+		json.Unmarshal([]byte(after), &a)
+	}
+
+	switch {
+	case before != "" && b.DeletedAt != "" && (after == "" || a.DeletedAt != ""):
+		return ""
+	case before == "" || (b.DeletedAt != "" && a.DeletedAt == ""):
+		return EventCreated
+	case after == "" || a.DeletedAt != "":
+		return EventDeleted
+	case b.CompletedAt == "" && a.CompletedAt != "":
+		return EventCompleted
+	default:
+		return EventUpdated
+	}
+}
+
+// LatestEventId returns the id of the latest event, or 0 if there are none
+// yet.
+func LatestEventId(db *sql.DB) (string, error) {
+	var id string
+	err := db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM audit`).Scan(&id)
+
+	return id, err
+}
+
+// ListEvents returns the events after the one with id `after`, oldest first,
+// or all of them if `after` is empty.
+func ListEvents(db *sql.DB, after string) ([]*EventModel, error) {
+	var from int64
+	if after != "" {
+		var err error
+		from, err = strconv.ParseInt(after, 10, 64)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("%w: event %q", ErrInvalidID, after)
+		}
+	}
+
+	rows, err := db.Query(`SELECT id, created_at, user, entity_type, entity_id,
+		operation, COALESCE(before,''), COALESCE(after,'') FROM audit
+		WHERE id > ? AND entity_type IN (?, ?)
+		ORDER BY id`,
+		from, TaskType, NoteType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*EventModel
+	for rows.Next() {
+		var createdAt, before, after string
+		e := new(EventModel)
+		err := rows.Scan(
+			&e.Id, &createdAt, &e.User, &e.Type, &e.EntityId, &e.Operation,
+			&before, &after,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		kind := eventKind(before, after)
+		if kind == "" {
+			continue
+		}
+
+		e.Event = e.Type + "." + kind
+		e.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+		if after != "" {
+			e.Item = json.RawMessage(after)
+		}
+
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,90 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListEvents(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	const (
+		pending   = `{"name":"test","completed_at":"","deleted_at":""}`
+		completed = `{"name":"test","completed_at":"2020-09-20 16:00:00","deleted_at":""}`
+		trashed   = `{"name":"test","completed_at":"2020-09-20 16:00:00","deleted_at":"2020-09-20 17:00:00"}`
+	)
+
+	mock.ExpectQuery("SELECT(.+)FROM audit WHERE id > \\? AND entity_type IN \\(\\?, \\?\\) ORDER BY id").
+		WithArgs(3, "task", "note").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "created_at", "user", "entity_type", "entity_id", "operation",
+			"before", "after",
+		}).
+			AddRow("4", "2020-09-20 15:00:00", "alice", "task", "1", "add", "", pending).
+			AddRow("5", "2020-09-20 15:30:00", "alice", "task", "1", "rename", pending, pending).
+			AddRow("6", "2020-09-20 16:00:00", "alice", "task", "1", "done", pending, completed).
+			AddRow("7", "2020-09-20 17:00:00", "bob", "task", "1", "delete", completed, trashed).
+			AddRow("8", "2020-09-20 18:00:00", "bob", "task", "1", "purge", trashed, "").
+			AddRow("9", "2020-09-20 19:00:00", "bob", "task", "1", "restore", trashed, completed).
+			AddRow("10", "2020-09-20 20:00:00", "bob", "task", "1", "undo", pending, ""))
+
+	events, err := ListEvents(db, "3")
+	assert.NoError(t, err)
+
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, e.Id+" "+e.Event)
+	}
+	assert.Equal(t, []string{
+		"4 task.created",
+		"5 task.updated",
+		"6 task.completed",
+		"7 task.deleted",
+		"9 task.created",
+		"10 task.deleted",
+	}, kinds)
+	assert.Equal(t, completed, string(events[2].Item))
+	assert.Nil(t, events[5].Item)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListEventsInvalidId(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	_, err := ListEvents(db, "last")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,231 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const linkType = "link"
+
+// linkState is the state of a link recorded in the audit log.
+type linkState struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// LinkModel represents an item (task or note) linked to another one.
+type LinkModel struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// String returns a printable representation of a linked item
+func (l *LinkModel) String() string {
+	return fmt.Sprintf("%s #%s (%s)", l.Type, l.Id, l.Name)
+}
+
+// ParseRef splits a reference such as `#task:3` or `note:groceries` into the
+// entity type and a name-or-id, where the id is prefixed by a '#'.
+func ParseRef(ref string) (string, string, error) {
+	isId := strings.HasPrefix(ref, "#")
+	parts := strings.SplitN(strings.TrimPrefix(ref, "#"), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf(
+			"invalid reference %q, expected <type>:<name> or #<type>:<id>",
+			ref,
+		)
+	}
+
+	if _, ok := tables[parts[0]]; !ok {
+		return "", "", fmt.Errorf("unknown type %q in reference %q", parts[0], ref)
+	}
+
+	if isId {
+		return parts[0], "#" + parts[1], nil
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// resolveRef returns the entity type and id referred to by `ref`.
+func resolveRef(db *sql.DB, ref string) (string, string, error) {
+	entityType, nameOrId, err := ParseRef(ref)
+	if err != nil {
+		return "", "", err
+	}
+
+	id, err := lookupId(db, tables[entityType], nameOrId)
+
+	return entityType, id, err
+}
+
+// AddLink links two items given their references (e.g. `#task:3` and
+// `#note:7`). Linking items that are already linked is a no-op.
+func AddLink(db *sql.DB, a Actor, from string, to string, t time.Time) error {
+	fromType, fromId, err := resolveRef(db, from)
+	if err != nil {
+		return err
+	}
+
+	toType, toId, err := resolveRef(db, to)
+	if err != nil {
+		return err
+	}
+
+	if fromType == toType && fromId == toId {
+		return fmt.Errorf("can't link %s to itself", from)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	insertQuery := `INSERT OR IGNORE INTO links
+		(from_type, from_id, to_type, to_id, created_at) VALUES (?, ?, ?, ?, ?)`
+	stmt, err := tx.Prepare(insertQuery)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res, err := stmt.Exec(fromType, fromId, toType, toId, t.Format(dateLayout))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		tx.Rollback()
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	l := &linkState{From: fromType + ":" + fromId, To: toType + ":" + toId}
+	err = audit(tx, a, linkType, strconv.FormatInt(id, 10), OpAdd, nil, l, t)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteLink removes the link between two items, regardless of the order in
+// which they were linked.
+func DeleteLink(db *sql.DB, a Actor, from string, to string) error {
+	fromType, fromId, err := resolveRef(db, from)
+	if err != nil {
+		return err
+	}
+
+	toType, toId, err := resolveRef(db, to)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var id string
+	l := new(linkState)
+	err = tx.QueryRow(`SELECT
+		id, from_type || ':' || from_id, to_type || ':' || to_id FROM links WHERE
+		(from_type = ? AND from_id = ? AND to_type = ? AND to_id = ?) OR
+		(from_type = ? AND from_id = ? AND to_type = ? AND to_id = ?)`,
+		fromType, fromId, toType, toId,
+		toType, toId, fromType, fromId,
+	).Scan(&id, &l.From, &l.To)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return &NotFoundError{Type: linkType, Ref: from + " " + to}
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM links WHERE id = ?`, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = audit(tx, a, linkType, id, OpDelete, l, nil, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListLinks returns the items linked to the entity of type `entityType`
+// with the given id, ordered by type and id. Items in the trash are ignored.
+func ListLinks(db *sql.DB, entityType string, id string) ([]*LinkModel, error) {
+	rows, err := db.Query(`SELECT
+		l.type, l.id, COALESCE(tasks.name, notes.name, '') FROM (
+			SELECT to_type AS type, to_id AS id FROM links
+			WHERE from_type = ? AND from_id = ?
+			UNION
+			SELECT from_type, from_id FROM links
+			WHERE to_type = ? AND to_id = ?
+		) AS l
+		LEFT JOIN tasks ON l.type = 'task' AND tasks.id = l.id
+		LEFT JOIN notes ON l.type = 'note' AND notes.id = l.id
+		WHERE COALESCE(tasks.deleted_at, notes.deleted_at) IS NULL
+		ORDER BY l.type, l.id`,
+		entityType, id, entityType, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*LinkModel
+	for rows.Next() {
+		l := &LinkModel{}
+		err = rows.Scan(&l.Type, &l.Id, &l.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, l)
+	}
+
+	return res, nil
+}
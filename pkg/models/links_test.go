@@ -0,0 +1,96 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRef(t *testing.T) {
+	entityType, ref, err := ParseRef("#task:3")
+	assert.NoError(t, err)
+	assert.Equal(t, "task", entityType)
+	assert.Equal(t, "#3", ref)
+
+	entityType, ref, err = ParseRef("note:groceries")
+	assert.NoError(t, err)
+	assert.Equal(t, "note", entityType)
+	assert.Equal(t, "groceries", ref)
+
+	_, _, err = ParseRef("#3")
+	assert.Error(t, err)
+
+	_, _, err = ParseRef("event:3")
+	assert.Error(t, err)
+}
+
+func TestAddLink(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	created := time.Now()
+	expectLookup(mock, "tasks", "id", "3", "3")
+	expectLookup(mock, "notes", "name", "groceries", "7")
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare("INSERT OR IGNORE INTO links")
+	prep.ExpectExec().WithArgs(
+		"task", "3", "note", "7", created.Format(dateLayout),
+	).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(mock, "link", "1", OpAdd)
+	mock.ExpectCommit()
+
+	err := AddLink(db, testActor, "#task:3", "note:groceries", created)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListLinks(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{
+		"type",
+		"id",
+		"name",
+	}).AddRow("note", "7", "groceries")
+
+	mock.ExpectQuery("SELECT(.+)FROM links").
+		WithArgs("task", "3", "task", "3").
+		WillReturnRows(rows)
+
+	links, err := ListLinks(db, "task", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(links))
+	assert.Equal(t, "note #7 (groceries)", links[0].String())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,535 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps the tasks and notes in memory, e.g. for
+// tests. It behaves as SQLiteStore, except that it doesn't keep the history
+// of the items. The store returned by As only sees the items of its user, as
+// PostgresStore does.
+type MemoryStore struct {
+	*memoryItems
+
+	// user is the owner of the items that the store sees and adds, or empty
+	// for all of them.
+	user string
+}
+
+// memoryItems are the items of a MemoryStore, shared with the stores that
+// As returns.
+type memoryItems struct {
+	mu    sync.Mutex
+	tasks []*memoryItem
+	notes []*memoryItem
+}
+
+var (
+	_ SharedStore = (*MemoryStore)(nil)
+	_ TrashStore  = (*MemoryStore)(nil)
+)
+
+// memoryItem is a task or a note of a MemoryStore. Tasks have a single
+// element in `contents`. Items are never removed, so their id is their
+// position plus one: emptying the trash marks them as purged instead.
+type memoryItem struct {
+	id          string
+	name        string
+	owner       string
+	contents    []string
+	createdAt   time.Time
+	completedAt time.Time
+	due         time.Time
+	priority    int
+	deleted     bool
+	deletedAt   time.Time
+	purged      bool
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryItems: new(memoryItems)}
+}
+
+// As returns the store with the same items that only sees the ones of the
+// user of `a`, and adds them on their behalf.
+func (s *MemoryStore) As(a Actor) Store {
+	return &MemoryStore{memoryItems: s.memoryItems, user: a.User}
+}
+
+// visible reports whether the store sees an item, which it doesn't if it's
+// in the trash.
+func (s *MemoryStore) visible(i *memoryItem) bool {
+	return !i.deleted && (s.user == "" || i.owner == s.user)
+}
+
+// storedTime returns `t` as it's read back from the database, i.e. to the
+// second and without a time zone.
+func storedTime(t time.Time) time.Time {
+	st, _ := time.Parse(dateLayout, t.Format(dateLayout))
+
+	return st
+}
+
+// find returns the items in `items` that the store sees referred to by
+// `ref`.
+func (s *MemoryStore) find(items []*memoryItem, ref string) ([]*memoryItem, error) {
+	field, value, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*memoryItem
+	for _, i := range items {
+		if s.visible(i) && (field == "id" && i.id == value || field == "name" && i.name == value) {
+			found = append(found, i)
+		}
+	}
+
+	return found, nil
+}
+
+// lookup returns the item of type `entityType` in `items` referred to by
+// `ref`, as lookupId does.
+func (s *MemoryStore) lookup(items []*memoryItem, entityType string, ref string) (*memoryItem, error) {
+	found, err := s.find(items, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, &NotFoundError{Type: entityType, Ref: ref}
+	case 1:
+		return found[0], nil
+	default:
+		var ids []string
+		for _, i := range found {
+			ids = append(ids, i.id)
+		}
+
+		return nil, &AmbiguousNameError{Type: entityType, Name: ref, Ids: ids}
+	}
+}
+
+// add adds an item of `owner` called `name` to `items`, failing if the name
+// is invalid or taken, and returns its id.
+func add(items *[]*memoryItem, entityType string, owner string, name string, contents []string, t time.Time) (int64, error) {
+	if err := validateName(name); err != nil {
+		return -1, err
+	}
+	if memoryNameTaken(*items, owner, name, "") {
+		return -1, &NameTakenError{Type: entityType, Name: name}
+	}
+
+	id := int64(len(*items) + 1)
+	*items = append(*items, &memoryItem{
+		id:        strconv.FormatInt(id, 10),
+		name:      name,
+		owner:     owner,
+		contents:  contents,
+		createdAt: storedTime(t),
+	})
+
+	return id, nil
+}
+
+// memoryNameTaken reports whether an item of `owner` in `items` outside the
+// trash, other than the one with id `id`, is called `name`.
+func memoryNameTaken(items []*memoryItem, owner string, name string, id string) bool {
+	for _, i := range items {
+		if !i.deleted && i.owner == owner && i.name == name && i.id != id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (i *memoryItem) task() *TaskModel {
+	return &TaskModel{
+		Id:          i.id,
+		Name:        i.name,
+		Contents:    i.contents[0],
+		CreatedAt:   i.createdAt,
+		CompletedAt: i.completedAt,
+		Due:         i.due,
+		Priority:    i.priority,
+	}
+}
+
+func (i *memoryItem) note() *NoteModel {
+	n := &NoteModel{Id: i.id, Name: i.name, CreatedAt: i.createdAt}
+	if len(i.contents) > 0 {
+		n.Contents = append([]string(nil), i.contents...)
+	}
+
+	return n
+}
+
+func (s *MemoryStore) ListTasks() ([]*TaskModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []*TaskModel
+	for _, i := range s.tasks {
+		if s.visible(i) {
+			res = append(res, i.task())
+		}
+	}
+
+	return res, nil
+}
+
+func (s *MemoryStore) GetTask(task string) (*TaskModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.lookup(s.tasks, TaskType, task)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.task(), nil
+}
+
+func (s *MemoryStore) AddTask(name string, contents string, t time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return add(&s.tasks, TaskType, s.user, name, []string{contents}, t)
+}
+
+func (s *MemoryStore) FindIds(entityType string, ref string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*memoryItem
+	switch entityType {
+	case TaskType:
+		items = s.tasks
+	case NoteType:
+		items = s.notes
+	default:
+		return nil, fmt.Errorf("unknown type %q", entityType)
+	}
+
+	found, err := s.find(items, ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, &NotFoundError{Type: entityType, Ref: ref}
+	}
+
+	var ids []string
+	for _, i := range found {
+		ids = append(ids, i.id)
+	}
+
+	return ids, nil
+}
+
+// update calls `fn` with the item of type `entityType` referred to by `ref`.
+func (s *MemoryStore) update(entityType string, ref string, fn func(i *memoryItem)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.tasks
+	if entityType == NoteType {
+		items = s.notes
+	}
+
+	i, err := s.lookup(items, entityType, ref)
+	if err != nil {
+		return err
+	}
+	fn(i)
+
+	return nil
+}
+
+func (s *MemoryStore) EditTask(task string, contents string) error {
+	return s.update(TaskType, task, func(i *memoryItem) { i.contents[0] = contents })
+}
+
+func (s *MemoryStore) RenameTask(task string, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.lookup(s.tasks, TaskType, task)
+	if err != nil {
+		return err
+	}
+	if memoryNameTaken(s.tasks, i.owner, name, i.id) {
+		return &NameTakenError{Type: TaskType, Name: name}
+	}
+	i.name = name
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteTask(task string, t time.Time) error {
+	return s.update(TaskType, task, func(i *memoryItem) { i.deleted, i.deletedAt = true, storedTime(t) })
+}
+
+func (s *MemoryStore) CompleteTask(task string, t time.Time) error {
+	return s.update(TaskType, task, func(i *memoryItem) { i.completedAt = storedTime(t) })
+}
+
+func (s *MemoryStore) SetTaskDue(task string, due time.Time) error {
+	var d time.Time
+	if !due.IsZero() {
+		d, _ = time.ParseInLocation(dateLayout, due.Local().Format(dateLayout), time.Local)
+	}
+
+	return s.update(TaskType, task, func(i *memoryItem) { i.due = d })
+}
+
+func (s *MemoryStore) SetTaskPriority(task string, priority int) error {
+	if err := validatePriority(priority); err != nil {
+		return err
+	}
+
+	return s.update(TaskType, task, func(i *memoryItem) { i.priority = priority })
+}
+
+func (s *MemoryStore) ListNotes() ([]*NoteModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []*NoteModel
+	for _, i := range s.notes {
+		if s.visible(i) {
+			res = append(res, &NoteModel{Id: i.id, Name: i.name, CreatedAt: i.createdAt})
+		}
+	}
+
+	return res, nil
+}
+
+func (s *MemoryStore) GetNote(note string) (*NoteModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, err := s.lookup(s.notes, NoteType, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.note(), nil
+}
+
+func (s *MemoryStore) AddNote(name string, contents string, t time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var c []string
+	if contents != "" {
+		c = []string{contents}
+	}
+
+	return add(&s.notes, NoteType, s.user, name, c, t)
+}
+
+func (s *MemoryStore) AppendNote(note string, contents string) error {
+	return s.update(NoteType, note, func(i *memoryItem) { i.contents = append(i.contents, contents) })
+}
+
+// RenameNote renames a note and rewrites the wiki-links to it, as the
+// function RenameNote does.
+func (s *MemoryStore) RenameNote(note string, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.lookup(s.notes, NoteType, note)
+	if err != nil {
+		return err
+	}
+	if memoryNameTaken(s.notes, n.owner, name, n.id) {
+		return &NameTakenError{Type: NoteType, Name: name}
+	}
+
+	oldLink, newLink := wikiLink(n.name), wikiLink(name)
+	n.name = name
+	for _, items := range [][]*memoryItem{s.tasks, s.notes} {
+		for _, i := range items {
+			if !s.visible(i) {
+				continue
+			}
+			for j, c := range i.contents {
+				i.contents[j] = strings.ReplaceAll(c, oldLink, newLink)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteNote(note string, t time.Time) error {
+	return s.update(NoteType, note, func(i *memoryItem) { i.deleted, i.deletedAt = true, storedTime(t) })
+}
+
+// Search returns the tasks and then the notes whose name or contents contain
+// `query`, ignoring the case, as the function Search does.
+func (s *MemoryStore) Search(query string) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query = strings.ToLower(query)
+	matches := func(i *memoryItem) bool {
+		if !s.visible(i) {
+			return false
+		}
+		if strings.Contains(strings.ToLower(i.name), query) {
+			return true
+		}
+		for _, c := range i.contents {
+			if strings.Contains(strings.ToLower(c), query) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var res []Result
+	for _, i := range s.tasks {
+		if matches(i) {
+			res = append(res, &TaskModel{Id: i.id, Name: i.name, Contents: i.contents[0]})
+		}
+	}
+	for _, i := range s.notes {
+		if matches(i) {
+			res = append(res, &NoteModel{Id: i.id, Name: i.name, Contents: i.note().Contents})
+		}
+	}
+
+	return res, nil
+}
+
+// inTrash reports whether the store sees an item in the trash.
+func (s *MemoryStore) inTrash(i *memoryItem) bool {
+	return i.deleted && !i.purged && (s.user == "" || i.owner == s.user)
+}
+
+// ListTrash returns the tasks and notes in the trash, the most recently
+// deleted first, as the function ListTrash does.
+func (s *MemoryStore) ListTrash() ([]*TrashItemModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []*TrashItemModel
+	for _, entityType := range []string{TaskType, NoteType} {
+		items := s.tasks
+		if entityType == NoteType {
+			items = s.notes
+		}
+
+		for _, i := range items {
+			if s.inTrash(i) {
+				res = append(res, &TrashItemModel{Type: entityType, Id: i.id, Name: i.name, DeletedAt: i.deletedAt})
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].DeletedAt.After(res[j].DeletedAt) })
+
+	return res, nil
+}
+
+// RestoreItem takes a task or a note out of the trash, as the function
+// RestoreItem does.
+func (s *MemoryStore) RestoreItem(entityType string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*memoryItem
+	switch entityType {
+	case TaskType:
+		items = s.tasks
+	case NoteType:
+		items = s.notes
+	default:
+		return fmt.Errorf("unknown type %q", entityType)
+	}
+
+	ref := "#" + strings.TrimPrefix(id, "#")
+	_, id, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range items {
+		if i.id != id || !s.inTrash(i) {
+			continue
+		}
+
+		if memoryNameTaken(items, i.owner, i.name, i.id) {
+			return fmt.Errorf(
+				"%w, rename it before restoring %s",
+				&NameTakenError{Type: entityType, Name: i.name},
+				ref,
+			)
+		}
+		i.deleted, i.deletedAt = false, time.Time{}
+
+		return nil
+	}
+
+	return &NotFoundError{Type: entityType, Ref: ref + " in the trash"}
+}
+
+// EmptyTrash permanently deletes the tasks and notes that were moved to the
+// trash before `t`, or all of them if `t` is the zero time, as the function
+// EmptyTrash does.
+func (s *MemoryStore) EmptyTrash(t time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, items := range [][]*memoryItem{s.tasks, s.notes} {
+		for _, i := range items {
+			if s.inTrash(i) && (t.IsZero() || i.deletedAt.Before(storedTime(t))) {
+				i.purged = true
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
@@ -25,12 +25,13 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type NoteModel struct {
-	Id        string    `json:"idd"`
+	Id        string    `json:"id"`
 	Name      string    `json:"name"`
 	Contents  []string  `json:"contents"`
 	CreatedAt time.Time `json:"created_at"`
@@ -45,7 +46,7 @@ func (n *NoteModel) String() string {
 	} else {
 		createdAtStr = fmt.Sprintf(
 			" | created_at: %s",
-			n.CreatedAt.Format(dateLayout),
+			n.CreatedAt.Format(displayLayout),
 		)
 	}
 
@@ -64,8 +65,13 @@ func (n *NoteModel) String() string {
 	)
 }
 
+// wikiLink returns the wiki-link to the note called `name`.
+func wikiLink(name string) string {
+	return "[[" + name + "]]"
+}
+
 func (n *NoteModel) Type() string {
-	return "note"
+	return NoteType
 }
 
 // ListNotes lists all the existing notes. The displayed
@@ -73,6 +79,7 @@ func (n *NoteModel) Type() string {
 func ListNotes(db *sql.DB) ([]*NoteModel, error) {
 	rows, err := db.Query(`SELECT
 		id, name, created_at FROM notes
+		WHERE deleted_at IS NULL
 		ORDER BY id
 	`)
 	if err != nil {
@@ -98,16 +105,19 @@ func ListNotes(db *sql.DB) ([]*NoteModel, error) {
 	return res, nil
 }
 
+// GetNote returns a note and its contents given its name or id. If `note`
+// starts with a '#', then it refers to the note id.
 func GetNote(db *sql.DB, note string) (*NoteModel, error) {
-	field, id := getIdFieldAndValue(note)
-	noteMetadataQuery := fmt.Sprintf(
-		`SELECT id, name, created_at FROM notes WHERE %s = ?`,
-		field,
-	)
+	id, err := lookupId(db, "notes", note)
+	if err != nil {
+		return nil, err
+	}
+
+	noteMetadataQuery := `SELECT id, name, created_at FROM notes WHERE id = ?`
 	row := db.QueryRow(noteMetadataQuery, id)
 	n := new(NoteModel)
 	var createdAt string
-	err := row.Scan(&n.Id, &n.Name, &createdAt)
+	err = row.Scan(&n.Id, &n.Name, &createdAt)
 	if err != nil {
 		return nil, err
 	}
@@ -135,68 +145,171 @@ func GetNote(db *sql.DB, note string) (*NoteModel, error) {
 	return n, nil
 }
 
-func AddNote(db *sql.DB, name string, contents string, t time.Time) (int64, error) {
-	insertQuery := `INSERT INTO notes(name, created_at) VALUES (?, ?)`
-	stmt, err := db.Prepare(insertQuery)
-	if err != nil {
+// AddNote adds a new note given a name, its contents and creation time. If
+// `contents` is empty, the note is created without any contents. It fails if
+// there's already a note with the same name. The note belongs to the user of
+// `a`.
+func AddNote(db *sql.DB, a Actor, name string, contents string, t time.Time) (int64, error) {
+	if err := validateName(name); err != nil {
 		return -1, err
 	}
 
-	res, err := stmt.Exec(name, t.Format(dateLayout))
+	taken, err := nameTaken(db, "notes", name, "", a.User)
 	if err != nil {
 		return -1, err
 	}
-
-	id, err := res.LastInsertId()
-	if err != nil {
-		return -1, err
+	if taken {
+		return -1, &NameTakenError{Type: NoteType, Name: name}
 	}
 
-	insertContentsQuery := `INSERT INTO notes_contents (note_id, contents) VALUES (?, ?)`
-	stmt, err = db.Prepare(insertContentsQuery)
+	id, err := mutate(db, a, NoteType, "", OpAdd, func(tx *change) (string, error) {
+		insertQuery := `INSERT INTO notes(name, created_at, owner) VALUES (?, ?, ?)`
+		stmt, err := tx.Prepare(insertQuery)
+		if err != nil {
+			return "", err
+		}
+
+		res, err := stmt.Exec(name, t.Format(dateLayout), a.User)
+		if err != nil {
+			return "", err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil || contents == "" {
+			return strconv.FormatInt(id, 10), err
+		}
+
+		insertContentsQuery := `INSERT INTO notes_contents (note_id, contents) VALUES (?, ?)`
+		stmt, err = tx.Prepare(insertContentsQuery)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = stmt.Exec(id, contents)
+
+		return strconv.FormatInt(id, 10), err
+	})
 	if err != nil {
-		return id, err
+		return -1, err
 	}
 
-	_, err = stmt.Exec(id, contents)
+	return strconv.ParseInt(id, 10, 64)
+}
 
-	return id, err
+// AppendNote appends contents to a note given its name or id. If `note`
+// starts with a '#', then it refers to the note id.
+func AppendNote(db *sql.DB, a Actor, note string, contents string) error {
+	return updateItem(
+		db, a, "notes", note, OpAppend,
+		`INSERT INTO notes_contents (contents, note_id) VALUES (?, ?)`, contents,
+	)
 }
 
-func AppendNote(db *sql.DB, note string, contents string) error {
-	field, id := getIdFieldAndValue(note)
+// RenameNote renames a note given its name or id. It fails if there's
+// already a note called `name`. Wiki-links to the note (`[[name]]`) in the
+// contents of notes and tasks are rewritten to use the new name.
+func RenameNote(db *sql.DB, a Actor, note string, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
 
-	var placeHolder string
-	if field == "name" {
-		placeHolder = "SELECT id FROM notes WHERE name = ?"
-	} else {
-		placeHolder = "?"
+	id, err := lookupId(db, "notes", note)
+	if err != nil {
+		return err
 	}
 
-	appendQuery := fmt.Sprintf(
-		`INSERT INTO notes_contents (note_id, contents) VALUES ((%s), ?)`,
-		placeHolder,
-	)
+	taken, err := nameTaken(db, "notes", name, id, "")
+	if err != nil {
+		return err
+	}
+	if taken {
+		return &NameTakenError{Type: NoteType, Name: name}
+	}
 
-	stmt, err := db.Prepare(appendQuery)
+	var oldName string
+	err = db.QueryRow(`SELECT name FROM notes WHERE id = ?`, id).Scan(&oldName)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(id, contents)
+	_, err = mutate(db, a, NoteType, id, OpRename, func(tx *change) (string, error) {
+		_, err := tx.Exec(`UPDATE notes SET name = ? WHERE id = ?`, name, id)
+		if err != nil {
+			return "", err
+		}
+
+		return id, relink(tx, wikiLink(oldName), wikiLink(name))
+	})
 
 	return err
 }
 
-func DeleteNote(db *sql.DB, note string) error {
-	field, id := getIdFieldAndValue(note)
-	deleteQuery := fmt.Sprintf(`DELETE FROM notes WHERE %s = ?`, field)
-	stmt, err := db.Prepare(deleteQuery)
+// relink rewrites the wiki-link `oldLink` as `newLink` in the contents of the
+// notes and tasks that the user making the change can access, recording the
+// changes. The items in the trash and the ones of other users are left alone.
+func relink(tx *change, oldLink string, newLink string) error {
+	type item struct {
+		entityType string
+		id         string
+		before     *snapshot
+	}
+
+	u := tx.actor.User
+	rows, err := tx.Query(`SELECT DISTINCT 'note', note_id FROM notes_contents
+		WHERE INSTR(contents, ?) > 0 AND note_id IN (
+			SELECT id FROM notes WHERE deleted_at IS NULL AND `+accessible+`)
+		UNION
+		SELECT 'task', id FROM tasks
+		WHERE INSTR(contents, ?) > 0 AND deleted_at IS NULL AND `+accessible,
+		oldLink, u, NoteType, u,
+		oldLink, u, TaskType, u,
+	)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(id)
+	var items []*item
+	for rows.Next() {
+		i := new(item)
+		if err := rows.Scan(&i.entityType, &i.id); err != nil {
+			rows.Close()
+			return err
+		}
 
-	return err
+		items = append(items, i)
+	}
+	rows.Close()
+
+	for _, i := range items {
+		i.before, err = takeSnapshot(tx, i.entityType, i.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, i := range items {
+		query := `UPDATE tasks SET contents = REPLACE(contents, ?, ?) WHERE id = ?`
+		if i.entityType == NoteType {
+			query = `UPDATE notes_contents SET contents = REPLACE(contents, ?, ?) WHERE note_id = ?`
+		}
+
+		if _, err := tx.Exec(query, oldLink, newLink, i.id); err != nil {
+			return err
+		}
+
+		if err := tx.record(i.entityType, i.id, OpRelink, i.before); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteNote moves a note to the trash given its name or id. If `note`
+// starts with a '#', then it refers to the note id.
+func DeleteNote(db *sql.DB, a Actor, note string, t time.Time) error {
+	return updateItem(
+		db, a, "notes", note, OpDelete,
+		`UPDATE notes SET deleted_at = ? WHERE id = ?`, t.Format(dateLayout),
+	)
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -13,6 +14,7 @@ func TestListNotes(t *testing.T) {
 
 	query := `SELECT
 		id, name, created_at FROM notes
+		WHERE deleted_at IS NULL
 		ORDER BY id`
 	rows := sqlmock.NewRows([]string{
 		"id",
@@ -30,3 +32,71 @@ func TestListNotes(t *testing.T) {
 		t.Errorf("There were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestRenameNote(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "notes", "name", "test", "1")
+	expectNameCheck(mock, "notes", "renamed", "1", 0)
+	mock.ExpectQuery("SELECT name FROM notes WHERE id = \\?").
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("test"))
+	expectChange(mock)
+	expectSnapshot(mock, NoteType, "1", "test", "contents")
+	mock.ExpectExec("UPDATE notes SET name = \\? WHERE id = \\?").
+		WithArgs("renamed", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT DISTINCT 'note', note_id FROM notes_contents(.+)deleted_at IS NULL(.+)owner = \\?").
+		WithArgs(
+			"[[test]]", "alice", NoteType, "alice",
+			"[[test]]", "alice", TaskType, "alice",
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id"}).AddRow("task", "2"))
+	expectSnapshot(mock, TaskType, "2", "call", "read [[test]]")
+	mock.ExpectExec("UPDATE tasks SET contents = REPLACE\\(contents, \\?, \\?\\) WHERE id = \\?").
+		WithArgs("[[test]]", "[[renamed]]", "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "2", 1, true, OpRelink, "call", "read [[renamed]]")
+	expectRecord(mock, NoteType, "1", 1, true, OpRename, "renamed", "contents")
+	mock.ExpectCommit()
+
+	err := RenameNote(db, testActor, "test", "renamed")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRenameNoteRelinksAccessibleItems(t *testing.T) {
+	db := newTestDB(t)
+	bob := Actor{User: "bob"}
+	now := time.Now()
+
+	_, err := AddNote(db, testActor, "shopping", "", now)
+	assert.NoError(t, err)
+	_, err = AddTask(db, testActor, "mine", "see [[shopping]]", now)
+	assert.NoError(t, err)
+	_, err = AddTask(db, testActor, "trashed", "see [[shopping]]", now)
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteTask(db, testActor, "trashed", now))
+	_, err = AddTask(db, bob, "bob's", "see [[shopping]]", now)
+	assert.NoError(t, err)
+	_, err = AddTask(db, bob, "shared", "see [[shopping]]", now)
+	assert.NoError(t, err)
+	assert.NoError(t, ShareItem(db, bob, TaskType, "shared", "alice", now))
+
+	assert.NoError(t, RenameNote(db, testActor, "shopping", "groceries"))
+
+	for task, contents := range map[string]string{
+		"#1": "see [[groceries]]",
+		"#2": "see [[shopping]]",
+		"#3": "see [[shopping]]",
+		"#4": "see [[groceries]]",
+	} {
+		var c string
+		assert.NoError(t, db.QueryRow(`SELECT contents FROM tasks WHERE id = ?`, task[1:]).Scan(&c))
+		assert.Equal(t, contents, c, task)
+	}
+}
@@ -0,0 +1,626 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PostgresStore is the Store backed by a PostgreSQL database, which a team
+// can share. It only keeps the tasks and notes, without their history, and
+// searches them with the full-text search of PostgreSQL. The store returned
+// by As only sees the items of its user, since items can't be shared. See
+// database.SetupPostgres for its schema.
+type PostgresStore struct {
+	db    *sql.DB
+	actor Actor
+}
+
+var (
+	_ SharedStore = (*PostgresStore)(nil)
+	_ TrashStore  = (*PostgresStore)(nil)
+)
+
+// NewPostgresStore returns the store backed by `db`.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// As returns the store backed by the same database that only sees the items
+// of the user of `a`, and adds them on their behalf.
+func (s *PostgresStore) As(a Actor) Store {
+	return &PostgresStore{db: s.db, actor: a}
+}
+
+// Check checks that the tables of the tasks and notes can be read.
+func (s *PostgresStore) Check(ctx context.Context) error {
+	for _, table := range []string{"tasks", "notes"} {
+		var n int
+		err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("can't read the %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// owned is the condition on the rows that the store sees, given the user of
+// the store as the argument `$n`: all of them if it's empty.
+func owned(n int) string {
+	return fmt.Sprintf(`($%[1]d::text = '' OR owner = $%[1]d)`, n)
+}
+
+// findIds returns the ids of the rows in `table` outside the trash referred
+// to by `ref`.
+func (s *PostgresStore) findIds(table string, ref string) ([]string, error) {
+	field, value, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id FROM %s WHERE %s = $1 AND deleted_at IS NULL AND %s ORDER BY id`,
+		table,
+		field,
+		owned(2),
+	)
+
+	return queryIds(s.db, query, value, s.actor.User)
+}
+
+// lookupId returns the id of the row in `table` referred to by `ref`.
+func (s *PostgresStore) lookupId(table string, ref string) (string, error) {
+	ids, err := s.findIds(table, ref)
+	if err != nil {
+		return "", err
+	}
+
+	return oneId(ids, table, ref)
+}
+
+// checkName checks that `name` is valid and not taken in `table` by a row
+// other than the one with id `id`, which is empty for new items. Names are
+// unique per owner: the one of the row, or the user of the store for new
+// items.
+func (s *PostgresStore) checkName(table string, name string, id string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	var count int
+	err := s.db.QueryRow(
+		fmt.Sprintf(`SELECT COUNT(*) FROM %[1]s
+			WHERE name = $1 AND deleted_at IS NULL AND id::text <> $2
+			AND owner = COALESCE((SELECT owner FROM %[1]s WHERE id::text = $2), $3)`, table),
+		name, id, s.actor.User,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &NameTakenError{Type: entityType(table), Name: name}
+	}
+
+	return nil
+}
+
+// nameConflict returns a NameTakenError for `name` in `table` if `err` is
+// the violation of the unique index of the names, which happens when another
+// server takes the name after checkName, or `err` otherwise.
+func nameConflict(err error, table string, name string) error {
+	if uniqueViolation(err) {
+		return &NameTakenError{Type: entityType(table), Name: name}
+	}
+
+	return err
+}
+
+// update runs `query` on the row in `table` referred to by `ref`. The id of
+// the row is the last argument of the query.
+func (s *PostgresStore) update(table string, ref string, query string, args ...interface{}) error {
+	id, err := s.lookupId(table, ref)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(query, append(args, id)...)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, table, ref)
+}
+
+func (s *PostgresStore) FindIds(entityType string, ref string) ([]string, error) {
+	table, ok := tables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", entityType)
+	}
+
+	ids, err := s.findIds(table, ref)
+	if err == nil && len(ids) == 0 {
+		return nil, &NotFoundError{Type: entityType, Ref: ref}
+	}
+
+	return ids, err
+}
+
+const postgresTaskColumns = `id, name, contents, created_at, completed_at, due, priority`
+
+// scanPostgresTask scans a row with the columns in postgresTaskColumns.
+func scanPostgresTask(row interface{ Scan(...interface{}) error }) (*TaskModel, error) {
+	t := new(TaskModel)
+	var completedAt, due sql.NullTime
+	err := row.Scan(&t.Id, &t.Name, &t.Contents, &t.CreatedAt, &completedAt, &due, &t.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	t.CreatedAt = t.CreatedAt.Local()
+	if completedAt.Valid {
+		t.CompletedAt = completedAt.Time.Local()
+	}
+	if due.Valid {
+		t.Due = due.Time.Local()
+	}
+
+	return t, nil
+}
+
+func (s *PostgresStore) ListTasks() ([]*TaskModel, error) {
+	rows, err := s.db.Query(`SELECT `+postgresTaskColumns+` FROM tasks
+		WHERE deleted_at IS NULL AND `+owned(1)+`
+		ORDER BY id
+	`, s.actor.User)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*TaskModel
+	for rows.Next() {
+		t, err := scanPostgresTask(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, t)
+	}
+
+	return res, rows.Err()
+}
+
+func (s *PostgresStore) GetTask(task string) (*TaskModel, error) {
+	id, err := s.lookupId("tasks", task)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPostgresTask(s.db.QueryRow(
+		`SELECT `+postgresTaskColumns+` FROM tasks WHERE id = $1`, id,
+	))
+}
+
+func (s *PostgresStore) AddTask(name string, contents string, t time.Time) (int64, error) {
+	if err := s.checkName("tasks", name, ""); err != nil {
+		return -1, err
+	}
+
+	var id int64
+	err := s.db.QueryRow(
+		`INSERT INTO tasks (name, contents, created_at, owner) VALUES ($1, $2, $3, $4) RETURNING id`,
+		name, contents, t, s.actor.User,
+	).Scan(&id)
+	if err != nil {
+		return -1, nameConflict(err, "tasks", name)
+	}
+
+	return id, nil
+}
+
+func (s *PostgresStore) EditTask(task string, contents string) error {
+	return s.update("tasks", task, `UPDATE tasks SET contents = $1 WHERE id = $2`, contents)
+}
+
+func (s *PostgresStore) RenameTask(task string, name string) error {
+	id, err := s.lookupId("tasks", task)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkName("tasks", name, id); err != nil {
+		return err
+	}
+
+	err = s.update("tasks", "#"+id, `UPDATE tasks SET name = $1 WHERE id = $2`, name)
+
+	return nameConflict(err, "tasks", name)
+}
+
+func (s *PostgresStore) DeleteTask(task string, t time.Time) error {
+	return s.update("tasks", task, `UPDATE tasks SET deleted_at = $1 WHERE id = $2`, t)
+}
+
+func (s *PostgresStore) CompleteTask(task string, t time.Time) error {
+	return s.update("tasks", task, `UPDATE tasks SET completed_at = $1 WHERE id = $2`, t)
+}
+
+func (s *PostgresStore) SetTaskDue(task string, due time.Time) error {
+	var value interface{}
+	if !due.IsZero() {
+		value = due
+	}
+
+	return s.update("tasks", task, `UPDATE tasks SET due = $1 WHERE id = $2`, value)
+}
+
+func (s *PostgresStore) SetTaskPriority(task string, priority int) error {
+	if err := validatePriority(priority); err != nil {
+		return err
+	}
+
+	return s.update("tasks", task, `UPDATE tasks SET priority = $1 WHERE id = $2`, priority)
+}
+
+func (s *PostgresStore) ListNotes() ([]*NoteModel, error) {
+	rows, err := s.db.Query(`SELECT
+		id, name, created_at FROM notes
+		WHERE deleted_at IS NULL AND `+owned(1)+`
+		ORDER BY id
+	`, s.actor.User)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*NoteModel
+	for rows.Next() {
+		n := new(NoteModel)
+		if err := rows.Scan(&n.Id, &n.Name, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		n.CreatedAt = n.CreatedAt.Local()
+
+		res = append(res, n)
+	}
+
+	return res, rows.Err()
+}
+
+// noteContents returns the contents of the note with id `id`, in the order
+// they were added.
+func (s *PostgresStore) noteContents(id string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT contents FROM notes_contents WHERE note_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+
+		res = append(res, c)
+	}
+
+	return res, rows.Err()
+}
+
+func (s *PostgresStore) GetNote(note string) (*NoteModel, error) {
+	id, err := s.lookupId("notes", note)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(NoteModel)
+	err = s.db.QueryRow(`SELECT id, name, created_at FROM notes WHERE id = $1`, id).Scan(
+		&n.Id, &n.Name, &n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	n.CreatedAt = n.CreatedAt.Local()
+
+	n.Contents, err = s.noteContents(n.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (s *PostgresStore) AddNote(name string, contents string, t time.Time) (int64, error) {
+	if err := s.checkName("notes", name, ""); err != nil {
+		return -1, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(
+		`INSERT INTO notes (name, created_at, owner) VALUES ($1, $2, $3) RETURNING id`,
+		name, t, s.actor.User,
+	).Scan(&id)
+	if err != nil {
+		return -1, nameConflict(err, "notes", name)
+	}
+
+	if contents != "" {
+		_, err = tx.Exec(`INSERT INTO notes_contents (note_id, contents) VALUES ($1, $2)`, id, contents)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (s *PostgresStore) AppendNote(note string, contents string) error {
+	return s.update(
+		"notes", note,
+		`INSERT INTO notes_contents (contents, note_id) VALUES ($1, $2)`, contents,
+	)
+}
+
+// RenameNote renames a note and rewrites the wiki-links to it, as the
+// function RenameNote does.
+func (s *PostgresStore) RenameNote(note string, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	id, err := s.lookupId("notes", note)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkName("notes", name, id); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	if err := tx.QueryRow(`SELECT name FROM notes WHERE id = $1`, id).Scan(&oldName); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE notes SET name = $1 WHERE id = $2`, name, id); err != nil {
+		return nameConflict(err, "notes", name)
+	}
+
+	// Only the items that the store sees are rewritten, as the function
+	// RenameNote only rewrites the ones that the user can access.
+	oldLink, newLink := wikiLink(oldName), wikiLink(name)
+	for _, query := range []string{
+		`UPDATE notes_contents SET contents = REPLACE(contents, $1, $2)
+		WHERE STRPOS(contents, $1) > 0 AND note_id IN (
+			SELECT id FROM notes WHERE deleted_at IS NULL AND ` + owned(3) + `)`,
+		`UPDATE tasks SET contents = REPLACE(contents, $1, $2)
+		WHERE STRPOS(contents, $1) > 0 AND deleted_at IS NULL AND ` + owned(3),
+	} {
+		if _, err := tx.Exec(query, oldLink, newLink, s.actor.User); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) DeleteNote(note string, t time.Time) error {
+	return s.update("notes", note, `UPDATE notes SET deleted_at = $1 WHERE id = $2`, t)
+}
+
+// ListTrash returns the tasks and notes in the trash, the most recently
+// deleted first, as the function ListTrash does.
+func (s *PostgresStore) ListTrash() ([]*TrashItemModel, error) {
+	rows, err := s.db.Query(`SELECT
+		'task', id, name, deleted_at FROM tasks WHERE deleted_at IS NOT NULL AND `+owned(1)+`
+		UNION ALL
+		SELECT 'note', id, name, deleted_at FROM notes WHERE deleted_at IS NOT NULL AND `+owned(1)+`
+		ORDER BY 4 DESC
+	`, s.actor.User)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*TrashItemModel
+	for rows.Next() {
+		t := &TrashItemModel{}
+		if err := rows.Scan(&t.Type, &t.Id, &t.Name, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		t.DeletedAt = t.DeletedAt.Local()
+
+		res = append(res, t)
+	}
+
+	return res, rows.Err()
+}
+
+// RestoreItem takes a task or a note out of the trash given its type and
+// id, as the function RestoreItem does.
+func (s *PostgresStore) RestoreItem(entityType string, id string) error {
+	table, ok := tables[entityType]
+	if !ok {
+		return fmt.Errorf("unknown type %q", entityType)
+	}
+
+	ref := "#" + strings.TrimPrefix(id, "#")
+	_, id, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	err = s.db.QueryRow(
+		fmt.Sprintf(`SELECT name FROM %s WHERE id = $1 AND deleted_at IS NOT NULL AND %s`, table, owned(2)),
+		id, s.actor.User,
+	).Scan(&name)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Type: entityType, Ref: ref + " in the trash"}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkName(table, name, id); err != nil {
+		return fmt.Errorf("%w, rename it before restoring %s", err, ref)
+	}
+
+	res, err := s.db.Exec(fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE id = $1`, table), id)
+	if uniqueViolation(err) {
+		return fmt.Errorf("%w, rename it before restoring %s", nameConflict(err, table, name), ref)
+	}
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, table, ref)
+}
+
+// EmptyTrash permanently deletes the tasks and notes that were moved to the
+// trash before `t`, or all of them if `t` is the zero time, and returns how
+// many there were.
+func (s *PostgresStore) EmptyTrash(t time.Time) (int64, error) {
+	condition, args := "deleted_at IS NOT NULL AND "+owned(1), []interface{}{s.actor.User}
+	if !t.IsZero() {
+		condition += " AND deleted_at < $2"
+		args = append(args, t)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// The contents of the notes are deleted with them.
+	var count int64
+	for _, table := range []string{"tasks", "notes"} {
+		res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, condition), args...)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+
+	return count, tx.Commit()
+}
+
+// tsQuery returns the text search query that matches the documents with
+// words starting with each of the words in `query`, e.g. `'milk':* &
+// 'egg':*` for "milk, egg". Only letters and digits make up words.
+func tsQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = "'" + w + "':*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// Search returns the tasks and then the notes whose name or contents have
+// words that start with the words in `query`. Unlike the function Search, it
+// matches words rather than any part of the text. Only the id, the name and
+// the contents of the items are set.
+func (s *PostgresStore) Search(query string) ([]Result, error) {
+	q := tsQuery(query)
+	if q == "" {
+		return nil, nil
+	}
+
+	var res []Result
+
+	rows, err := s.db.Query(`SELECT id, name, contents FROM tasks
+		WHERE deleted_at IS NULL AND `+owned(2)+` AND search @@ to_tsquery('simple', $1)
+		ORDER BY id`, q, s.actor.User,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := new(TaskModel)
+		if err := rows.Scan(&t.Id, &t.Name, &t.Contents); err != nil {
+			return nil, err
+		}
+
+		res = append(res, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids, err := queryIds(s.db, `SELECT id FROM notes
+		WHERE deleted_at IS NULL AND `+owned(2)+` AND (
+			search @@ to_tsquery('simple', $1) OR EXISTS (
+				SELECT 1 FROM notes_contents
+				WHERE note_id = notes.id AND search @@ to_tsquery('simple', $1)
+			)
+		)
+		ORDER BY id`, q, s.actor.User,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		n, err := s.GetNote("#" + id)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &NoteModel{Id: n.Id, Name: n.Name, Contents: n.Contents})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,200 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	d "github.com/csixteen/clerk/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if postgres.stop != nil {
+		postgres.stop()
+	}
+
+	os.Exit(code)
+}
+
+// postgres is the PostgreSQL server of the tests, see postgresDSN.
+var postgres struct {
+	once sync.Once
+	dsn  string
+	stop func()
+	err  error
+}
+
+// postgresDSN returns the DSN of the PostgreSQL server to test against: the
+// one in $CLERK_TEST_POSTGRES or, if the PostgreSQL binaries are in the PATH,
+// one started in a temporary directory. Without either, the test is skipped.
+func postgresDSN(t *testing.T) string {
+	postgres.once.Do(func() {
+		if dsn := os.Getenv("CLERK_TEST_POSTGRES"); dsn != "" {
+			postgres.dsn = dsn
+			return
+		}
+
+		postgres.dsn, postgres.stop, postgres.err = startPostgres()
+	})
+
+	if postgres.err != nil {
+		t.Fatalf("An error occurred when starting PostgreSQL: %s", postgres.err)
+	}
+	if postgres.dsn == "" {
+		t.Skip("PostgreSQL isn't available, set CLERK_TEST_POSTGRES or add its binaries to the PATH")
+	}
+
+	return postgres.dsn
+}
+
+// startPostgres starts a PostgreSQL server that only listens on a Unix
+// socket in a temporary directory, if `initdb` and `pg_ctl` are in the
+// PATH, and returns its DSN and the function that stops it.
+func startPostgres() (string, func(), error) {
+	if _, err := exec.LookPath("initdb"); err != nil {
+		return "", nil, nil
+	}
+
+	dir, err := ioutil.TempDir("", "clerk-postgres")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+
+	out, err := exec.Command("initdb", "-D", data, "-U", "clerk", "--auth=trust").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb: %w\n%s", err, out)
+	}
+
+	out, err = exec.Command(
+		"pg_ctl", "-D", data, "-l", filepath.Join(dir, "log"), "-w",
+		"-o", fmt.Sprintf("-c listen_addresses='' -k %s", dir),
+		"start",
+	).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("pg_ctl start: %w\n%s", err, out)
+	}
+
+	stop := func() {
+		exec.Command("pg_ctl", "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+
+	return fmt.Sprintf("host=%s user=clerk dbname=postgres sslmode=disable", dir), stop, nil
+}
+
+// withSearchPath returns `dsn` with `schema` as the search path.
+func withSearchPath(dsn string, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+
+		return u.String()
+	}
+
+	return dsn + " search_path=" + schema
+}
+
+// newPostgresStore returns a store backed by a new schema of the PostgreSQL
+// server of the tests, which is dropped when the test ends.
+func newPostgresStore(t *testing.T) Store {
+	dsn := postgresDSN(t)
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("clerk_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("An error occurred when creating the schema: %s", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := d.SetupPostgres(withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("An error occurred when creating the database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewPostgresStore(db)
+}
+
+func TestTsQuery(t *testing.T) {
+	cases := map[string]string{
+		"milk":                 "'milk':*",
+		"Milk, EGGS":           "'milk':* & 'eggs':*",
+		"it's (a) 'test' & !x": "'it':* & 's':* & 'a':* & 'test':* & 'x':*",
+		"  ":                   "",
+	}
+	for query, expected := range cases {
+		assert.Equal(t, expected, tsQuery(query), query)
+	}
+}
+
+func TestWithSearchPath(t *testing.T) {
+	assert.Equal(t,
+		"postgres://clerk@localhost/clerk?search_path=test&sslmode=disable",
+		withSearchPath("postgres://clerk@localhost/clerk?sslmode=disable", "test"),
+	)
+	assert.True(t, strings.HasSuffix(withSearchPath("host=/tmp user=clerk", "test"), " search_path=test"))
+}
+
+func TestPostgresUniqueNames(t *testing.T) {
+	s := newPostgresStore(t).(*PostgresStore)
+	now := time.Now()
+
+	// Even without checkName, as when two servers add the same name at once.
+	insert := `INSERT INTO tasks (name, created_at, owner) VALUES ($1, $2, $3)`
+	_, err := s.db.Exec(insert, "groceries", now, "alice")
+	assert.NoError(t, err)
+	_, err = s.db.Exec(insert, "groceries", now, "alice")
+	assert.True(t, uniqueViolation(err), "%v", err)
+	assert.True(t, errors.Is(nameConflict(err, "tasks", "groceries"), ErrNameTaken))
+
+	// Other users and the trash don't take names.
+	_, err = s.db.Exec(insert, "groceries", now, "bob")
+	assert.NoError(t, err)
+	_, err = s.db.Exec(`UPDATE tasks SET deleted_at = $1 WHERE owner = 'alice'`, now)
+	assert.NoError(t, err)
+	_, err = s.db.Exec(insert, "groceries", now, "alice")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,491 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Operations recorded in the history of tasks and notes and in the audit
+// log.
+const (
+	OpAdd      = "add"
+	OpEdit     = "edit"
+	OpAppend   = "append"
+	OpRename   = "rename"
+	OpRelink   = "relink"
+	OpDone     = "done"
+	OpDelete   = "delete"
+	OpRestore  = "restore"
+	OpRevert   = "revert"
+	OpInitial  = "initial"
+	OpUndo     = "undo"
+	OpRedo     = "redo"
+	OpPurge    = "purge"
+	OpDue      = "due"
+	OpPriority = "priority"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// snapshot is the state of a task or a note at a given point in time. Tasks
+// have a single element in `Contents`. The owner isn't part of the history,
+// but it's needed to create items again when their creation is redone.
+type snapshot struct {
+	Name        string   `json:"name"`
+	Contents    []string `json:"contents"`
+	CreatedAt   string   `json:"created_at"`
+	CompletedAt string   `json:"completed_at"`
+	DeletedAt   string   `json:"deleted_at"`
+	Owner       string   `json:"owner,omitempty"`
+	Due         string   `json:"due,omitempty"`
+	Priority    int      `json:"priority,omitempty"`
+}
+
+// takeSnapshot returns the current state of a task or a note.
+func takeSnapshot(q querier, entityType string, id string) (*snapshot, error) {
+	s := new(snapshot)
+
+	if entityType == TaskType {
+		var contents string
+		err := q.QueryRow(`SELECT
+			name, contents, created_at, COALESCE(completed_at,''), COALESCE(deleted_at,''),
+			COALESCE(owner,''), COALESCE(due,''), priority FROM tasks WHERE id = ?`,
+			id,
+		).Scan(
+			&s.Name, &contents, &s.CreatedAt, &s.CompletedAt, &s.DeletedAt, &s.Owner,
+			&s.Due, &s.Priority,
+		)
+		if err != nil {
+			return nil, err
+		}
+		s.Contents = []string{contents}
+
+		return s, nil
+	}
+
+	err := q.QueryRow(`SELECT
+		name, created_at, COALESCE(deleted_at,''), COALESCE(owner,'')
+		FROM notes WHERE id = ?`,
+		id,
+	).Scan(&s.Name, &s.CreatedAt, &s.DeletedAt, &s.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(
+		`SELECT contents FROM notes_contents WHERE note_id = ? ORDER BY rowid`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contents string
+		if err := rows.Scan(&contents); err != nil {
+			return nil, err
+		}
+
+		s.Contents = append(s.Contents, contents)
+	}
+
+	return s, rows.Err()
+}
+
+// insertRevision appends a revision with the state `s` to the history of an
+// item.
+func insertRevision(q querier, entityType string, id string, rev int, op string, s *snapshot, t time.Time) error {
+	contents, err := json.Marshal(s.Contents)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`INSERT INTO revisions
+		(entity_type, entity_id, rev, operation, name, contents, completed_at, deleted_at, created_at,
+		due, priority)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)`,
+		entityType, id, rev, op, s.Name, string(contents),
+		s.CompletedAt, s.DeletedAt, t.Format(dateLayout), s.Due, s.Priority,
+	)
+
+	return err
+}
+
+// revise records the current state of an item as a new revision and
+// returns it. Items created before revisions were introduced don't have a
+// history yet, in which case their previous state `before` is recorded first.
+func revise(q querier, entityType string, id string, op string, before *snapshot, t time.Time) (*snapshot, error) {
+	var rev int
+	err := q.QueryRow(
+		`SELECT COALESCE(MAX(rev), 0) FROM revisions WHERE entity_type = ? AND entity_id = ?`,
+		entityType, id,
+	).Scan(&rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if rev == 0 && before != nil {
+		rev++
+		if err := insertRevision(q, entityType, id, rev, OpInitial, before, t); err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := takeSnapshot(q, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return after, insertRevision(q, entityType, id, rev+1, op, after, t)
+}
+
+// change is a transaction that changes one or more tasks and notes. Every
+// change is recorded in the history of the items and in the journal of
+// operations, where all the operations of a change share the same batch.
+type change struct {
+	*sql.Tx
+	actor Actor
+	batch int64
+	t     time.Time
+}
+
+// record records the change of an item, whose previous state was `before`,
+// in its history, in the journal of operations and in the audit log.
+func (c *change) record(entityType string, id string, op string, before *snapshot) error {
+	after, err := revise(c, entityType, id, op, before, c.t)
+	if err != nil {
+		return err
+	}
+
+	err = journal(c, c.actor, c.batch, entityType, id, op, before, after, c.t)
+	if err != nil {
+		return err
+	}
+
+	return audit(c, c.actor, entityType, id, op, before, after, c.t)
+}
+
+// mutate runs `fn`, which changes the item of type `entityType` with the
+// given id, in a transaction and records the change as made by `a`. When
+// adding items, `id` is empty and `fn` returns the id of the new item.
+func mutate(db *sql.DB, a Actor, entityType string, id string, op string, fn func(tx *change) (string, error)) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	c := &change{Tx: tx, actor: a, batch: newBatch(now), t: now}
+
+	// Undone operations can't be redone after a new change.
+	if err := clearRedo(c, a); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	var before *snapshot
+	if id != "" {
+		before, err = takeSnapshot(c, entityType, id)
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	id, err = fn(c)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	err = c.record(entityType, id, op, before)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	return id, tx.Commit()
+}
+
+// RevisionModel struct representation of a row in `revisions` table
+type RevisionModel struct {
+	Id          string    `json:"id"`
+	Type        string    `json:"type"`
+	EntityId    string    `json:"entity_id"`
+	Rev         int       `json:"rev"`
+	Operation   string    `json:"operation"`
+	Name        string    `json:"name"`
+	Contents    []string  `json:"contents"`
+	CompletedAt time.Time `json:"completed_at"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	Due         time.Time `json:"due"`
+	Priority    int       `json:"priority"`
+}
+
+// MarshalJSON encodes the unset dates of a revision as null, rather than as
+// the zero time.
+func (r RevisionModel) MarshalJSON() ([]byte, error) {
+	type revision RevisionModel
+
+	return json.Marshal(&struct {
+		revision
+		CompletedAt *time.Time `json:"completed_at"`
+		DeletedAt   *time.Time `json:"deleted_at"`
+		Due         *time.Time `json:"due"`
+	}{revision(r), optionalTime(r.CompletedAt), optionalTime(r.DeletedAt), optionalTime(r.Due)})
+}
+
+// String returns a printable representation of a Revision
+func (r *RevisionModel) String() string {
+	var scheduleStr string
+	if !r.Due.IsZero() {
+		scheduleStr += fmt.Sprintf(" | due: %s", r.Due.Format(displayLayout))
+	}
+	if r.Priority != 0 {
+		scheduleStr += fmt.Sprintf(" | priority: %d", r.Priority)
+	}
+
+	return fmt.Sprintf(
+		"- rev: %d | %s | name: %s | created_at: %s%s\n  Contents: %s\n",
+		r.Rev,
+		r.Operation,
+		r.Name,
+		r.CreatedAt.Format(displayLayout),
+		scheduleStr,
+		strings.Join(r.Contents, "; "),
+	)
+}
+
+// Text returns the name and the contents of the item at this revision, one
+// line per line of contents, so that revisions can be compared. The due
+// date and the priority of tasks come after the name.
+func (r *RevisionModel) Text() string {
+	text := "name: " + r.Name + "\n"
+	if r.Type == TaskType {
+		var due string
+		if !r.Due.IsZero() {
+			due = r.Due.Format(displayLayout)
+		}
+		text += fmt.Sprintf("due: %s\npriority: %d\n", due, r.Priority)
+	}
+
+	return text + strings.Join(r.Contents, "\n")
+}
+
+const revisionColumns = `id, entity_type, entity_id, rev, operation, name, contents,
+	COALESCE(completed_at,''), COALESCE(deleted_at,''), created_at,
+	COALESCE(due,''), priority`
+
+func scanRevision(scan func(dest ...interface{}) error) (*RevisionModel, error) {
+	var contents, completedAt, deletedAt, createdAt, due string
+	r := new(RevisionModel)
+	err := scan(
+		&r.Id, &r.Type, &r.EntityId, &r.Rev, &r.Operation, &r.Name, &contents,
+		&completedAt, &deletedAt, &createdAt, &due, &r.Priority,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(contents), &r.Contents); err != nil {
+		return nil, err
+	}
+
+	r.CompletedAt, _ = time.Parse(dateLayout, completedAt)
+	r.DeletedAt, _ = time.Parse(dateLayout, deletedAt)
+	r.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+	r.Due, _ = time.ParseInLocation(dateLayout, due, time.Local)
+
+	return r, nil
+}
+
+// ListRevisions returns the history of a task or a note, given its type and
+// its name or id, ordered by revision.
+func ListRevisions(db *sql.DB, entityType string, ref string) ([]*RevisionModel, error) {
+	id, err := lookupEntityId(db, entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		`SELECT `+revisionColumns+` FROM revisions
+		WHERE entity_type = ? AND entity_id = ? ORDER BY rev`,
+		entityType, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*RevisionModel
+	for rows.Next() {
+		r, err := scanRevision(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, r)
+	}
+
+	return res, rows.Err()
+}
+
+// GetRevision returns a revision of a task or a note, given its type, its
+// name or id and the revision number.
+func GetRevision(db *sql.DB, entityType string, ref string, rev int) (*RevisionModel, error) {
+	id, err := lookupEntityId(db, entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := scanRevision(db.QueryRow(
+		`SELECT `+revisionColumns+` FROM revisions
+		WHERE entity_type = ? AND entity_id = ? AND rev = ?`,
+		entityType, id, rev,
+	).Scan)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Type: "revision", Ref: fmt.Sprintf("%d of %s %s", rev, entityType, ref)}
+	}
+
+	return r, err
+}
+
+// CurrentRevision returns the current state of a task or a note as an
+// unsaved revision, which can be compared with the revisions in its history.
+func CurrentRevision(db *sql.DB, entityType string, ref string) (*RevisionModel, error) {
+	id, err := lookupEntityId(db, entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := takeSnapshot(db, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RevisionModel{
+		Type:      entityType,
+		EntityId:  id,
+		Operation: "current",
+		Name:      s.Name,
+		Contents:  s.Contents,
+		Priority:  s.Priority,
+	}
+	r.Due, _ = time.ParseInLocation(dateLayout, s.Due, time.Local)
+
+	return r, nil
+}
+
+// RevertItem sets the name and the contents of a task or a note, and the due
+// date and the priority of a task, back to the ones of a previous revision.
+// The revert itself is recorded as a new revision.
+func RevertItem(db *sql.DB, a Actor, entityType string, ref string, rev int) error {
+	r, err := GetRevision(db, entityType, ref, rev)
+	if err != nil {
+		return err
+	}
+
+	current, err := takeSnapshot(db, entityType, r.EntityId)
+	if err != nil {
+		return err
+	}
+
+	if current.Name != r.Name {
+		taken, err := nameTaken(db, tables[entityType], r.Name, r.EntityId, "")
+		if err != nil {
+			return err
+		}
+		if taken {
+			return &NameTakenError{Type: entityType, Name: r.Name}
+		}
+	}
+
+	_, err = mutate(db, a, entityType, r.EntityId, OpRevert, func(tx *change) (string, error) {
+		return r.EntityId, restoreRevision(tx, r)
+	})
+
+	return err
+}
+
+// restoreRevision sets the name and the contents of a task or a note, and the
+// due date and the priority of a task, to the ones of revision `r`.
+func restoreRevision(q querier, r *RevisionModel) error {
+	id := r.EntityId
+	if r.Type == TaskType {
+		var due string
+		if !r.Due.IsZero() {
+			due = r.Due.Local().Format(dateLayout)
+		}
+
+		_, err := q.Exec(
+			`UPDATE tasks SET name = ?, contents = ?, due = NULLIF(?, ''), priority = ? WHERE id = ?`,
+			r.Name, strings.Join(r.Contents, "\n"), due, r.Priority, id,
+		)
+		return err
+	}
+
+	_, err := q.Exec(`UPDATE notes SET name = ? WHERE id = ?`, r.Name, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`DELETE FROM notes_contents WHERE note_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range r.Contents {
+		_, err = q.Exec(
+			`INSERT INTO notes_contents (note_id, contents) VALUES (?, ?)`,
+			id, c,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupEntityId returns the id of the item of type `entityType` referred
+// to by `ref`.
+func lookupEntityId(db *sql.DB, entityType string, ref string) (string, error) {
+	table, ok := tables[entityType]
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", entityType)
+	}
+
+	return lookupId(db, table, ref)
+}
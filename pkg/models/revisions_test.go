@@ -0,0 +1,142 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectSnapshot expects the queries that read the current state of an item.
+func expectSnapshot(mock sqlmock.Sqlmock, entityType string, id string, name string, contents ...string) {
+	if entityType == TaskType {
+		mock.ExpectQuery("SELECT(.+)FROM tasks WHERE id = \\?").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"name", "contents", "created_at", "completed_at", "deleted_at", "owner",
+				"due", "priority",
+			}).AddRow(name, contents[0], "2020-09-20 15:00:00", "", "", "alice", "", 0))
+		return
+	}
+
+	mock.ExpectQuery("SELECT(.+)FROM notes WHERE id = \\?").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"name", "created_at", "deleted_at", "owner",
+		}).AddRow(name, "2020-09-20 15:00:00", "", "alice"))
+
+	rows := sqlmock.NewRows([]string{"contents"})
+	for _, c := range contents {
+		rows.AddRow(c)
+	}
+	mock.ExpectQuery("SELECT contents FROM notes_contents WHERE note_id = \\? ORDER BY rowid").
+		WithArgs(id).
+		WillReturnRows(rows)
+}
+
+// expectRevise expects an item's change to be recorded in its history,
+// where `lastRev` is the number of the latest revision of the item. If the
+// item has no history and `initial` is set, its previous state is recorded
+// first.
+func expectRevise(mock sqlmock.Sqlmock, entityType string, id string, lastRev int, initial bool, op string, name string, contents ...string) {
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(rev\\), 0\\) FROM revisions").
+		WithArgs(entityType, id).
+		WillReturnRows(sqlmock.NewRows([]string{"rev"}).AddRow(lastRev))
+
+	if lastRev == 0 && initial {
+		lastRev++
+		mock.ExpectExec("INSERT INTO revisions").
+			WithArgs(
+				entityType, id, lastRev, OpInitial,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(),
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	expectSnapshot(mock, entityType, id, name, contents...)
+	mock.ExpectExec("INSERT INTO revisions").
+		WithArgs(
+			entityType, id, lastRev+1, op,
+			name, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+}
+
+var revisionRows = []string{
+	"id", "entity_type", "entity_id", "rev", "operation", "name", "contents",
+	"completed_at", "deleted_at", "created_at", "due", "priority",
+}
+
+func TestListRevisions(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "notes", "name", "test", "1")
+	mock.ExpectQuery("SELECT(.+)FROM revisions(.+)ORDER BY rev").
+		WithArgs("note", "1").
+		WillReturnRows(sqlmock.NewRows(revisionRows).
+			AddRow("1", "note", "1", 1, "add", "test", `["one"]`, "", "", "2020-09-20 15:00:00", "", 0).
+			AddRow("2", "note", "1", 2, "append", "test", `["one","two"]`, "", "", "2020-09-20 15:01:00", "", 0))
+
+	revs, err := ListRevisions(db, "note", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(revs))
+	assert.Equal(t, []string{"one", "two"}, revs[1].Contents)
+	assert.Equal(t, "name: test\none\ntwo", revs[1].Text())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevertItem(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "tasks", "id", "1", "1")
+	mock.ExpectQuery("SELECT(.+)FROM revisions(.+)AND rev = \\?").
+		WithArgs("task", "1", 1).
+		WillReturnRows(sqlmock.NewRows(revisionRows).
+			AddRow("1", "task", "1", 1, "add", "test", `["old contents"]`, "", "", "2020-09-20 15:00:00",
+				"2020-10-12 18:00:00", 2))
+	expectSnapshot(mock, TaskType, "1", "test", "new contents")
+
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "1", "test", "new contents")
+	mock.ExpectExec("UPDATE tasks SET name = \\?, contents = \\?, due = NULLIF\\(\\?, ''\\), priority = \\? WHERE id = \\?").
+		WithArgs("test", "old contents", "2020-10-12 18:00:00", 2, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "1", 2, true, OpRevert, "test", "old contents")
+	mock.ExpectCommit()
+
+	err := RevertItem(db, testActor, "task", "#1", 1)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
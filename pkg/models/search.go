@@ -0,0 +1,111 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Result is a task or a note found by Search.
+type Result interface {
+	Type() string
+	String() string
+}
+
+func searchNotes(db *sql.DB, query string) ([]Result, error) {
+	// Notes without contents, e.g. new journal notes, are found by name.
+	searchNotesQuery := `SELECT DISTINCT id, name, COALESCE(GROUP_CONCAT(contents,'|'), '') as contents
+		FROM notes
+		LEFT JOIN notes_contents ON notes.id = notes_contents.note_id
+		WHERE deleted_at IS NULL AND (name LIKE '%' || ? || '%' OR COALESCE(contents, '') LIKE '%' || ? || '%')
+		GROUP BY id`
+
+	rows, err := db.Query(searchNotesQuery, query, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Result
+	for rows.Next() {
+		var contents string
+		n := &NoteModel{}
+		err = rows.Scan(&n.Id, &n.Name, &contents)
+		if err != nil {
+			return nil, err
+		}
+		if contents != "" {
+			n.Contents = strings.Split(contents, "|")
+		}
+
+		res = append(res, n)
+	}
+
+	return res, nil
+}
+
+func searchTasks(db *sql.DB, query string) ([]Result, error) {
+	searchTasksQuery := `SELECT DISTINCT id, name, contents FROM tasks
+		WHERE deleted_at IS NULL AND (name LIKE '%' || ? || '%' OR contents LIKE '%' || ? || '%')`
+
+	rows, err := db.Query(searchTasksQuery, query, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Result
+	for rows.Next() {
+		t := &TaskModel{}
+		err = rows.Scan(&t.Id, &t.Name, &t.Contents)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+// Search returns the tasks and then the notes whose name or contents contain
+// `query`, ignoring the case. Only the id, the name and the contents of the
+// items are set.
+func Search(db *sql.DB, query string) ([]Result, error) {
+	var res []Result
+
+	tasksResult, err := searchTasks(db, query)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, tasksResult...)
+
+	notesResult, err := searchNotes(db, query)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, notesResult...)
+
+	return res, nil
+}
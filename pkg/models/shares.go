@@ -0,0 +1,258 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const shareType = "share"
+
+// shareState is the state of a share recorded in the audit log.
+type shareState struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+	User string `json:"user"`
+}
+
+// ShareModel represents a user that a task or a note is shared with.
+type ShareModel struct {
+	User      string    `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// String returns a printable representation of a Share
+func (s *ShareModel) String() string {
+	return fmt.Sprintf(
+		"- user: %s | created_at: %s",
+		s.User,
+		s.CreatedAt.Format(displayLayout),
+	)
+}
+
+// accessible selects the tasks or notes that a user can access: the ones
+// they own and the ones shared with them. Its arguments are the user, the
+// entity type and the user again.
+const accessible = `(owner = ? OR id IN (
+	SELECT entity_id FROM shares WHERE entity_type = ? AND user = ?))`
+
+// AccessibleIds returns the ids of the items of type `entityType` that
+// `user` can access, including the ones in the trash.
+func AccessibleIds(db *sql.DB, entityType string, user string) (map[string]bool, error) {
+	table, ok := tables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", entityType)
+	}
+
+	ids, err := queryIds(
+		db,
+		fmt.Sprintf(`SELECT id FROM %s WHERE %s`, table, accessible),
+		user, entityType, user,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		res[id] = true
+	}
+
+	return res, nil
+}
+
+// CanAccess reports whether `user` can access the item of type `entityType`
+// with the given id, either because they own it or because it's shared with
+// them.
+func CanAccess(db *sql.DB, entityType string, id string, user string) (bool, error) {
+	table, ok := tables[entityType]
+	if !ok {
+		return false, fmt.Errorf("unknown type %q", entityType)
+	}
+
+	var count int
+	err := db.QueryRow(
+		fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE id = ? AND %s`, table, accessible),
+		id, user, entityType, user,
+	).Scan(&count)
+
+	return count > 0, err
+}
+
+// ItemOwner returns the owner of the item of type `entityType` with the
+// given id, which may be in the trash.
+func ItemOwner(db *sql.DB, entityType string, id string) (string, error) {
+	table, ok := tables[entityType]
+	if !ok {
+		return "", fmt.Errorf("unknown type %q", entityType)
+	}
+
+	var owner string
+	err := db.QueryRow(
+		fmt.Sprintf(`SELECT COALESCE(owner,'') FROM %s WHERE id = ?`, table),
+		id,
+	).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return "", &NotFoundError{Type: entityType, Ref: "#" + id}
+	}
+
+	return owner, err
+}
+
+// ListShares returns the users that a task or a note is shared with, given
+// its type and its name or id, ordered by name.
+func ListShares(db *sql.DB, entityType string, ref string) ([]*ShareModel, error) {
+	id, err := lookupEntityId(db, entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT
+		user, created_at FROM shares
+		WHERE entity_type = ? AND entity_id = ?
+		ORDER BY user`,
+		entityType, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*ShareModel
+	for rows.Next() {
+		var createdAt string
+		s := &ShareModel{}
+		if err := rows.Scan(&s.User, &createdAt); err != nil {
+			return nil, err
+		}
+
+		s.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
+
+// ShareItem shares a task or a note, given its type and its name or id, with
+// `user`, who can then see and change it. Sharing an item twice with the same
+// user is a no-op.
+func ShareItem(db *sql.DB, a Actor, entityType string, ref string, user string, t time.Time) error {
+	id, err := lookupEntityId(db, entityType, ref)
+	if err != nil {
+		return err
+	}
+
+	owner, err := ItemOwner(db, entityType, id)
+	if err != nil {
+		return err
+	}
+
+	if user == "" || user == owner {
+		return fmt.Errorf("can't share %s %s with %q", entityType, ref, user)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`INSERT OR IGNORE INTO shares
+		(entity_type, entity_id, user, created_at) VALUES (?, ?, ?, ?)`,
+		entityType, id, user, t.Format(dateLayout),
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		tx.Rollback()
+		return err
+	}
+
+	shareId, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	s := &shareState{Type: entityType, Id: id, User: user}
+	err = audit(tx, a, shareType, strconv.FormatInt(shareId, 10), OpAdd, nil, s, t)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UnshareItem stops sharing a task or a note, given its type and its name or
+// id, with `user`.
+func UnshareItem(db *sql.DB, a Actor, entityType string, ref string, user string) error {
+	id, err := lookupEntityId(db, entityType, ref)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var shareId string
+	err = tx.QueryRow(
+		`SELECT id FROM shares WHERE entity_type = ? AND entity_id = ? AND user = ?`,
+		entityType, id, user,
+	).Scan(&shareId)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return &NotFoundError{
+			Type: shareType,
+			Ref:  fmt.Sprintf("of %s %s with %s", entityType, ref, user),
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM shares WHERE id = ?`, shareId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	s := &shareState{Type: entityType, Id: id, User: user}
+	err = audit(tx, a, shareType, shareId, OpDelete, s, nil, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,136 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessibleIds(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id FROM tasks WHERE \\(owner = \\? OR id IN \\((.+)FROM shares").
+		WithArgs("alice", TaskType, "alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("3"))
+
+	ids, err := AccessibleIds(db, TaskType, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"1": true, "3": true}, ids)
+
+	_, err = AccessibleIds(db, "unknown", "alice")
+	assert.EqualError(t, err, `unknown type "unknown"`)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM notes WHERE id = \\? AND \\(owner = \\?").
+		WithArgs("2", "bob", NoteType, "bob").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	ok, err := CanAccess(db, NoteType, "2", "bob")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestShareItem(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	shared := time.Now()
+	ownerQuery := "SELECT COALESCE\\(owner,''\\) FROM tasks WHERE id = \\?"
+	expectLookup(mock, "tasks", "id", "1", "1")
+	mock.ExpectQuery(ownerQuery).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("alice"))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT OR IGNORE INTO shares").
+		WithArgs(TaskType, "1", "bob", shared.Format(dateLayout)).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	expectAudit(mock, shareType, "4", OpAdd)
+	mock.ExpectCommit()
+
+	expectLookup(mock, "tasks", "name", "test", "1")
+	mock.ExpectQuery(ownerQuery).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"owner"}).AddRow("alice"))
+
+	expectLookup(mock, "tasks", "id", "2")
+
+	assert.NoError(t, ShareItem(db, testActor, TaskType, "#1", "bob", shared))
+	assert.EqualError(t, ShareItem(db, testActor, TaskType, "test", "alice", shared), `can't share task test with "alice"`)
+	assert.True(t, errors.Is(ShareItem(db, testActor, TaskType, "#2", "bob", shared), ErrNotFound))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUnshareItem(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	query := "SELECT id FROM shares WHERE entity_type = \\? AND entity_id = \\? AND user = \\?"
+	expectLookup(mock, "notes", "id", "3", "3")
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).
+		WithArgs(NoteType, "3", "bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("4"))
+	mock.ExpectExec("DELETE FROM shares WHERE id = \\?").
+		WithArgs("4").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, shareType, "4", OpDelete)
+	mock.ExpectCommit()
+
+	expectLookup(mock, "notes", "id", "3", "3")
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).
+		WithArgs(NoteType, "3", "carol").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	assert.NoError(t, UnshareItem(db, testActor, NoteType, "#3", "bob"))
+
+	err := UnshareItem(db, testActor, NoteType, "#3", "carol")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "share of note #3 with carol not found")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
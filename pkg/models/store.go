@@ -0,0 +1,178 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TaskStore keeps the tasks. Tasks are referred to by name or by id prefixed
+// by a '#', and the stores fail with the errors of this package, e.g. a
+// NotFoundError when there's no such task.
+type TaskStore interface {
+	ListTasks() ([]*TaskModel, error)
+	GetTask(task string) (*TaskModel, error)
+	AddTask(name string, contents string, t time.Time) (int64, error)
+	EditTask(task string, contents string) error
+	RenameTask(task string, name string) error
+	DeleteTask(task string, t time.Time) error
+	CompleteTask(task string, t time.Time) error
+	SetTaskDue(task string, due time.Time) error
+	SetTaskPriority(task string, priority int) error
+}
+
+// NoteStore keeps the notes, which are referred to as the tasks are in a
+// TaskStore. ListNotes doesn't return the contents of the notes.
+type NoteStore interface {
+	ListNotes() ([]*NoteModel, error)
+	GetNote(note string) (*NoteModel, error)
+	AddNote(name string, contents string, t time.Time) (int64, error)
+	AppendNote(note string, contents string) error
+	RenameNote(note string, name string) error
+	DeleteNote(note string, t time.Time) error
+}
+
+// Searcher finds the tasks and notes that contain a query, as Search does.
+type Searcher interface {
+	Search(query string) ([]Result, error)
+}
+
+// Store keeps the tasks and the notes, and searches them.
+type Store interface {
+	TaskStore
+	NoteStore
+	Searcher
+
+	// FindIds returns the ids of all the items of type `entityType` referred
+	// to by `ref`, as the function FindIds does.
+	FindIds(entityType string, ref string) ([]string, error)
+}
+
+// TrashStore is a Store that keeps the deleted items in a trash, from which
+// they can be restored until it's emptied, as the functions ListTrash,
+// RestoreItem and EmptyTrash do.
+type TrashStore interface {
+	Store
+
+	ListTrash() ([]*TrashItemModel, error)
+	RestoreItem(entityType string, id string) error
+	EmptyTrash(t time.Time) (int64, error)
+}
+
+// SharedStore is a Store shared by several users, such as the one of a
+// clerk-server. As returns the same store, making the changes as `a`. The
+// stores that can't share items, unlike SQLiteStore, then only see the items
+// of the user of `a`.
+type SharedStore interface {
+	Store
+
+	As(a Actor) Store
+}
+
+// SQLiteStore is the Store backed by the SQLite database of clerk, which
+// also keeps the history of the items and everything else.
+type SQLiteStore struct {
+	db    *sql.DB
+	actor Actor
+}
+
+var _ SharedStore = (*SQLiteStore)(nil)
+
+// NewSQLiteStore returns the store backed by `db`, which makes the changes
+// as `a`.
+func NewSQLiteStore(db *sql.DB, a Actor) *SQLiteStore {
+	return &SQLiteStore{db: db, actor: a}
+}
+
+func (s *SQLiteStore) As(a Actor) Store {
+	return NewSQLiteStore(s.db, a)
+}
+
+func (s *SQLiteStore) ListTasks() ([]*TaskModel, error) {
+	return ListTasks(s.db)
+}
+
+func (s *SQLiteStore) GetTask(task string) (*TaskModel, error) {
+	return GetTask(s.db, task)
+}
+
+func (s *SQLiteStore) AddTask(name string, contents string, t time.Time) (int64, error) {
+	return AddTask(s.db, s.actor, name, contents, t)
+}
+
+func (s *SQLiteStore) EditTask(task string, contents string) error {
+	return EditTask(s.db, s.actor, task, contents)
+}
+
+func (s *SQLiteStore) RenameTask(task string, name string) error {
+	return RenameTask(s.db, s.actor, task, name)
+}
+
+func (s *SQLiteStore) DeleteTask(task string, t time.Time) error {
+	return DeleteTask(s.db, s.actor, task, t)
+}
+
+func (s *SQLiteStore) CompleteTask(task string, t time.Time) error {
+	return CompleteTask(s.db, s.actor, task, t)
+}
+
+func (s *SQLiteStore) SetTaskDue(task string, due time.Time) error {
+	return SetTaskDue(s.db, s.actor, task, due)
+}
+
+func (s *SQLiteStore) SetTaskPriority(task string, priority int) error {
+	return SetTaskPriority(s.db, s.actor, task, priority)
+}
+
+func (s *SQLiteStore) ListNotes() ([]*NoteModel, error) {
+	return ListNotes(s.db)
+}
+
+func (s *SQLiteStore) GetNote(note string) (*NoteModel, error) {
+	return GetNote(s.db, note)
+}
+
+func (s *SQLiteStore) AddNote(name string, contents string, t time.Time) (int64, error) {
+	return AddNote(s.db, s.actor, name, contents, t)
+}
+
+func (s *SQLiteStore) AppendNote(note string, contents string) error {
+	return AppendNote(s.db, s.actor, note, contents)
+}
+
+func (s *SQLiteStore) RenameNote(note string, name string) error {
+	return RenameNote(s.db, s.actor, note, name)
+}
+
+func (s *SQLiteStore) DeleteNote(note string, t time.Time) error {
+	return DeleteNote(s.db, s.actor, note, t)
+}
+
+func (s *SQLiteStore) Search(query string) ([]Result, error) {
+	return Search(s.db, query)
+}
+
+func (s *SQLiteStore) FindIds(entityType string, ref string) ([]string, error) {
+	return FindIds(s.db, entityType, ref)
+}
@@ -0,0 +1,330 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	d "github.com/csixteen/clerk/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// stores are the implementations of Store, each returning a new empty store.
+var stores = map[string]func(t *testing.T) Store{
+	"memory":   func(t *testing.T) Store { return NewMemoryStore() },
+	"sqlite":   func(t *testing.T) Store { return NewSQLiteStore(newTestDB(t), testActor) },
+	"postgres": newPostgresStore,
+}
+
+// newTestDB returns a new SQLite database, for the tests that need more than
+// sqlmock.
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := d.SetupDatabase(filepath.Join(t.TempDir(), "clerk.db"))
+	if err != nil {
+		t.Fatalf("An error occurred when creating the database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestStoreTasks(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			created := time.Date(2020, 10, 11, 9, 12, 45, 500, time.Local)
+
+			id, err := s.AddTask("groceries", "buy milk", created)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), id)
+
+			_, err = s.AddTask("groceries", "again", created)
+			assert.True(t, errors.Is(err, ErrNameTaken))
+			_, err = s.AddTask("#2", "", created)
+			assert.True(t, errors.Is(err, ErrInvalidName))
+
+			task, err := s.GetTask("#1")
+			assert.NoError(t, err)
+			assert.Equal(t, "groceries", task.Name)
+			assert.Equal(t, "buy milk", task.Contents)
+			assert.Equal(t, created.Format(dateLayout), task.CreatedAt.Format(dateLayout))
+			assert.True(t, task.CompletedAt.IsZero())
+
+			_, err = s.GetTask("laundry")
+			assert.True(t, errors.Is(err, ErrNotFound))
+			_, err = s.GetTask("#0")
+			assert.True(t, errors.Is(err, ErrInvalidID))
+
+			assert.NoError(t, s.EditTask("groceries", "buy eggs"))
+			_, err = s.AddTask("laundry", "", created)
+			assert.NoError(t, err)
+			assert.True(t, errors.Is(s.RenameTask("groceries", "laundry"), ErrNameTaken))
+			assert.NoError(t, s.RenameTask("groceries", "shopping"))
+			// The task doesn't take its own name.
+			assert.NoError(t, s.RenameTask("shopping", "shopping"))
+			assert.NoError(t, s.RenameTask("shopping", "Shopping"))
+			assert.NoError(t, s.RenameTask("Shopping", "shopping"))
+			due := time.Date(2020, 10, 12, 18, 0, 0, 0, time.Local)
+			assert.NoError(t, s.SetTaskDue("shopping", due))
+			assert.NoError(t, s.SetTaskPriority("shopping", 2))
+			assert.Error(t, s.SetTaskPriority("shopping", -1))
+
+			task, err = s.GetTask("shopping")
+			assert.NoError(t, err)
+			assert.True(t, due.Equal(task.Due), "due %s", task.Due)
+			assert.Equal(t, 2, task.Priority)
+			assert.True(t, task.Overdue(due.Add(time.Minute)))
+			assert.False(t, task.Overdue(due))
+
+			assert.NoError(t, s.CompleteTask("shopping", created))
+
+			task, err = s.GetTask("shopping")
+			assert.NoError(t, err)
+			assert.Equal(t, "buy eggs", task.Contents)
+			assert.False(t, task.CompletedAt.IsZero())
+			assert.False(t, task.Overdue(due.Add(time.Minute)))
+
+			assert.NoError(t, s.SetTaskDue("shopping", time.Time{}))
+			task, err = s.GetTask("shopping")
+			assert.NoError(t, err)
+			assert.True(t, task.Due.IsZero())
+
+			assert.NoError(t, s.DeleteTask("laundry", created))
+			assert.True(t, errors.Is(s.DeleteTask("laundry", created), ErrNotFound))
+
+			tasks, err := s.ListTasks()
+			assert.NoError(t, err)
+			if assert.Len(t, tasks, 1) {
+				assert.Equal(t, "shopping", tasks[0].Name)
+			}
+
+			// Names in the trash can be reused
+			id, err = s.AddTask("laundry", "", created)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), id)
+		})
+	}
+}
+
+func TestStoreNotes(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			now := time.Now()
+
+			_, err := s.AddNote("recipes", "", now)
+			assert.NoError(t, err)
+			_, err = s.AddNote("index", "see [[recipes]]", now)
+			assert.NoError(t, err)
+			_, err = s.AddTask("cook", "from [[recipes]]", now)
+			assert.NoError(t, err)
+
+			note, err := s.GetNote("recipes")
+			assert.NoError(t, err)
+			assert.Empty(t, note.Contents)
+
+			assert.NoError(t, s.AppendNote("recipes", "pancakes"))
+			assert.NoError(t, s.AppendNote("#1", "waffles"))
+			note, err = s.GetNote("#1")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"pancakes", "waffles"}, note.Contents)
+
+			notes, err := s.ListNotes()
+			assert.NoError(t, err)
+			assert.Len(t, notes, 2)
+			assert.Empty(t, notes[0].Contents)
+
+			// Renaming a note rewrites the links to it
+			assert.True(t, errors.Is(s.RenameNote("recipes", "index"), ErrNameTaken))
+			assert.NoError(t, s.RenameNote("recipes", "cookbook"))
+			assert.NoError(t, s.RenameNote("cookbook", "cookbook"))
+			assert.NoError(t, s.RenameNote("cookbook", "Cookbook"))
+			assert.NoError(t, s.RenameNote("Cookbook", "cookbook"))
+			note, err = s.GetNote("index")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"see [[cookbook]]"}, note.Contents)
+			task, err := s.GetTask("cook")
+			assert.NoError(t, err)
+			assert.Equal(t, "from [[cookbook]]", task.Contents)
+
+			assert.NoError(t, s.DeleteNote("index", now))
+			_, err = s.GetNote("index")
+			assert.True(t, errors.Is(err, ErrNotFound))
+			assert.True(t, errors.Is(s.AppendNote("index", "more"), ErrNotFound))
+		})
+	}
+}
+
+func TestStoreSearch(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			now := time.Now()
+
+			s.AddNote("shopping", "Milk and eggs", now)
+			s.AddTask("groceries", "buy milk", now)
+			s.AddTask("laundry", "", now)
+			s.AddTask("milkshake", "", now)
+			s.DeleteTask("milkshake", now)
+
+			results, err := s.Search("MILK")
+			assert.NoError(t, err)
+			assert.Equal(t, []Result{
+				&TaskModel{Id: "1", Name: "groceries", Contents: "buy milk"},
+				&NoteModel{Id: "1", Name: "shopping", Contents: []string{"Milk and eggs"}},
+			}, results)
+
+			// Notes without contents are found by name.
+			s.AddNote("diary", "", now)
+			results, err = s.Search("diar")
+			assert.NoError(t, err)
+			assert.Equal(t, []Result{&NoteModel{Id: "2", Name: "diary"}}, results)
+		})
+	}
+}
+
+func TestStoreOwners(t *testing.T) {
+	// The SQLite store leaves the access checks to the server, since items
+	// can be shared there.
+	for _, name := range []string{"memory", "postgres"} {
+		t.Run(name, func(t *testing.T) {
+			shared := stores[name](t).(SharedStore)
+			alice := shared.As(Actor{User: "alice"})
+			bob := shared.As(Actor{User: "bob"})
+			now := time.Now()
+
+			_, err := alice.AddTask("groceries", "buy milk", now)
+			assert.NoError(t, err)
+			_, err = alice.AddNote("recipes", "", now)
+			assert.NoError(t, err)
+			_, err = alice.AddTask("cook", "from [[recipes]]", now)
+			assert.NoError(t, err)
+
+			// Bob can't tell alice's items from the ones that don't exist.
+			_, err = bob.GetTask("#1")
+			assert.True(t, errors.Is(err, ErrNotFound))
+			_, err = bob.FindIds(TaskType, "groceries")
+			assert.True(t, errors.Is(err, ErrNotFound))
+			assert.True(t, errors.Is(bob.EditTask("#1", "buy eggs"), ErrNotFound))
+			assert.True(t, errors.Is(bob.AppendNote("recipes", "pie"), ErrNotFound))
+			assert.True(t, errors.Is(bob.DeleteTask("groceries", now), ErrNotFound))
+
+			// Names are unique per user.
+			_, err = bob.AddTask("groceries", "buy bread", now)
+			assert.NoError(t, err)
+			_, err = bob.AddNote("cookbook", "", now)
+			assert.NoError(t, err)
+			_, err = bob.AddTask("bake", "from [[recipes]]", now)
+			assert.NoError(t, err)
+			assert.True(t, errors.Is(alice.RenameTask("cook", "groceries"), ErrNameTaken))
+			assert.NoError(t, bob.RenameNote("cookbook", "recipes"))
+
+			tasks, err := bob.ListTasks()
+			assert.NoError(t, err)
+			assert.Len(t, tasks, 2)
+			notes, err := bob.ListNotes()
+			assert.NoError(t, err)
+			assert.Len(t, notes, 1)
+
+			results, err := bob.Search("milk")
+			assert.NoError(t, err)
+			assert.Empty(t, results)
+
+			// Renaming a note only rewrites the links in the items of its
+			// owner.
+			assert.NoError(t, alice.RenameNote("recipes", "cookbook"))
+			task, err := alice.GetTask("cook")
+			assert.NoError(t, err)
+			assert.Equal(t, "from [[cookbook]]", task.Contents)
+			task, err = bob.GetTask("bake")
+			assert.NoError(t, err)
+			assert.Equal(t, "from [[recipes]]", task.Contents)
+
+			// The store of nobody in particular sees everything.
+			tasks, err = shared.ListTasks()
+			assert.NoError(t, err)
+			assert.Len(t, tasks, 4)
+		})
+	}
+}
+
+func TestStoreTrash(t *testing.T) {
+	for _, name := range []string{"memory", "postgres"} {
+		t.Run(name, func(t *testing.T) {
+			shared := stores[name](t).(SharedStore)
+			alice := shared.As(Actor{User: "alice"}).(TrashStore)
+			bob := shared.As(Actor{User: "bob"}).(TrashStore)
+			yesterday := time.Now().Add(-24 * time.Hour)
+			now := time.Now()
+
+			alice.AddTask("groceries", "buy milk", yesterday)
+			alice.AddNote("recipes", "pie", yesterday)
+			bob.AddTask("laundry", "", yesterday)
+			assert.NoError(t, alice.DeleteTask("groceries", yesterday))
+			assert.NoError(t, alice.DeleteNote("recipes", now))
+			assert.NoError(t, bob.DeleteTask("laundry", now))
+
+			items, err := alice.ListTrash()
+			assert.NoError(t, err)
+			assert.Len(t, items, 2)
+			assert.Equal(t, "recipes", items[0].Name)
+			assert.Equal(t, NoteType, items[0].Type)
+			assert.Equal(t, "groceries", items[1].Name)
+
+			// Users can't restore each other's items.
+			err = bob.RestoreItem(TaskType, "1")
+			assert.True(t, errors.Is(err, ErrNotFound))
+			assert.Equal(t, "task #1 in the trash not found", err.Error())
+
+			// Nor the ones whose name has been taken in the meantime.
+			alice.AddTask("groceries", "buy eggs", now)
+			assert.True(t, errors.Is(alice.RestoreItem(TaskType, "#1"), ErrNameTaken))
+			assert.NoError(t, alice.RenameTask("groceries", "shopping"))
+			assert.NoError(t, alice.RestoreItem(TaskType, "#1"))
+			task, err := alice.GetTask("groceries")
+			assert.NoError(t, err)
+			assert.Equal(t, "buy milk", task.Contents)
+
+			assert.NoError(t, alice.DeleteTask("groceries", yesterday))
+			n, err := alice.EmptyTrash(now.Add(-time.Hour))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+
+			n, err = alice.EmptyTrash(time.Time{})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			items, err = alice.ListTrash()
+			assert.NoError(t, err)
+			assert.Empty(t, items)
+			assert.True(t, errors.Is(alice.RestoreItem(NoteType, "1"), ErrNotFound))
+
+			items, err = bob.ListTrash()
+			assert.NoError(t, err)
+			assert.Len(t, items, 1)
+		})
+	}
+}
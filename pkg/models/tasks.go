@@ -24,7 +24,9 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -35,6 +37,25 @@ type TaskModel struct {
 	Contents    string    `json:"contents"`
 	CreatedAt   time.Time `json:"created_at"`
 	CompletedAt time.Time `json:"completed_at"`
+	Due         time.Time `json:"due"`
+	Priority    int       `json:"priority"`
+}
+
+// Overdue reports whether the task is due before `now` and isn't completed.
+func (t *TaskModel) Overdue(now time.Time) bool {
+	return !t.Due.IsZero() && t.CompletedAt.IsZero() && t.Due.Before(now)
+}
+
+// MarshalJSON encodes the completion and due dates of a task as null when
+// they're unset, rather than as the zero time.
+func (t TaskModel) MarshalJSON() ([]byte, error) {
+	type task TaskModel
+
+	return json.Marshal(&struct {
+		task
+		CompletedAt *time.Time `json:"completed_at"`
+		Due         *time.Time `json:"due"`
+	}{task(t), optionalTime(t.CompletedAt), optionalTime(t.Due)})
 }
 
 // String returns a printable representation of a Task
@@ -45,27 +66,60 @@ func (t *TaskModel) String() string {
 	} else {
 		createdAtStr = fmt.Sprintf(
 			" | created_at: %s",
-			t.CreatedAt.Format(dateLayout),
+			t.CreatedAt.Format(displayLayout),
 		)
 	}
 
+	var scheduleStr string
+	if !t.Due.IsZero() {
+		scheduleStr += fmt.Sprintf(" | due: %s", t.Due.Format(displayLayout))
+	}
+	if t.Priority != 0 {
+		scheduleStr += fmt.Sprintf(" | priority: %d", t.Priority)
+	}
+
 	return fmt.Sprintf(
-		"- id: %s | name: %s%s\n  Contents: %s\n",
+		"- id: %s | name: %s%s%s\n  Contents: %s\n",
 		t.Id,
 		t.Name,
 		createdAtStr,
+		scheduleStr,
 		t.Contents,
 	)
 }
 
 func (t *TaskModel) Type() string {
-	return "task"
+	return TaskType
+}
+
+const taskColumns = `id, name, contents, created_at, COALESCE(completed_at,''),
+	COALESCE(due,''), priority`
+
+func scanTask(scan func(dest ...interface{}) error) (*TaskModel, error) {
+	var createdAt, completedAt, due string
+	t := &TaskModel{}
+	err := scan(&t.Id, &t.Name, &t.Contents, &createdAt, &completedAt, &due, &t.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	t.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+	if co, err := time.Parse(dateLayout, completedAt); err == nil {
+		t.CompletedAt = co
+	}
+	// Due dates are compared with the current time, so they're read in the
+	// time zone they were written in.
+	if d, err := time.ParseInLocation(dateLayout, due, time.Local); err == nil {
+		t.Due = d
+	}
+
+	return t, nil
 }
 
 // ListTask returns a slice of TaskModels ordered by `id`
 func ListTasks(db *sql.DB) ([]*TaskModel, error) {
-	rows, err := db.Query(`SELECT 
-		id, name, contents, created_at, COALESCE(completed_at,'') FROM tasks
+	rows, err := db.Query(`SELECT ` + taskColumns + ` FROM tasks
+		WHERE deleted_at IS NULL
 		ORDER BY id
 	`)
 	if err != nil {
@@ -75,81 +129,156 @@ func ListTasks(db *sql.DB) ([]*TaskModel, error) {
 
 	var res []*TaskModel
 	for rows.Next() {
-		var createdAt, completedAt string
-		t := &TaskModel{}
-		err = rows.Scan(&t.Id, &t.Name, &t.Contents, &createdAt, &completedAt)
+		t, err := scanTask(rows.Scan)
 		if err != nil {
 			return nil, err
 		}
 
-		cr, _ := time.Parse(dateLayout, createdAt)
-		t.CreatedAt = cr
-		co, coErr := time.Parse(dateLayout, completedAt)
-		if coErr == nil {
-			t.CompletedAt = co
-		}
-
 		res = append(res, t)
 	}
 
 	return res, nil
 }
 
-// AddTask adds a new task given a name, its contents and creation time
-func AddTask(db *sql.DB, name string, contents string, t time.Time) (int64, error) {
-	insertQuery := `INSERT INTO tasks(name, contents, created_at) VALUES (?, ?, ?)`
-	stmt, err := db.Prepare(insertQuery)
+// CountTasks returns the number of pending, completed and overdue tasks of
+// all the users, ignoring the ones in the trash. Tasks are overdue if they're
+// due before `now` and aren't completed, and aren't counted as pending then.
+func CountTasks(db *sql.DB, now time.Time) (pending int, completed int, overdue int, err error) {
+	err = db.QueryRow(`SELECT
+		COUNT(*) - COUNT(completed_at), COUNT(completed_at),
+		COUNT(CASE WHEN completed_at IS NULL AND due < ? THEN 1 END) FROM tasks
+		WHERE deleted_at IS NULL`,
+		now.Local().Format(dateLayout),
+	).Scan(&pending, &completed, &overdue)
+
+	return pending - overdue, completed, overdue, err
+}
+
+// GetTask returns a single task given its name or id. If `task` starts with
+// a '#', then it refers to the task id.
+func GetTask(db *sql.DB, task string) (*TaskModel, error) {
+	id, err := lookupId(db, "tasks", task)
 	if err != nil {
+		return nil, err
+	}
+
+	return scanTask(db.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id).Scan)
+}
+
+// AddTask adds a new task given a name, its contents and creation time. It
+// fails if there's already a task with the same name. The task belongs to the
+// user of `a`.
+func AddTask(db *sql.DB, a Actor, name string, contents string, t time.Time) (int64, error) {
+	if err := validateName(name); err != nil {
 		return -1, err
 	}
 
-	res, err := stmt.Exec(name, contents, t.Format(dateLayout))
+	taken, err := nameTaken(db, "tasks", name, "", a.User)
 	if err != nil {
 		return -1, err
 	}
+	if taken {
+		return -1, &NameTakenError{Type: TaskType, Name: name}
+	}
 
-	return res.LastInsertId()
-}
+	id, err := mutate(db, a, TaskType, "", OpAdd, func(tx *change) (string, error) {
+		insertQuery := `INSERT INTO tasks(name, contents, created_at, owner) VALUES (?, ?, ?, ?)`
+		stmt, err := tx.Prepare(insertQuery)
+		if err != nil {
+			return "", err
+		}
 
-// EditTask sets the contents of a task
-func EditTask(db *sql.DB, task string, contents string) error {
-	field, id := getIdFieldAndValue(task)
-	editQuery := fmt.Sprintf(`UPDATE tasks SET contents = ? WHERE %s = ?`, field)
-	stmt, err := db.Prepare(editQuery)
+		res, err := stmt.Exec(name, contents, t.Format(dateLayout), a.User)
+		if err != nil {
+			return "", err
+		}
+
+		id, err := res.LastInsertId()
+
+		return strconv.FormatInt(id, 10), err
+	})
 	if err != nil {
-		return err
+		return -1, err
 	}
 
-	_, err = stmt.Exec(contents, id)
+	return strconv.ParseInt(id, 10, 64)
+}
 
-	return err
+// EditTask sets the contents of a task
+func EditTask(db *sql.DB, a Actor, task string, contents string) error {
+	return updateItem(
+		db, a, "tasks", task, OpEdit,
+		`UPDATE tasks SET contents = ? WHERE id = ?`, contents,
+	)
 }
 
-// DeleteTask deletes a task given its name or id. If `task` starts with a '#',
-// then it refers to the task id: #123 refers to id 123.
-func DeleteTask(db *sql.DB, task string) error {
-	field, id := getIdFieldAndValue(task)
-	deleteQuery := fmt.Sprintf(`DELETE FROM tasks WHERE %s = ?`, field)
-	stmt, err := db.Prepare(deleteQuery)
+// RenameTask renames a task given its name or id. It fails if there's
+// already a task called `name`.
+func RenameTask(db *sql.DB, a Actor, task string, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	id, err := lookupId(db, "tasks", task)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(id)
+	taken, err := nameTaken(db, "tasks", name, id, "")
+	if err != nil {
+		return err
+	}
+	if taken {
+		return &NameTakenError{Type: TaskType, Name: name}
+	}
 
-	return err
+	return updateRow(
+		db, a, "tasks", id, task, OpRename,
+		`UPDATE tasks SET name = ? WHERE id = ?`, name,
+	)
 }
 
-// CompleteTask marks a task as completed by setting its `completed_at` field
-// to the current time.
-func CompleteTask(db *sql.DB, task string, t time.Time) error {
-	field, id := getIdFieldAndValue(task)
-	completeQuery := fmt.Sprintf(`UPDATE tasks SET completed_at = ? WHERE %s = ?`, field)
-	stmt, err := db.Prepare(completeQuery)
-	if err != nil {
+// DeleteTask moves a task to the trash given its name or id. If `task` starts
+// with a '#', then it refers to the task id: #123 refers to id 123.
+func DeleteTask(db *sql.DB, a Actor, task string, t time.Time) error {
+	return updateItem(
+		db, a, "tasks", task, OpDelete,
+		`UPDATE tasks SET deleted_at = ? WHERE id = ?`, t.Format(dateLayout),
+	)
+}
+
+// SetTaskDue sets when a task is due given its name or id, or clears it if
+// `due` is the zero time.
+func SetTaskDue(db *sql.DB, a Actor, task string, due time.Time) error {
+	var value interface{}
+	if !due.IsZero() {
+		value = due.Local().Format(dateLayout)
+	}
+
+	return updateItem(
+		db, a, "tasks", task, OpDue,
+		`UPDATE tasks SET due = ? WHERE id = ?`, value,
+	)
+}
+
+// SetTaskPriority sets the priority of a task given its name or id. Higher
+// numbers come first, and 0 means no priority.
+func SetTaskPriority(db *sql.DB, a Actor, task string, priority int) error {
+	if err := validatePriority(priority); err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(t.Format(dateLayout), id)
-	return err
+	return updateItem(
+		db, a, "tasks", task, OpPriority,
+		`UPDATE tasks SET priority = ? WHERE id = ?`, priority,
+	)
+}
+
+// CompleteTask marks a task as completed by setting its `completed_at` field
+// to the current time.
+func CompleteTask(db *sql.DB, a Actor, task string, t time.Time) error {
+	return updateItem(
+		db, a, "tasks", task, OpDone,
+		`UPDATE tasks SET completed_at = ? WHERE id = ?`, t.Format(dateLayout),
+	)
 }
@@ -24,6 +24,8 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -40,12 +42,61 @@ func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// expectLookup expects the query that resolves a name or id into the ids of
+// the matching rows in `table`.
+func expectLookup(mock sqlmock.Sqlmock, table string, field string, value string, ids ...string) {
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+
+	mock.ExpectQuery(
+		"SELECT id FROM " + table + " WHERE " + field + " = \\? AND deleted_at IS NULL ORDER BY id",
+	).WithArgs(value).WillReturnRows(rows)
+}
+
+// expectNameCheck expects the query that checks whether a name is taken by an
+// item other than the one with id `id`, among the items of the test actor for
+// new items, whose id is empty, or of the owner of the item.
+func expectNameCheck(mock sqlmock.Sqlmock, table string, name string, id string, count int) {
+	owner := testActor.User
+	if id != "" {
+		// The owner is read from the item itself.
+		owner = id
+	}
+
+	mock.ExpectQuery(
+		"SELECT COUNT\\(\\*\\) FROM "+table+" WHERE name = \\? AND deleted_at IS NULL AND id != \\?(.+)AND COALESCE\\(owner,''\\) = ",
+	).WithArgs(name, id, owner).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestTaskMarshalJSON(t *testing.T) {
+	task := &TaskModel{
+		Id:        "1",
+		Name:      "groceries",
+		CreatedAt: time.Date(2020, 10, 11, 9, 12, 45, 0, time.UTC),
+		Due:       time.Date(2020, 10, 12, 18, 0, 0, 0, time.UTC),
+	}
+
+	b, err := json.Marshal(task)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","name":"groceries","contents":"",
+		"created_at":"2020-10-11T09:12:45Z","completed_at":null,
+		"due":"2020-10-12T18:00:00Z","priority":0}`, string(b))
+
+	var decoded TaskModel
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	assert.True(t, decoded.CompletedAt.IsZero())
+	assert.True(t, task.Due.Equal(decoded.Due))
+}
+
 func TestListTasks(t *testing.T) {
 	db, mock := newMockDB(t)
 	defer db.Close()
 
-	query := `SELECT
-		id, name, contents, created_at, COALESCE\(completed_at,''\) FROM tasks
+	query := `SELECT id, name, contents, created_at, COALESCE\(completed_at,''\),
+	COALESCE\(due,''\), priority FROM tasks
+		WHERE deleted_at IS NULL
 		ORDER BY id`
 	rows := sqlmock.NewRows([]string{
 		"id",
@@ -53,13 +104,71 @@ func TestListTasks(t *testing.T) {
 		"contents",
 		"created_at",
 		"completed_at",
-	}).AddRow("1", "test", "test contents", "2020-09-20 15:00", "")
+		"due",
+		"priority",
+	}).AddRow("1", "test", "test contents", "2020-09-20 15:00", "", "2020-09-25 18:00:00", 2)
 
 	mock.ExpectQuery(query).WillReturnRows(rows)
 
 	tasks, err := ListTasks(db)
 	assert.Equal(t, 1, len(tasks))
 	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 9, 25, 18, 0, 0, 0, time.Local), tasks[0].Due)
+	assert.Equal(t, 2, tasks[0].Priority)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountTasks(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	now := time.Date(2020, 10, 12, 18, 0, 0, 0, time.Local)
+	query := `SELECT
+		COUNT\(\*\) - COUNT\(completed_at\), COUNT\(completed_at\),
+		COUNT\(CASE WHEN completed_at IS NULL AND due < \? THEN 1 END\) FROM tasks
+		WHERE deleted_at IS NULL`
+	mock.ExpectQuery(query).WithArgs("2020-10-12 18:00:00").WillReturnRows(
+		sqlmock.NewRows([]string{"pending", "completed", "overdue"}).AddRow(3, 2, 1),
+	)
+
+	pending, completed, overdue, err := CountTasks(db, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pending)
+	assert.Equal(t, 2, completed)
+	assert.Equal(t, 1, overdue)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetTask(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	query := `SELECT id, name, contents, created_at, COALESCE\(completed_at,''\),
+	COALESCE\(due,''\), priority FROM tasks WHERE id = \?`
+	rows := sqlmock.NewRows([]string{
+		"id",
+		"name",
+		"contents",
+		"created_at",
+		"completed_at",
+		"due",
+		"priority",
+	}).AddRow("1", "test", "test contents", "2020-09-20 15:00:00", "", "", 0)
+
+	expectLookup(mock, "tasks", "id", "1", "1")
+	mock.ExpectQuery(query).WithArgs("1").WillReturnRows(rows)
+
+	task, err := GetTask(db, "#1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", task.Name)
+	assert.True(t, task.CompletedAt.IsZero())
+	assert.True(t, task.Due.IsZero())
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -71,13 +180,17 @@ func TestAddTask(t *testing.T) {
 	defer db.Close()
 
 	created := time.Now()
-	query := "INSERT INTO tasks\\(name, contents, created_at\\) VALUES \\(\\?, \\?, \\?\\)"
+	expectNameCheck(mock, "tasks", "test", "", 0)
+	expectChange(mock)
+	query := "INSERT INTO tasks\\(name, contents, created_at, owner\\) VALUES \\(\\?, \\?, \\?, \\?\\)"
 	prep := mock.ExpectPrepare(query)
 	prep.ExpectExec().WithArgs(
-		"test", "test contents", created.Format(dateLayout),
+		"test", "test contents", created.Format(dateLayout), testActor.User,
 	).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRecord(mock, TaskType, "1", 0, false, OpAdd, "test", "test contents")
+	mock.ExpectCommit()
 
-	id, err := AddTask(db, "test", "test contents", created)
+	id, err := AddTask(db, testActor, "test", "test contents", created)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), id)
 
@@ -90,13 +203,18 @@ func TestEditTask(t *testing.T) {
 	db, mock := newMockDB(t)
 	defer db.Close()
 
-	query := "UPDATE tasks SET contents = \\? WHERE name = \\?"
+	expectLookup(mock, "tasks", "name", "test", "1")
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "1", "test", "test contents")
+	query := "UPDATE tasks SET contents = \\? WHERE id = \\?"
 	prep := mock.ExpectPrepare(query)
 	prep.ExpectExec().WithArgs(
-		"new contents", "test",
+		"new contents", "1",
 	).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "1", 1, true, OpEdit, "test", "new contents")
+	mock.ExpectCommit()
 
-	err := EditTask(db, "test", "new contents")
+	err := EditTask(db, testActor, "test", "new contents")
 	assert.NoError(t, err)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -108,11 +226,19 @@ func TestDeleteTask(t *testing.T) {
 	db, mock := newMockDB(t)
 	defer db.Close()
 
-	query := "DELETE FROM tasks WHERE name = \\?"
+	deleted := time.Now()
+	expectLookup(mock, "tasks", "name", "test", "1")
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "1", "test", "test contents")
+	query := "UPDATE tasks SET deleted_at = \\? WHERE id = \\?"
 	prep := mock.ExpectPrepare(query)
-	prep.ExpectExec().WithArgs("test").WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs(
+		deleted.Format(dateLayout), "1",
+	).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "1", 0, true, OpDelete, "test", "test contents")
+	mock.ExpectCommit()
 
-	err := DeleteTask(db, "test")
+	err := DeleteTask(db, testActor, "test", deleted)
 	assert.NoError(t, err)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -125,16 +251,110 @@ func TestCompleteTask(t *testing.T) {
 	defer db.Close()
 
 	completed := time.Now()
-	query := "UPDATE tasks SET completed_at = \\? WHERE name = \\?"
+	expectLookup(mock, "tasks", "name", "test", "1")
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "1", "test", "test contents")
+	query := "UPDATE tasks SET completed_at = \\? WHERE id = \\?"
 	prep := mock.ExpectPrepare(query)
 	prep.ExpectExec().WithArgs(
-		completed.Format(dateLayout), "test",
+		completed.Format(dateLayout), "1",
 	).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "1", 2, true, OpDone, "test", "test contents")
+	mock.ExpectCommit()
 
-	err := CompleteTask(db, "test", completed)
+	err := CompleteTask(db, testActor, "test", completed)
 	assert.NoError(t, err)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestRenameTask(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "tasks", "name", "test", "1")
+	expectNameCheck(mock, "tasks", "renamed", "1", 0)
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "1", "test", "test contents")
+	prep := mock.ExpectPrepare("UPDATE tasks SET name = \\? WHERE id = \\?")
+	prep.ExpectExec().WithArgs("renamed", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "1", 1, true, OpRename, "renamed", "test contents")
+	mock.ExpectCommit()
+
+	err := RenameTask(db, testActor, "test", "renamed")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRenameTaskCollision(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "tasks", "id", "1", "1")
+	expectNameCheck(mock, "tasks", "other", "1", 1)
+
+	err := RenameTask(db, testActor, "#1", "other")
+	assert.Error(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddTaskDuplicate(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectNameCheck(mock, "tasks", "test", "", 1)
+
+	_, err := AddTask(db, testActor, "test", "test contents", time.Now())
+	assert.EqualError(t, err, `there's already a task called "test"`)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteTaskAmbiguous(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "tasks", "name", "test", "1", "3")
+
+	err := DeleteTask(db, testActor, "test", time.Now())
+	var ambiguous *AmbiguousNameError
+	assert.True(t, errors.Is(err, ErrAmbiguous))
+	assert.True(t, errors.As(err, &ambiguous))
+	assert.Equal(t, []string{"1", "3"}, ambiguous.Ids)
+	assert.EqualError(t, err, `"test" matches 2 tasks (#1, #3), use an id instead`)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEditTaskNotFound(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectLookup(mock, "tasks", "id", "1", "1")
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "1", "test", "test contents")
+	prep := mock.ExpectPrepare("UPDATE tasks SET contents = \\? WHERE id = \\?")
+	prep.ExpectExec().WithArgs(
+		"new contents", "1",
+	).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := EditTask(db, testActor, "#1", "new contents")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,219 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const templateType = "template"
+
+// TemplateModel struct representation of a row in `templates` table
+type TemplateModel struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Contents  string    `json:"contents"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// String returns a printable representation of a Template
+func (t *TemplateModel) String() string {
+	var createdAtStr string
+	if (t.CreatedAt == time.Time{}) {
+		createdAtStr = ""
+	} else {
+		createdAtStr = fmt.Sprintf(
+			" | created_at: %s",
+			t.CreatedAt.Format(displayLayout),
+		)
+	}
+
+	var contentsStr string
+	if t.Contents != "" {
+		contentsStr = fmt.Sprintf("\n  Contents:\n%s", t.Contents)
+	}
+
+	return fmt.Sprintf(
+		"- id: %s | name: %s%s%s\n",
+		t.Id,
+		t.Name,
+		createdAtStr,
+		contentsStr,
+	)
+}
+
+// ListTemplates returns all the templates ordered by `id`, without their
+// contents.
+func ListTemplates(db *sql.DB) ([]*TemplateModel, error) {
+	rows, err := db.Query(`SELECT
+		id, name, created_at FROM templates
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*TemplateModel
+	for rows.Next() {
+		var createdAt string
+		t := &TemplateModel{}
+		err = rows.Scan(&t.Id, &t.Name, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		cr, _ := time.Parse(dateLayout, createdAt)
+		t.CreatedAt = cr
+
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+// GetTemplate returns a template given its name or id. If `template` starts
+// with a '#', then it refers to the template id.
+func GetTemplate(db *sql.DB, template string) (*TemplateModel, error) {
+	field, id, err := getIdFieldAndValue(template)
+	if err != nil {
+		return nil, err
+	}
+
+	getQuery := fmt.Sprintf(
+		`SELECT id, name, contents, created_at FROM templates WHERE %s = ?`,
+		field,
+	)
+
+	var createdAt string
+	t := new(TemplateModel)
+	err = db.QueryRow(getQuery, id).Scan(&t.Id, &t.Name, &t.Contents, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Type: templateType, Ref: template}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cr, _ := time.Parse(dateLayout, createdAt)
+	t.CreatedAt = cr
+
+	return t, nil
+}
+
+// AddTemplate adds a new template given a name, its contents and creation
+// time. Template names are unique.
+func AddTemplate(db *sql.DB, a Actor, name string, contents string, t time.Time) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return -1, err
+	}
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM templates WHERE name = ?`, name).Scan(&count)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+	if count > 0 {
+		tx.Rollback()
+		return -1, &NameTakenError{Type: templateType, Name: name}
+	}
+
+	insertQuery := `INSERT INTO templates(name, contents, created_at) VALUES (?, ?, ?)`
+	stmt, err := tx.Prepare(insertQuery)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	res, err := stmt.Exec(name, contents, t.Format(dateLayout))
+	if uniqueViolation(err) {
+		tx.Rollback()
+		return -1, &NameTakenError{Type: templateType, Name: name}
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	tmpl := &TemplateModel{
+		Id:        strconv.FormatInt(id, 10),
+		Name:      name,
+		Contents:  contents,
+		CreatedAt: t,
+	}
+	if err := audit(tx, a, templateType, tmpl.Id, OpAdd, nil, tmpl, t); err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	return id, tx.Commit()
+}
+
+// DeleteTemplate deletes a template given its name or id. If `template`
+// starts with a '#', then it refers to the template id.
+func DeleteTemplate(db *sql.DB, a Actor, template string) error {
+	tmpl, err := GetTemplate(db, template)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`DELETE FROM templates WHERE id = ?`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res, err := stmt.Exec(tmpl.Id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := checkAffected(res, "templates", template); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit(tx, a, templateType, tmpl.Id, OpDelete, tmpl, nil, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
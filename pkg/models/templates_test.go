@@ -0,0 +1,99 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTemplate(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	created := time.Now()
+	query := "INSERT INTO templates\\(name, contents, created_at\\) VALUES \\(\\?, \\?, \\?\\)"
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM templates WHERE name = \\?").
+		WithArgs("meeting").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	prep := mock.ExpectPrepare(query)
+	prep.ExpectExec().WithArgs(
+		"meeting", "# {{name}}", created.Format(dateLayout),
+	).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(mock, "template", "1", OpAdd)
+	mock.ExpectCommit()
+
+	id, err := AddTemplate(db, testActor, "meeting", "# {{name}}", created)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddTemplateNameTaken(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM templates WHERE name = \\?").
+		WithArgs("meeting").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err := AddTemplate(db, testActor, "meeting", "# {{name}}", time.Now())
+	assert.True(t, errors.Is(err, ErrNameTaken))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	query := "SELECT id, name, contents, created_at FROM templates WHERE name = \\?"
+	rows := sqlmock.NewRows([]string{
+		"id",
+		"name",
+		"contents",
+		"created_at",
+	}).AddRow("1", "meeting", "# {{name}}", "2020-05-14 09:30:00")
+
+	mock.ExpectQuery(query).WithArgs("meeting").WillReturnRows(rows)
+
+	tmpl, err := GetTemplate(db, "meeting")
+	assert.NoError(t, err)
+	assert.Equal(t, "# {{name}}", tmpl.Contents)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
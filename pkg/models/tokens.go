@@ -0,0 +1,172 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const tokenType = "token"
+
+// tokenPrefix makes the API tokens easy to recognize, e.g. in leaked
+// configuration files.
+const tokenPrefix = "clerk_"
+
+// TokenModel struct representation of a row in `tokens` table. The token
+// itself isn't stored, only its hash.
+type TokenModel struct {
+	Id        string    `json:"id"`
+	User      string    `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+// String returns a printable representation of a Token
+func (t *TokenModel) String() string {
+	var revokedAtStr string
+	if !t.RevokedAt.IsZero() {
+		revokedAtStr = fmt.Sprintf(
+			" | revoked_at: %s",
+			t.RevokedAt.Format(displayLayout),
+		)
+	}
+
+	return fmt.Sprintf(
+		"- id: %s | user: %s | created_at: %s%s",
+		t.Id,
+		t.User,
+		t.CreatedAt.Format(displayLayout),
+		revokedAtStr,
+	)
+}
+
+// hashToken returns the hash of `token` stored in the database. Tokens are
+// random, so a fast hash is enough.
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(h[:])
+}
+
+// CreateToken creates an API token for `user` and returns it. It can't be
+// retrieved afterwards.
+func CreateToken(db *sql.DB, user string, t time.Time) (string, error) {
+	if strings.TrimSpace(user) == "" {
+		return "", fmt.Errorf("the user can't be empty")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + hex.EncodeToString(b)
+
+	_, err := db.Exec(
+		`INSERT INTO tokens(user, hash, created_at) VALUES (?, ?, ?)`,
+		user, hashToken(token), t.Format(dateLayout),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ListTokens returns all the API tokens ordered by `id`, including the
+// revoked ones.
+func ListTokens(db *sql.DB) ([]*TokenModel, error) {
+	rows, err := db.Query(`SELECT
+		id, user, created_at, COALESCE(revoked_at,'') FROM tokens
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*TokenModel
+	for rows.Next() {
+		var createdAt, revokedAt string
+		t := &TokenModel{}
+		err = rows.Scan(&t.Id, &t.User, &createdAt, &revokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		t.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+		t.RevokedAt, _ = time.Parse(dateLayout, revokedAt)
+
+		res = append(res, t)
+	}
+
+	return res, rows.Err()
+}
+
+// RevokeToken revokes an API token given its id, with or without the '#'
+// prefix. Revoked tokens are kept, so that they can still be listed.
+func RevokeToken(db *sql.DB, id string, t time.Time) error {
+	ref := "#" + strings.TrimPrefix(id, "#")
+	_, id, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.Exec(
+		`UPDATE tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		t.Format(dateLayout), id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Type: tokenType, Ref: ref}
+	}
+
+	return nil
+}
+
+// Authenticate returns the user of an API token. It fails with
+// ErrUnauthorized if the token is unknown or has been revoked.
+func Authenticate(db *sql.DB, token string) (string, error) {
+	var user string
+	err := db.QueryRow(
+		`SELECT user FROM tokens WHERE hash = ? AND revoked_at IS NULL`,
+		hashToken(token),
+	).Scan(&user)
+	if err == sql.ErrNoRows {
+		return "", ErrUnauthorized
+	}
+
+	return user, err
+}
@@ -0,0 +1,126 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateToken(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	created := time.Now()
+	mock.ExpectExec("INSERT INTO tokens\\(user, hash, created_at\\) VALUES \\(\\?, \\?, \\?\\)").
+		WithArgs("alice", sqlmock.AnyArg(), created.Format(dateLayout)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	token, err := CreateToken(db, "alice", created)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, tokenPrefix))
+	assert.Equal(t, len(tokenPrefix)+64, len(token))
+
+	_, err = CreateToken(db, " ", created)
+	assert.EqualError(t, err, "the user can't be empty")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	query := "SELECT user FROM tokens WHERE hash = \\? AND revoked_at IS NULL"
+	mock.ExpectQuery(query).
+		WithArgs(hashToken("clerk_valid")).
+		WillReturnRows(sqlmock.NewRows([]string{"user"}).AddRow("alice"))
+	mock.ExpectQuery(query).
+		WithArgs(hashToken("clerk_revoked")).
+		WillReturnRows(sqlmock.NewRows([]string{"user"}))
+
+	user, err := Authenticate(db, "clerk_valid")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", user)
+
+	_, err = Authenticate(db, "clerk_revoked")
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListTokens(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT(.+)FROM tokens(.+)ORDER BY id").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user", "created_at", "revoked_at",
+		}).
+			AddRow("1", "alice", "2020-09-20 15:00:00", "2020-09-21 15:00:00").
+			AddRow("2", "alice", "2020-09-21 15:00:00", ""))
+
+	tokens, err := ListTokens(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tokens))
+	assert.False(t, tokens[0].RevokedAt.IsZero())
+	assert.True(t, tokens[1].RevokedAt.IsZero())
+	assert.Equal(t, "- id: 2 | user: alice | created_at: 2020-09-21 15:00:00", tokens[1].String())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	revoked := time.Now()
+	query := "UPDATE tokens SET revoked_at = \\? WHERE id = \\? AND revoked_at IS NULL"
+	mock.ExpectExec(query).
+		WithArgs(revoked.Format(dateLayout), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).
+		WithArgs(revoked.Format(dateLayout), "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, RevokeToken(db, "#1", revoked))
+
+	err := RevokeToken(db, "1", revoked)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "token #1 not found")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
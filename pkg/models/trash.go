@@ -0,0 +1,221 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TrashItemModel represents a task or a note in the trash.
+type TrashItemModel struct {
+	Type      string    `json:"type"`
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// String returns a printable representation of an item in the trash
+func (t *TrashItemModel) String() string {
+	return fmt.Sprintf(
+		"- %s | id: %s | name: %s | deleted_at: %s",
+		t.Type,
+		t.Id,
+		t.Name,
+		t.DeletedAt.Format(displayLayout),
+	)
+}
+
+// ListTrash returns the tasks and notes in the trash, the most recently
+// deleted first.
+func ListTrash(db *sql.DB) ([]*TrashItemModel, error) {
+	rows, err := db.Query(`SELECT
+		'task', id, name, deleted_at FROM tasks WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'note', id, name, deleted_at FROM notes WHERE deleted_at IS NOT NULL
+		ORDER BY 4 DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*TrashItemModel
+	for rows.Next() {
+		var deletedAt string
+		t := &TrashItemModel{}
+		err = rows.Scan(&t.Type, &t.Id, &t.Name, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		de, _ := time.Parse(dateLayout, deletedAt)
+		t.DeletedAt = de
+
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+// RestoreItem takes a task or a note out of the trash given its type and
+// id, with or without the '#' prefix. It fails if another item with the same
+// name has been created in the meantime.
+func RestoreItem(db *sql.DB, a Actor, entityType string, id string) error {
+	table, ok := tables[entityType]
+	if !ok {
+		return fmt.Errorf("unknown type %q", entityType)
+	}
+
+	ref := "#" + strings.TrimPrefix(id, "#")
+	_, id, err := getIdFieldAndValue(ref)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	err = db.QueryRow(
+		fmt.Sprintf(`SELECT name FROM %s WHERE id = ? AND deleted_at IS NOT NULL`, table),
+		id,
+	).Scan(&name)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Type: entityType, Ref: ref + " in the trash"}
+	}
+	if err != nil {
+		return err
+	}
+
+	taken, err := nameTaken(db, table, name, id, "")
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf(
+			"%w, rename it before restoring %s",
+			&NameTakenError{Type: entityType, Name: name},
+			ref,
+		)
+	}
+
+	_, err = mutate(db, a, entityType, id, OpRestore, func(tx *change) (string, error) {
+		stmt, err := tx.Prepare(
+			fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE id = ?`, table),
+		)
+		if err != nil {
+			return "", err
+		}
+
+		res, err := stmt.Exec(id)
+		if err != nil {
+			return "", err
+		}
+
+		return id, checkAffected(res, table, ref)
+	})
+
+	return err
+}
+
+// EmptyTrash permanently deletes the tasks and notes that were moved to the
+// trash before `t`, or all of them if `t` is the zero time. It returns the
+// number of deleted items.
+func EmptyTrash(db *sql.DB, a Actor, t time.Time) (int64, error) {
+	return emptyTrash(db, a, "", t)
+}
+
+// EmptyTrashOf is like EmptyTrash, but only deletes the items owned by the
+// user of `a`.
+func EmptyTrashOf(db *sql.DB, a Actor, t time.Time) (int64, error) {
+	if a.User == "" {
+		return 0, fmt.Errorf("the owner can't be empty")
+	}
+
+	return emptyTrash(db, a, a.User, t)
+}
+
+// emptyTrash permanently deletes the items in the trash, only the ones owned
+// by `owner` unless it's empty.
+func emptyTrash(db *sql.DB, a Actor, owner string, t time.Time) (int64, error) {
+	condition, args := "deleted_at IS NOT NULL", []interface{}{}
+	if !t.IsZero() {
+		condition += " AND deleted_at < ?"
+		args = append(args, t.Format(dateLayout))
+	}
+	if owner != "" {
+		condition += " AND owner = ?"
+		args = append(args, owner)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// The items are journaled, so that undoing older changes to them brings
+	// them back to the trash first.
+	now := time.Now()
+	b := newBatch(now)
+	if err := clearRedo(tx, a); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var count int64
+	for _, entityType := range []string{TaskType, NoteType} {
+		table := tables[entityType]
+		ids, err := queryIds(tx, fmt.Sprintf(`SELECT id FROM %s WHERE %s`, table, condition), args...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		for _, id := range ids {
+			before, err := takeSnapshot(tx, entityType, id)
+			if err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+
+			if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, table), id); err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+
+			if err := journal(tx, a, b, entityType, id, OpPurge, before, nil, now); err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+
+			if err := audit(tx, a, entityType, id, OpPurge, before, nil, now); err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+		}
+
+		count += int64(len(ids))
+	}
+
+	return count, tx.Commit()
+}
@@ -0,0 +1,146 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListTrash(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{
+		"type",
+		"id",
+		"name",
+		"deleted_at",
+	}).AddRow("note", "2", "test", "2020-10-11 19:28:00")
+
+	mock.ExpectQuery("SELECT(.+)FROM tasks WHERE deleted_at IS NOT NULL").
+		WillReturnRows(rows)
+
+	items, err := ListTrash(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "note", items[0].Type)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreItem(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT name FROM tasks WHERE id = \\? AND deleted_at IS NOT NULL").
+		WithArgs("2").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("test"))
+	expectNameCheck(mock, "tasks", "test", "2", 0)
+	expectChange(mock)
+	expectSnapshot(mock, TaskType, "2", "test", "test contents")
+	prep := mock.ExpectPrepare("UPDATE tasks SET deleted_at = NULL WHERE id = \\?")
+	prep.ExpectExec().WithArgs("2").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRecord(mock, TaskType, "2", 2, true, OpRestore, "test", "test contents")
+	mock.ExpectCommit()
+
+	err := RestoreItem(db, testActor, "task", "2")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	before := time.Now()
+	expectChange(mock)
+	mock.ExpectQuery("SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < \\?").
+		WithArgs(before.Format(dateLayout)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+	for _, id := range []string{"1", "2"} {
+		expectSnapshot(mock, TaskType, id, "test", "test contents")
+		mock.ExpectExec("DELETE FROM tasks WHERE id = \\?").
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectJournal(mock, TaskType, id, OpPurge)
+		expectAudit(mock, TaskType, id, OpPurge)
+	}
+	mock.ExpectQuery("SELECT id FROM notes WHERE deleted_at IS NOT NULL AND deleted_at < \\?").
+		WithArgs(before.Format(dateLayout)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	expectSnapshot(mock, NoteType, "1", "test", "contents")
+	mock.ExpectExec("DELETE FROM notes WHERE id = \\?").
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectJournal(mock, NoteType, "1", OpPurge)
+	expectAudit(mock, NoteType, "1", OpPurge)
+	mock.ExpectCommit()
+
+	n, err := EmptyTrash(db, testActor, before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEmptyTrashOf(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	expectChange(mock)
+	mock.ExpectQuery("SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND owner = \\?").
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("2"))
+	expectSnapshot(mock, TaskType, "2", "test", "test contents")
+	mock.ExpectExec("DELETE FROM tasks WHERE id = \\?").
+		WithArgs("2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectJournal(mock, TaskType, "2", OpPurge)
+	expectAudit(mock, TaskType, "2", OpPurge)
+	mock.ExpectQuery("SELECT id FROM notes WHERE deleted_at IS NOT NULL AND owner = \\?").
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	n, err := EmptyTrashOf(db, testActor, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = EmptyTrashOf(db, Actor{}, time.Time{})
+	assert.Error(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
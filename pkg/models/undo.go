@@ -0,0 +1,401 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OperationModel struct representation of a row in `operations` table
+type OperationModel struct {
+	Id        string    `json:"id"`
+	Batch     int64     `json:"batch"`
+	Type      string    `json:"type"`
+	EntityId  string    `json:"entity_id"`
+	Operation string    `json:"operation"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+
+	before *snapshot
+	after  *snapshot
+}
+
+// String returns a printable representation of an Operation
+func (o *OperationModel) String() string {
+	return fmt.Sprintf(
+		"- %s %s #%s (%s) | created_at: %s\n",
+		o.Operation,
+		o.Type,
+		o.EntityId,
+		o.Name,
+		o.CreatedAt.Format(displayLayout),
+	)
+}
+
+// journal appends an operation to the journal of operations, along with the
+// states of the item before and after it. `before` is nil for new items.
+func journal(q querier, a Actor, batch int64, entityType string, id string, op string, before *snapshot, after *snapshot, t time.Time) error {
+	beforeJSON, err := toJSON(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := toJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`INSERT INTO operations
+		(batch, entity_type, entity_id, operation, before, after, created_at, user)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		batch, entityType, id, op, beforeJSON, afterJSON, t.Format(dateLayout), a.User,
+	)
+
+	return err
+}
+
+// batch is the batch of the changes made since StartBatch, or 0 if every
+// change is a batch of its own.
+var batch int64
+
+// StartBatch groups the changes made from now on into a single batch, which
+// is undone and redone at once, until the function it returns is called.
+func StartBatch() (end func()) {
+	batch = time.Now().UnixNano()
+
+	return func() { batch = 0 }
+}
+
+// newBatch returns the batch of a change made at `t`.
+func newBatch(t time.Time) int64 {
+	if batch != 0 {
+		return batch
+	}
+
+	return t.UnixNano()
+}
+
+// clearRedo drops the operations that the user of `a` has undone, which
+// can't be redone once they change something else.
+func clearRedo(q querier, a Actor) error {
+	_, err := q.Exec(
+		`DELETE FROM operations WHERE undone = 1 AND `+ownOperations,
+		a.User,
+	)
+
+	return err
+}
+
+// ownOperations selects the operations of a user, given as an
+// argument, which are the only ones they can undo and redo. Operations
+// recorded before users were introduced are given to the user who upgrades
+// the database when it's migrated.
+const ownOperations = `user = ?`
+
+// Undo reverses the last `n` changes of the user of `a`, most recent first,
+// in a single transaction. It returns the operations that were reversed.
+func Undo(db *sql.DB, a Actor, n int, t time.Time) ([]*OperationModel, error) {
+	return replay(db, a, n, true, t)
+}
+
+// Redo applies again the last `n` changes that were undone, in the order in
+// which they were originally made. It returns the operations that were
+// applied.
+func Redo(db *sql.DB, a Actor, n int, t time.Time) ([]*OperationModel, error) {
+	return replay(db, a, n, false, t)
+}
+
+// replay undoes or redoes the last `n` changes, setting the items they
+// changed to their state before or after each operation.
+func replay(db *sql.DB, a Actor, n int, undo bool, t time.Time) ([]*OperationModel, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCount, n)
+	}
+
+	batchesQuery := `SELECT batch FROM operations WHERE undone = 0 AND ` + ownOperations + `
+		GROUP BY batch ORDER BY MAX(id) DESC LIMIT ?`
+	opsQuery := `SELECT ` + operationColumns + ` FROM operations
+		WHERE batch = ? ORDER BY id DESC`
+	op, undone, nothing := OpUndo, 1, ErrNothingToUndo
+	if !undo {
+		batchesQuery = `SELECT batch FROM operations WHERE undone = 1 AND ` + ownOperations + `
+			GROUP BY batch ORDER BY MIN(id) LIMIT ?`
+		opsQuery = `SELECT ` + operationColumns + ` FROM operations
+			WHERE batch = ? ORDER BY id`
+		op, undone, nothing = OpRedo, 0, ErrNothingToRedo
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	batches, err := queryBatches(tx, batchesQuery, a.User, n)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(batches) == 0 {
+		tx.Rollback()
+		return nil, nothing
+	}
+
+	var res []*OperationModel
+	for _, batch := range batches {
+		ops, err := queryOperations(tx, opsQuery, batch)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		for _, o := range ops {
+			s, expected := o.after, o.before
+			if undo {
+				s, expected = o.before, o.after
+			}
+
+			current, err := takeSnapshot(tx, o.Type, o.EntityId)
+			if err == sql.ErrNoRows {
+				current, err = nil, nil
+			}
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			// Later changes that weren't undone would be lost.
+			if !current.matches(expected) {
+				tx.Rollback()
+				return nil, &ConflictError{Type: o.Type, Id: o.EntityId, Op: op}
+			}
+
+			if err := applySnapshot(tx, o.Type, o.EntityId, s); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			if err := audit(tx, a, o.Type, o.EntityId, op, current, s, t); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			// Items whose creation was undone no longer exist.
+			if s != nil {
+				if _, err := revise(tx, o.Type, o.EntityId, op, nil, t); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE operations SET undone = ? WHERE batch = ?`, undone, batch)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		res = append(res, ops...)
+	}
+
+	return res, tx.Commit()
+}
+
+func queryBatches(q querier, query string, args ...interface{}) ([]int64, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []int64
+	for rows.Next() {
+		var batch int64
+		if err := rows.Scan(&batch); err != nil {
+			return nil, err
+		}
+
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+const operationColumns = `id, batch, entity_type, entity_id, operation,
+	COALESCE(before,''), COALESCE(after,''), created_at`
+
+func queryOperations(q querier, query string, args ...interface{}) ([]*OperationModel, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*OperationModel
+	for rows.Next() {
+		var before, after, createdAt string
+		o := new(OperationModel)
+		err := rows.Scan(
+			&o.Id, &o.Batch, &o.Type, &o.EntityId, &o.Operation,
+			&before, &after, &createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.before, err = parseSnapshot(before); err != nil {
+			return nil, err
+		}
+		if o.after, err = parseSnapshot(after); err != nil {
+			return nil, err
+		}
+
+		if o.after != nil {
+			o.Name = o.after.Name
+		} else if o.before != nil {
+			o.Name = o.before.Name
+		}
+		o.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+
+		res = append(res, o)
+	}
+
+	return res, rows.Err()
+}
+
+// parseSnapshot parses a snapshot stored in the journal of operations. An
+// empty string means that the item didn't exist.
+func parseSnapshot(s string) (*snapshot, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	res := new(snapshot)
+
+	return res, json.Unmarshal([]byte(s), res)
+}
+
+// matches reports whether two states of an item are the same. Owners aren't
+// compared, since operations recorded before owners were introduced don't
+// have them.
+func (s *snapshot) matches(o *snapshot) bool {
+	if s == nil || o == nil {
+		return s == o
+	}
+
+	if len(s.Contents) != len(o.Contents) {
+		return false
+	}
+	for i := range s.Contents {
+		if s.Contents[i] != o.Contents[i] {
+			return false
+		}
+	}
+
+	return s.Name == o.Name &&
+		s.CreatedAt == o.CreatedAt &&
+		s.CompletedAt == o.CompletedAt &&
+		s.DeletedAt == o.DeletedAt &&
+		s.Due == o.Due &&
+		s.Priority == o.Priority
+}
+
+// applySnapshot sets a task or a note to the state `s`. If `s` is nil, the
+// item is permanently deleted. Items that no longer exist are created again
+// with the same id.
+func applySnapshot(q querier, entityType string, id string, s *snapshot) error {
+	table := tables[entityType]
+
+	if s == nil {
+		_, err := q.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, table), id)
+		return err
+	}
+
+	var res sql.Result
+	var err error
+	if entityType == TaskType {
+		res, err = q.Exec(`UPDATE tasks SET
+			name = ?, contents = ?, created_at = ?,
+			completed_at = NULLIF(?, ''), deleted_at = NULLIF(?, ''),
+			due = NULLIF(?, ''), priority = ?
+			WHERE id = ?`,
+			s.Name, strings.Join(s.Contents, "\n"), s.CreatedAt,
+			s.CompletedAt, s.DeletedAt, s.Due, s.Priority, id,
+		)
+	} else {
+		res, err = q.Exec(`UPDATE notes SET
+			name = ?, created_at = ?, deleted_at = NULLIF(?, '')
+			WHERE id = ?`,
+			s.Name, s.CreatedAt, s.DeletedAt, id,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		if entityType == TaskType {
+			_, err = q.Exec(`INSERT INTO tasks
+				(id, name, contents, created_at, completed_at, deleted_at, owner, due, priority)
+				VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)`,
+				id, s.Name, strings.Join(s.Contents, "\n"), s.CreatedAt,
+				s.CompletedAt, s.DeletedAt, s.Owner, s.Due, s.Priority,
+			)
+		} else {
+			_, err = q.Exec(`INSERT INTO notes
+				(id, name, created_at, deleted_at, owner)
+				VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))`,
+				id, s.Name, s.CreatedAt, s.DeletedAt, s.Owner,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if entityType == TaskType {
+		return nil
+	}
+
+	_, err = q.Exec(`DELETE FROM notes_contents WHERE note_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range s.Contents {
+		_, err = q.Exec(`INSERT INTO notes_contents (note_id, contents) VALUES (?, ?)`, id, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
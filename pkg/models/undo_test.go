@@ -0,0 +1,233 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectChange expects a change to start, which drops the operations that
+// could be redone.
+func expectChange(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM operations WHERE undone = 1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectRecord expects an item's change to be recorded in its history and in
+// the journal of operations and the audit log. See expectRevise.
+func expectRecord(mock sqlmock.Sqlmock, entityType string, id string, lastRev int, initial bool, op string, name string, contents ...string) {
+	expectRevise(mock, entityType, id, lastRev, initial, op, name, contents...)
+	expectJournal(mock, entityType, id, op)
+	expectAudit(mock, entityType, id, op)
+}
+
+// expectJournal expects an operation to be appended to the journal.
+func expectJournal(mock sqlmock.Sqlmock, entityType string, id string, op string) {
+	mock.ExpectExec("INSERT INTO operations").
+		WithArgs(
+			sqlmock.AnyArg(), entityType, id, op,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+var operationRows = []string{
+	"id", "batch", "entity_type", "entity_id", "operation", "before", "after", "created_at",
+}
+
+func TestUndo(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch FROM operations WHERE undone = 0(.+)ORDER BY MAX\\(id\\) DESC").
+		WithArgs(testActor.User, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"batch"}).AddRow(20).AddRow(10))
+
+	mock.ExpectQuery("SELECT(.+)FROM operations WHERE batch = \\? ORDER BY id DESC").
+		WithArgs(20).
+		WillReturnRows(sqlmock.NewRows(operationRows).AddRow(
+			"2", 20, "task", "1", "edit",
+			`{"name":"test","contents":["old"],"created_at":"2020-09-20 15:00:00"}`,
+			`{"name":"test","contents":["new"],"created_at":"2020-09-20 15:00:00"}`,
+			"2020-09-20 15:01:00",
+		))
+	expectSnapshot(mock, TaskType, "1", "test", "new")
+	mock.ExpectExec("UPDATE tasks SET(.+)WHERE id = \\?").
+		WithArgs("test", "old", "2020-09-20 15:00:00", "", "", "", 0, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, TaskType, "1", OpUndo)
+	expectRevise(mock, TaskType, "1", 2, false, OpUndo, "test", "old")
+	mock.ExpectExec("UPDATE operations SET undone = \\? WHERE batch = \\?").
+		WithArgs(1, 20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectQuery("SELECT(.+)FROM operations WHERE batch = \\? ORDER BY id DESC").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(operationRows).AddRow(
+			"1", 10, "task", "1", "add", "",
+			`{"name":"test","contents":["old"],"created_at":"2020-09-20 15:00:00"}`,
+			"2020-09-20 15:00:00",
+		))
+	expectSnapshot(mock, TaskType, "1", "test", "old")
+	mock.ExpectExec("DELETE FROM tasks WHERE id = \\?").
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, TaskType, "1", OpUndo)
+	mock.ExpectExec("UPDATE operations SET undone = \\? WHERE batch = \\?").
+		WithArgs(1, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ops, err := Undo(db, testActor, 2, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ops))
+	assert.Equal(t, "edit", ops[0].Operation)
+	assert.Equal(t, "add", ops[1].Operation)
+	assert.Equal(t, "test", ops[1].Name)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRedo(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch FROM operations WHERE undone = 1(.+)ORDER BY MIN\\(id\\)").
+		WithArgs(testActor.User, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"batch"}).AddRow(10))
+	mock.ExpectQuery("SELECT(.+)FROM operations WHERE batch = \\? ORDER BY id").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(operationRows).AddRow(
+			"1", 10, "note", "3", "add", "",
+			`{"name":"test","contents":["one","two"],"created_at":"2020-09-20 15:00:00","owner":"alice"}`,
+			"2020-09-20 15:00:00",
+		))
+	mock.ExpectQuery("SELECT(.+)FROM notes WHERE id = \\?").
+		WithArgs("3").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("UPDATE notes SET(.+)WHERE id = \\?").
+		WithArgs("test", "2020-09-20 15:00:00", "", "3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO notes").
+		WithArgs("3", "test", "2020-09-20 15:00:00", "", "alice").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("DELETE FROM notes_contents WHERE note_id = \\?").
+		WithArgs("3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, c := range []string{"one", "two"} {
+		mock.ExpectExec("INSERT INTO notes_contents").
+			WithArgs("3", c).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	expectAudit(mock, NoteType, "3", OpRedo)
+	expectRevise(mock, NoteType, "3", 2, false, OpRedo, "test", "one", "two")
+	mock.ExpectExec("UPDATE operations SET undone = \\? WHERE batch = \\?").
+		WithArgs(0, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ops, err := Redo(db, testActor, 1, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ops))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRedoNothing(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT batch FROM operations WHERE undone = 1").
+		WithArgs(testActor.User, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"batch"}))
+	mock.ExpectRollback()
+
+	_, err := Redo(db, testActor, 1, time.Now())
+	assert.True(t, errors.Is(err, ErrNothingToRedo))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUndoBatch(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	AddTask(db, testActor, "groceries", "", now)
+	AddTask(db, testActor, "laundry", "", now)
+
+	end := StartBatch()
+	assert.NoError(t, DeleteTask(db, testActor, "#1", now))
+	assert.NoError(t, DeleteTask(db, testActor, "#2", now))
+	end()
+
+	ops, err := Undo(db, testActor, 1, now)
+	assert.NoError(t, err)
+	assert.Len(t, ops, 2)
+
+	tasks, err := ListTasks(db)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
+
+func TestUndoPurge(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+
+	AddTask(db, testActor, "groceries", "buy milk", now)
+	assert.NoError(t, DeleteTask(db, testActor, "groceries", now))
+	_, err := EmptyTrash(db, testActor, time.Time{})
+	assert.NoError(t, err)
+
+	// Undoing the purge brings the task back to the trash, and then undoing
+	// the deletion restores it.
+	ops, err := Undo(db, testActor, 1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, OpPurge, ops[0].Operation)
+
+	trash, err := ListTrash(db)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+
+	_, err = Undo(db, testActor, 1, now)
+	assert.NoError(t, err)
+
+	task, err := GetTask(db, "groceries")
+	assert.NoError(t, err)
+	assert.Equal(t, "buy milk", task.Contents)
+}
@@ -0,0 +1,562 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const webhookType = "webhook"
+
+// secretPrefix makes the secrets of the webhooks easy to recognize.
+const secretPrefix = "whsec_"
+
+// Statuses of the deliveries of webhooks.
+const (
+	DeliveryPending   = "pending"
+	DeliverySending   = "sending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	// webhookMaxAttempts is how many times a delivery is attempted before
+	// it's given up.
+	webhookMaxAttempts = 6
+
+	// webhookBackoff is how long to wait before attempting a delivery
+	// again after the first failure. It doubles after each failure.
+	webhookBackoff = 30 * time.Second
+
+	// webhookLease is how long a delivery being sent is left to whoever
+	// claimed it, before it's due again in case they stopped.
+	webhookLease = time.Minute
+)
+
+// WebhookModel struct representation of a row in `webhooks` table. The
+// secret that signs the deliveries is only returned when the webhook is
+// added.
+type WebhookModel struct {
+	Id        string    `json:"id"`
+	Event     string    `json:"event"`
+	URL       string    `json:"url"`
+	Owner     string    `json:"owner"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// String returns a printable representation of a Webhook
+func (w *WebhookModel) String() string {
+	var secretStr string
+	if w.Secret != "" {
+		secretStr = fmt.Sprintf("\n  Secret: %s", w.Secret)
+	}
+
+	return fmt.Sprintf(
+		"- id: %s | event: %s | url: %s | created_at: %s%s\n",
+		w.Id,
+		w.Event,
+		w.URL,
+		w.CreatedAt.Format(displayLayout),
+		secretStr,
+	)
+}
+
+// DeliveryModel struct representation of a row in `webhook_deliveries`
+// table, which logs the deliveries of an event to a webhook.
+type DeliveryModel struct {
+	Id            string    `json:"id"`
+	WebhookId     string    `json:"webhook_id"`
+	EventId       string    `json:"event_id"`
+	Event         string    `json:"event"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	ResponseCode  int       `json:"response_code"`
+	Error         string    `json:"error"`
+	CreatedAt     time.Time `json:"created_at"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+}
+
+// String returns a printable representation of a Delivery
+func (d *DeliveryModel) String() string {
+	var details []string
+	if d.ResponseCode != 0 {
+		details = append(details, fmt.Sprintf("response: %d", d.ResponseCode))
+	}
+	if d.Error != "" {
+		details = append(details, "error: "+d.Error)
+	}
+	if d.Status == DeliveryPending && !d.NextAttemptAt.IsZero() {
+		details = append(details, "next_attempt_at: "+d.NextAttemptAt.Format(displayLayout))
+	}
+	if !d.DeliveredAt.IsZero() {
+		details = append(details, "delivered_at: "+d.DeliveredAt.Format(displayLayout))
+	}
+
+	var detailsStr string
+	if len(details) > 0 {
+		detailsStr = " | " + strings.Join(details, " | ")
+	}
+
+	return fmt.Sprintf(
+		"- id: %s | event: %s #%s | status: %s | attempts: %d%s\n",
+		d.Id,
+		d.Event,
+		d.EventId,
+		d.Status,
+		d.Attempts,
+		detailsStr,
+	)
+}
+
+// PendingDelivery is a delivery that is due, along with what's needed to
+// send it.
+type PendingDelivery struct {
+	DeliveryModel
+	URL     string
+	Secret  string
+	Payload []byte
+}
+
+// validateWebhook checks the event and the URL of a webhook.
+func validateWebhook(event string, u string) error {
+	valid := event == "*"
+	for _, entityType := range []string{TaskType, NoteType} {
+		if event == entityType {
+			valid = true
+		}
+		for _, kind := range []string{EventCreated, EventUpdated, EventDeleted, EventCompleted} {
+			if event == entityType+"."+kind {
+				valid = true
+			}
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: invalid URL %q", ErrInvalidWebhook, u)
+	}
+
+	return nil
+}
+
+// AddWebhook adds a webhook that receives the events called `event` (see
+// EventModel.Is) from now on, signed with `secret`, or with a random secret
+// if it's empty. The webhook belongs to the user of `a`, and only receives
+// the events about the items they can access.
+func AddWebhook(db *sql.DB, a Actor, event string, u string, secret string, t time.Time) (*WebhookModel, error) {
+	if err := validateWebhook(event, u); err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = secretPrefix + hex.EncodeToString(b)
+	}
+
+	latest, err := LatestEventId(db)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO webhooks(event, url, secret, owner, last_event_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		event, u, secret, a.User, latest, t.Format(dateLayout),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookModel{
+		Id:        fmt.Sprint(id),
+		Event:     event,
+		URL:       u,
+		Owner:     a.User,
+		Secret:    secret,
+		CreatedAt: t,
+	}, nil
+}
+
+// ListWebhooks returns all the webhooks ordered by `id`, without their
+// secrets.
+func ListWebhooks(db *sql.DB) ([]*WebhookModel, error) {
+	rows, err := db.Query(`SELECT
+		id, event, url, COALESCE(owner,''), created_at FROM webhooks
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*WebhookModel
+	for rows.Next() {
+		var createdAt string
+		w := &WebhookModel{}
+		if err := rows.Scan(&w.Id, &w.Event, &w.URL, &w.Owner, &createdAt); err != nil {
+			return nil, err
+		}
+
+		w.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+
+		res = append(res, w)
+	}
+
+	return res, rows.Err()
+}
+
+// webhookId returns the id of a webhook given with or without the '#'
+// prefix, as a reference and as a value.
+func webhookId(id string) (string, string, error) {
+	ref := "#" + strings.TrimPrefix(id, "#")
+	_, id, err := getIdFieldAndValue(ref)
+
+	return ref, id, err
+}
+
+// GetWebhook returns a webhook, without its secret, given its id with or
+// without the '#' prefix.
+func GetWebhook(db *sql.DB, id string) (*WebhookModel, error) {
+	ref, id, err := webhookId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdAt string
+	w := &WebhookModel{}
+	err = db.QueryRow(
+		`SELECT id, event, url, COALESCE(owner,''), created_at FROM webhooks WHERE id = ?`,
+		id,
+	).Scan(&w.Id, &w.Event, &w.URL, &w.Owner, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Type: webhookType, Ref: ref}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	w.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+
+	return w, nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries given its id, with or
+// without the '#' prefix.
+func DeleteWebhook(db *sql.DB, id string) error {
+	ref, id, err := webhookId(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, "webhooks", ref)
+}
+
+// ListDeliveries returns the deliveries of a webhook, given its id with or
+// without the '#' prefix, oldest first.
+func ListDeliveries(db *sql.DB, webhook string) ([]*DeliveryModel, error) {
+	w, err := GetWebhook(db, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT
+		id, webhook_id, event_id, event, status, attempts, COALESCE(response_code,0),
+		COALESCE(error,''), created_at, COALESCE(next_attempt_at,''), COALESCE(delivered_at,'')
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY id`,
+		w.Id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*DeliveryModel
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, d)
+	}
+
+	return res, rows.Err()
+}
+
+// scanDelivery scans a delivery selected as in ListDeliveries, followed by
+// the columns in `extra`.
+func scanDelivery(rows *sql.Rows, extra ...interface{}) (*DeliveryModel, error) {
+	var createdAt, nextAttemptAt, deliveredAt string
+	d := new(DeliveryModel)
+	err := rows.Scan(append([]interface{}{
+		&d.Id, &d.WebhookId, &d.EventId, &d.Event, &d.Status, &d.Attempts, &d.ResponseCode,
+		&d.Error, &createdAt, &nextAttemptAt, &deliveredAt,
+	}, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	d.CreatedAt, _ = time.Parse(dateLayout, createdAt)
+	d.NextAttemptAt, _ = time.Parse(dateLayout, nextAttemptAt)
+	d.DeliveredAt, _ = time.Parse(dateLayout, deliveredAt)
+
+	return d, nil
+}
+
+// QueueDeliveries queues a delivery of each new event to each webhook that
+// matches it. Webhooks only get the events about the items that their owner
+// can access, like in the audit log.
+func QueueDeliveries(db *sql.DB, t time.Time) error {
+	rows, err := db.Query(`SELECT id, event, COALESCE(owner,''), last_event_id FROM webhooks ORDER BY id`)
+	if err != nil {
+		return err
+	}
+
+	type webhook struct {
+		id, event, owner, lastEventId string
+	}
+	var webhooks []webhook
+	for rows.Next() {
+		var w webhook
+		if err := rows.Scan(&w.id, &w.event, &w.owner, &w.lastEventId); err != nil {
+			rows.Close()
+			return err
+		}
+
+		webhooks = append(webhooks, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		events, err := ListEvents(db, w.lastEventId)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			continue
+		}
+
+		if err := queueEvents(db, w.id, w.event, w.owner, w.lastEventId, events, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queueEvents queues the deliveries of the `events` that match a webhook and
+// moves it past them, unless it has been moved from `lastEventId` in the
+// meantime: whoever moved it queued them.
+func queueEvents(db *sql.DB, webhookId string, event string, owner string, lastEventId string, events []*EventModel, t time.Time) error {
+	var matching []*EventModel
+	for _, e := range events {
+		if !e.Is(event) {
+			continue
+		}
+
+		if owner != "" && e.User != owner {
+			ok, err := CanAccess(db, e.Type, e.EntityId, owner)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		matching = append(matching, e)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE webhooks SET last_event_id = ? WHERE id = ? AND last_event_id = ?`,
+		events[len(events)-1].Id, webhookId, lastEventId,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	for _, e := range matching {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO webhook_deliveries
+			(webhook_id, event_id, event, payload, status, created_at, next_attempt_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			webhookId, e.Id, e.Event, string(payload), DeliveryPending,
+			t.Format(dateLayout), t.Format(dateLayout),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DueDeliveries returns the deliveries whose next attempt is due at `t`,
+// oldest first: the pending ones, and the ones that were being sent by
+// someone who didn't record how it went. They must be claimed before they're
+// sent.
+func DueDeliveries(db *sql.DB, t time.Time) ([]*PendingDelivery, error) {
+	rows, err := db.Query(`SELECT
+		d.id, d.webhook_id, d.event_id, d.event, d.status, d.attempts, COALESCE(d.response_code,0),
+		COALESCE(d.error,''), d.created_at, COALESCE(d.next_attempt_at,''), COALESCE(d.delivered_at,''),
+		w.url, w.secret, d.payload
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status IN (?, ?) AND d.next_attempt_at <= ?
+		ORDER BY d.id`,
+		DeliveryPending, DeliverySending, t.Format(dateLayout),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*PendingDelivery
+	for rows.Next() {
+		p := new(PendingDelivery)
+		var payload string
+		d, err := scanDelivery(rows, &p.URL, &p.Secret, &payload)
+		if err != nil {
+			return nil, err
+		}
+
+		p.DeliveryModel = *d
+		p.Payload = []byte(payload)
+
+		res = append(res, p)
+	}
+
+	return res, rows.Err()
+}
+
+// ClaimDelivery claims a due delivery at `t` so that it's only sent once,
+// even by different processes. It reports false if someone else claimed it,
+// or recorded an attempt, since it was returned by DueDeliveries.
+func ClaimDelivery(db *sql.DB, d *PendingDelivery, t time.Time) (bool, error) {
+	res, err := db.Exec(
+		`UPDATE webhook_deliveries SET status = ?, next_attempt_at = ?
+		WHERE id = ? AND status = ? AND next_attempt_at = ?`,
+		DeliverySending, t.Add(webhookLease).Format(dateLayout),
+		d.Id, d.Status, d.NextAttemptAt.Format(dateLayout),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return n == 1, err
+}
+
+// RecordAttempt records an attempt to send a delivery at `t`, which got a
+// response with status `code`, or failed with `e` before that. Deliveries
+// that fail are attempted again later, waiting twice as long after each
+// failure, until they're given up.
+func RecordAttempt(db *sql.DB, id string, code int, e error, t time.Time) error {
+	var attempts int
+	err := db.QueryRow(`SELECT attempts FROM webhook_deliveries WHERE id = ?`, id).Scan(&attempts)
+	if err == sql.ErrNoRows {
+		// The webhook has been deleted in the meantime.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	attempts++
+
+	var errMsg sql.NullString
+	if e != nil {
+		errMsg = sql.NullString{String: e.Error(), Valid: true}
+	} else if code < 200 || code > 299 {
+		errMsg = sql.NullString{String: fmt.Sprintf("unexpected status %d", code), Valid: true}
+	}
+
+	var responseCode sql.NullInt64
+	if code != 0 {
+		responseCode = sql.NullInt64{Int64: int64(code), Valid: true}
+	}
+
+	status, next, delivered := DeliveryPending, sql.NullString{}, sql.NullString{}
+	switch {
+	case !errMsg.Valid:
+		status = DeliveryDelivered
+		delivered = sql.NullString{String: t.Format(dateLayout), Valid: true}
+	case attempts >= webhookMaxAttempts:
+		status = DeliveryFailed
+	default:
+		backoff := webhookBackoff << uint(attempts-1)
+		next = sql.NullString{String: t.Add(backoff).Format(dateLayout), Valid: true}
+	}
+
+	_, err = db.Exec(
+		`UPDATE webhook_deliveries SET
+		status = ?, attempts = ?, response_code = ?, error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?`,
+		status, attempts, responseCode, errMsg, next, delivered, id,
+	)
+
+	return err
+}
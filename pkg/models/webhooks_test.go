@@ -0,0 +1,141 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddWebhook(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	created := time.Now()
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), 0\\) FROM audit").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+	mock.ExpectExec("INSERT INTO webhooks").
+		WithArgs("task.completed", "https://example.com/hook", sqlmock.AnyArg(), testActor.User, "7", created.Format(dateLayout)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	w, err := AddWebhook(db, testActor, "task.completed", "https://example.com/hook", "", created)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", w.Id)
+	assert.Regexp(t, "^whsec_[0-9a-f]{48}$", w.Secret)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddWebhookInvalid(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	for _, c := range []struct {
+		event, url string
+	}{
+		{"task.done", "https://example.com/hook"},
+		{"link", "https://example.com/hook"},
+		{"*", "example.com/hook"},
+		{"note", "ftp://example.com/hook"},
+	} {
+		_, err := AddWebhook(db, testActor, c.event, c.url, "", time.Now())
+		assert.True(t, errors.Is(err, ErrInvalidWebhook), "%s %s", c.event, c.url)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRecordAttempt(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	now := time.Date(2020, 9, 20, 15, 0, 0, 0, time.UTC)
+	update := "UPDATE webhook_deliveries SET"
+
+	// The third failure waits for 4 times the backoff
+	mock.ExpectQuery("SELECT attempts FROM webhook_deliveries WHERE id = \\?").
+		WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"attempts"}).AddRow(2))
+	mock.ExpectExec(update).
+		WithArgs(DeliveryPending, 3, 500, "unexpected status 500", "2020-09-20 15:02:00", nil, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// The last one gives up
+	mock.ExpectQuery("SELECT attempts FROM webhook_deliveries WHERE id = \\?").
+		WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"attempts"}).AddRow(webhookMaxAttempts - 1))
+	mock.ExpectExec(update).
+		WithArgs(DeliveryFailed, webhookMaxAttempts, nil, "connection refused", nil, nil, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Success
+	mock.ExpectQuery("SELECT attempts FROM webhook_deliveries WHERE id = \\?").
+		WithArgs("2").WillReturnRows(sqlmock.NewRows([]string{"attempts"}).AddRow(0))
+	mock.ExpectExec(update).
+		WithArgs(DeliveryDelivered, 1, 204, nil, nil, "2020-09-20 15:00:00", "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, RecordAttempt(db, "1", 500, nil, now))
+	assert.NoError(t, RecordAttempt(db, "1", 0, errors.New("connection refused"), now))
+	assert.NoError(t, RecordAttempt(db, "2", 204, nil, now))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestClaimDelivery(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	now := time.Date(2020, 9, 20, 15, 0, 0, 0, time.UTC)
+	d := &PendingDelivery{DeliveryModel: DeliveryModel{Id: "1", Status: DeliveryPending, NextAttemptAt: now}}
+	claim := "UPDATE webhook_deliveries SET status = \\?, next_attempt_at = \\? WHERE id = \\? AND status = \\? AND next_attempt_at = \\?"
+
+	mock.ExpectExec(claim).
+		WithArgs(DeliverySending, "2020-09-20 15:01:00", "1", DeliveryPending, "2020-09-20 15:00:00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Someone else claimed it first
+	mock.ExpectExec(claim).
+		WithArgs(DeliverySending, "2020-09-20 15:01:00", "1", DeliveryPending, "2020-09-20 15:00:00").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	claimed, err := ClaimDelivery(db, d, now)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = ClaimDelivery(db, d, now)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,72 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// listAudit lists the entries of the audit log, filtered by the `since`
+// (RFC 3339), `type`, `entity_id` and `user` query parameters. Users only see
+// their own changes and the ones made to the items they can access.
+func (s *Server) listAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := models.AuditFilter{
+		Type:     q.Get("type"),
+		EntityId: q.Get("entity_id"),
+		User:     q.Get("user"),
+	}
+
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			fail(w, badRequest("invalid time %q, expected RFC 3339", since))
+			return
+		}
+		f.Since = t.Local()
+	}
+
+	all, err := models.ListAudit(s.db, f)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	ok, err := s.accessibleItems(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	entries := []*models.AuditEntryModel{}
+	for _, e := range all {
+		if e.User == user(r) || ok[e.Type][e.EntityId] {
+			entries = append(entries, e)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
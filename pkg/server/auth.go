@@ -0,0 +1,248 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+type contextKey int
+
+const actorKey contextKey = iota
+
+// publicRoute is the name of the routes that don't require a token.
+const publicRoute = "public"
+
+// actor returns who sent the request, as they make changes.
+func actor(r *http.Request) models.Actor {
+	a, _ := r.Context().Value(actorKey).(models.Actor)
+
+	return a
+}
+
+// user returns the user who sent the request.
+func user(r *http.Request) string {
+	return actor(r).User
+}
+
+// bearerToken returns the token in the Authorization header of a request, or
+// an empty string if there's none.
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(h[7:])
+}
+
+// unauthorized responds with 401 Unauthorized.
+func unauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="clerk"`)
+	writeJSON(w, http.StatusUnauthorized, &ErrorResponse{Error: err.Error(), Code: CodeUnauthorized})
+}
+
+// authenticate rejects the requests without a valid API token, and makes the
+// user of the token the one who makes the changes. Public routes and the web
+// UI are let through.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil && (route.GetName() == publicRoute || route.GetName() == uiRoute) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			unauthorized(w, errors.New("missing bearer token"))
+			return
+		}
+
+		u, err := models.Authenticate(s.db, token)
+		if errors.Is(err, models.ErrUnauthorized) {
+			unauthorized(w, err)
+			return
+		}
+		if err != nil {
+			fail(w, err)
+			return
+		}
+
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		a := models.Actor{User: u, Host: host, Command: r.Method + " " + r.URL.Path}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey, a)))
+	})
+}
+
+// authorize rejects the requests about a task or a note that the user can't
+// access, as if it didn't exist.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, ok := vars["id"]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		collection := vars["type"]
+		if collection == "" {
+			collection = strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		}
+
+		switch collection {
+		case "tasks", "notes":
+			if err := s.checkAccess(r, strings.TrimSuffix(collection, "s"), id); err != nil {
+				fail(w, err)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkAccess fails with a NotFoundError unless the user who sent the
+// request can access an item, so that the items of other users can't be told
+// apart from the ones that don't exist. External stores only find the items
+// of the user.
+func (s *Server) checkAccess(r *http.Request, entityType string, id string) error {
+	if s.external {
+		_, err := s.storeOf(r).FindIds(entityType, "#"+id)
+		return err
+	}
+
+	ok, err := models.CanAccess(s.db, entityType, id, user(r))
+	if err != nil || ok {
+		return err
+	}
+
+	// Invalid ids are reported as such.
+	if _, err := s.storeOf(r).FindIds(entityType, "#"+id); err != nil {
+		return err
+	}
+
+	return &models.NotFoundError{Type: entityType, Ref: "#" + id}
+}
+
+// accessible returns the ids of the items of type `entityType` that the user
+// who sent the request can access.
+func (s *Server) accessible(r *http.Request, entityType string) (map[string]bool, error) {
+	if s.external {
+		return s.allIds(r, entityType)
+	}
+
+	return models.AccessibleIds(s.db, entityType, user(r))
+}
+
+// allIds returns the ids of all the items of type `entityType` that the
+// store of the request sees.
+func (s *Server) allIds(r *http.Request, entityType string) (map[string]bool, error) {
+	store := s.storeOf(r)
+	res := make(map[string]bool)
+	if entityType == models.TaskType {
+		tasks, err := store.ListTasks()
+		for _, t := range tasks {
+			res[t.Id] = true
+		}
+		return res, err
+	}
+
+	notes, err := store.ListNotes()
+	for _, n := range notes {
+		res[n.Id] = true
+	}
+	return res, err
+}
+
+// accessibleItems returns the ids of the tasks and notes that the user who
+// sent the request can access, by type.
+func (s *Server) accessibleItems(r *http.Request) (map[string]map[string]bool, error) {
+	res := make(map[string]map[string]bool)
+	for _, entityType := range []string{models.TaskType, models.NoteType} {
+		ids, err := s.accessible(r, entityType)
+		if err != nil {
+			return nil, err
+		}
+		res[entityType] = ids
+	}
+
+	return res, nil
+}
+
+// findIds is like models.FindIds, but ignores the items that the user who
+// sent the request can't access.
+func (s *Server) findIds(r *http.Request, entityType string, ref string) ([]string, error) {
+	ids, err := s.storeOf(r).FindIds(entityType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.accessible(r, entityType)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, id := range ids {
+		if ok[id] {
+			res = append(res, id)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, &models.NotFoundError{Type: entityType, Ref: ref}
+	}
+
+	return res, nil
+}
+
+// resolveRef turns a reference such as `task:groceries` into one by id, e.g.
+// `#task:3`, among the items that the user who sent the request can access.
+func (s *Server) resolveRef(r *http.Request, ref string) (string, error) {
+	entityType, nameOrId, err := models.ParseRef(ref)
+	if err != nil {
+		return "", badRequest("%s", err)
+	}
+
+	ids, err := s.findIds(r, entityType, nameOrId)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) > 1 {
+		return "", &models.AmbiguousNameError{Type: entityType, Name: nameOrId, Ids: ids}
+	}
+
+	return "#" + entityType + ":" + ids[0], nil
+}
@@ -0,0 +1,116 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthentication(t *testing.T) {
+	s := newTestServer(t)
+
+	var e ErrorResponse
+	w := doAs(t, s, "", http.MethodGet, "/tasks", nil, &e)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, CodeUnauthorized, e.Code)
+	assert.Equal(t, "missing bearer token", e.Error)
+	assert.Equal(t, `Bearer realm="clerk"`, w.Header().Get("WWW-Authenticate"))
+
+	send := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("clerk_unknown").Code)
+
+	tk := token(t, s, "alice")
+	assert.Equal(t, http.StatusOK, send(tk).Code)
+
+	tokens, err := models.ListTokens(s.db)
+	assert.NoError(t, err)
+	assert.NoError(t, models.RevokeToken(s.db, tokens[len(tokens)-1].Id, time.Now()))
+	assert.Equal(t, http.StatusUnauthorized, send(tk).Code)
+}
+
+func TestOwnership(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "alice's task"}, nil)
+	do(t, s, http.MethodPost, "/notes", map[string]interface{}{"name": "alice's note", "contents": []string{"milk"}}, nil)
+	doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "bob's task", "contents": "milk"}, nil)
+
+	var tasks []*models.TaskModel
+	doAs(t, s, "bob", http.MethodGet, "/tasks", nil, &tasks)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "bob's task", tasks[0].Name)
+
+	var notes []*models.NoteModel
+	doAs(t, s, "bob", http.MethodGet, "/notes", nil, &notes)
+	assert.Empty(t, notes)
+
+	for _, r := range []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{http.MethodGet, "/tasks/1", nil},
+		{http.MethodPatch, "/tasks/1", map[string]string{"name": "stolen"}},
+		{http.MethodDelete, "/tasks/1", nil},
+		{http.MethodGet, "/tasks?ref=alice%27s+task", nil},
+		{http.MethodGet, "/notes/1/revisions", nil},
+		{http.MethodPost, "/notes/1/contents", map[string]string{"contents": "eggs"}},
+		{http.MethodPost, "/links", map[string]string{"from": "#task:2", "to": "#note:1"}},
+		{http.MethodPost, "/trash/restore", map[string]string{"type": "task", "id": "1"}},
+	} {
+		var e ErrorResponse
+		w := doAs(t, s, "bob", r.method, r.path, r.body, &e)
+		assert.Equal(t, http.StatusNotFound, w.Code, r.path)
+		assert.Equal(t, CodeNotFound, e.Code, r.path)
+	}
+
+	var results []*SearchResult
+	doAs(t, s, "bob", http.MethodGet, "/search?q=milk", nil, &results)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "bob's task", results[0].Name)
+
+	var entries []*models.AuditEntryModel
+	doAs(t, s, "bob", http.MethodGet, "/audit", nil, &entries)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "bob", entries[0].User)
+	assert.Equal(t, "POST /tasks", entries[0].Command)
+
+	var task models.TaskModel
+	w := do(t, s, http.MethodGet, "/tasks/1", nil, &task)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice's task", task.Name)
+}
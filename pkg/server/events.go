@@ -0,0 +1,157 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// keepAlive is how long an event stream can stay silent before a comment is
+// sent, so that proxies don't close it.
+const keepAlive = 15 * time.Second
+
+// events streams the changes made to the tasks and notes that the user can
+// access as server-sent events, as they happen. The stream starts after the
+// event in the `Last-Event-ID` header or the `last_event_id` query
+// parameter, or with the next event if there's neither. The stream starts with
+// the id of the event it starts after.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		fail(w, errors.New("streaming isn't supported"))
+		return
+	}
+
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("last_event_id")
+	}
+	if last == "" {
+		s.mu.RLock()
+		latest, err := models.LatestEventId(s.db)
+		s.mu.RUnlock()
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		last = latest
+	}
+
+	// The first batch is read before the response starts, so that an
+	// invalid event id is reported as an error.
+	start := last
+	events, last, err := s.nextEvents(r, last)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// An id without data tells clients where the stream starts, so that they
+	// can resume from there even if no event is sent before they reconnect.
+	fmt.Fprintf(w, "id: %s\n\n", start)
+	flusher.Flush()
+
+	poll := time.NewTicker(s.pollInterval)
+	defer poll.Stop()
+
+	written := time.Now()
+	for {
+		for _, e := range events {
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+
+		if len(events) > 0 {
+			written = time.Now()
+			flusher.Flush()
+		} else if time.Since(written) >= keepAlive {
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			written = time.Now()
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+
+		events, last, err = s.nextEvents(r, last)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// nextEvents returns the events after the one with id `after` that the user
+// who sent the request can see, like in the audit log, and the id of the
+// latest event, which the user may not see.
+func (s *Server) nextEvents(r *http.Request, after string) ([]*models.EventModel, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all, err := models.ListEvents(s.db, after)
+	if err != nil || len(all) == 0 {
+		return nil, after, err
+	}
+
+	ok, err := s.accessibleItems(r)
+	if err != nil {
+		return nil, after, err
+	}
+
+	var events []*models.EventModel
+	for _, e := range all {
+		if e.User == user(r) || ok[e.Type][e.EntityId] {
+			events = append(events, e)
+		}
+	}
+
+	return events, all[len(all)-1].Id, nil
+}
+
+// writeEvent writes `e` in the format of server-sent events.
+func writeEvent(w io.Writer, e *models.EventModel) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.Id, e.Event, data)
+
+	return err
+}
@@ -0,0 +1,164 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventStream is a stream of server-sent events read by a test.
+type eventStream struct {
+	ids    chan string
+	events chan *models.EventModel
+}
+
+// openEvents opens the event stream of `s` as `user`, resuming after
+// `lastEventId` unless it's empty.
+func openEvents(t *testing.T, s *Server, user string, lastEventId string) *eventStream {
+	s.pollInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		ts.Close()
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token(t, s, user))
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	stream := &eventStream{ids: make(chan string, 100), events: make(chan *models.EventModel, 100)}
+	go func() {
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				stream.ids <- strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				e := new(models.EventModel)
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), e) == nil {
+					stream.events <- e
+				}
+			}
+		}
+	}()
+
+	return stream
+}
+
+// next returns the next event of the stream.
+func (e *eventStream) next(t *testing.T) *models.EventModel {
+	select {
+	case ev := <-e.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return nil
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "before"}, nil)
+
+	stream := openEvents(t, s, "alice", "")
+	start := <-stream.ids
+	assert.Equal(t, "1", start)
+
+	var task models.TaskModel
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, &task)
+	doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "bob's"}, nil)
+	do(t, s, http.MethodPatch, "/tasks/"+task.Id, map[string]string{"completed_at": "2020-09-20T15:00:00Z"}, nil)
+	do(t, s, http.MethodDelete, "/tasks/"+task.Id, nil, nil)
+
+	// Only the changes to the items that alice can access
+	for _, name := range []string{"task.created", "task.completed", "task.deleted"} {
+		e := stream.next(t)
+		assert.Equal(t, name, e.Event)
+		assert.Equal(t, task.Id, e.EntityId)
+		assert.Equal(t, "alice", e.User)
+	}
+
+	var item struct {
+		Name string `json:"name"`
+	}
+	do(t, s, http.MethodPost, "/notes", map[string]string{"name": "note"}, nil)
+	e := stream.next(t)
+	assert.Equal(t, "note.created", e.Event)
+	assert.NoError(t, json.Unmarshal(e.Item, &item))
+	assert.Equal(t, "note", item.Name)
+}
+
+func TestEventsResume(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "first"}, nil)
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "second"}, nil)
+
+	stream := openEvents(t, s, "alice", "1")
+	assert.Equal(t, "1", <-stream.ids)
+	e := stream.next(t)
+	assert.Equal(t, "2", e.Id)
+	assert.Equal(t, "task.created", e.Event)
+	assert.Equal(t, "2", <-stream.ids)
+}
+
+func TestEventsErrors(t *testing.T) {
+	s := newTestServer(t)
+
+	var e ErrorResponse
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token(t, s, "alice"))
+	req.Header.Set("Last-Event-ID", "last")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+	assert.Equal(t, CodeInvalidID, e.Code)
+
+	w = doAs(t, s, "", http.MethodGet, "/events", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
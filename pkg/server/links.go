@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// linkRequest is the body of the requests that link two items, which are
+// referred to as in the command-line, e.g. `#task:3` or `note:groceries`.
+type linkRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// listLinks lists the items linked to a task or a note that the user can
+// access.
+func (s *Server) listLinks(w http.ResponseWriter, r *http.Request) {
+	all, err := models.ListLinks(s.db, entityType(r), mux.Vars(r)["id"])
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	ok, err := s.accessibleItems(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	links := []*models.LinkModel{}
+	for _, l := range all {
+		if ok[l.Type][l.Id] {
+			links = append(links, l)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, links)
+}
+
+func (s *Server) addLink(w http.ResponseWriter, r *http.Request) {
+	var req linkRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	from, to, err := s.resolveLink(r, req.From, req.To)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := models.AddLink(s.db, actor(r), from, to, time.Now()); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteLink removes the link between the items given by the `from` and `to`
+// query parameters.
+func (s *Server) deleteLink(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := s.resolveLink(r, q.Get("from"), q.Get("to"))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := models.DeleteLink(s.db, actor(r), from, to); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolveLink resolves the references to the two sides of a link, see
+// resolveRef.
+func (s *Server) resolveLink(r *http.Request, from string, to string) (string, string, error) {
+	from, err := s.resolveRef(r, from)
+	if err != nil {
+		return "", "", err
+	}
+
+	to, err = s.resolveRef(r, to)
+
+	return from, to, err
+}
@@ -0,0 +1,57 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinks(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, nil)
+	do(t, s, http.MethodPost, "/notes", map[string]string{"name": "groceries"}, nil)
+
+	w := do(t, s, http.MethodPost, "/links", map[string]string{"from": "#task:1", "to": "note:groceries"}, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	var links []*models.LinkModel
+	w = do(t, s, http.MethodGet, "/notes/1/links", nil, &links)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []*models.LinkModel{{Type: "task", Id: "1", Name: "test"}}, links)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodPost, "/links", map[string]string{"from": "#task:1", "to": "note:unknown"}, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeNotFound, e.Code)
+
+	w = do(t, s, http.MethodDelete, "/links?from=%23task:1&to=note:groceries", nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	do(t, s, http.MethodGet, "/tasks/1/links", nil, &links)
+	assert.Empty(t, links)
+}
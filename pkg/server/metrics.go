@@ -0,0 +1,335 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// buckets are the upper bounds of the buckets of the histograms, in seconds,
+// as in the Prometheus clients.
+var buckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// histogram counts observations in buckets.
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+
+	for i, b := range buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// write writes the samples of the histogram `name` with the labels `labels`
+// in the Prometheus text format.
+func (h *histogram) write(w io.Writer, name string, labels string) {
+	var cumulative uint64
+	for i, b := range buckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", name, labels, strconv.FormatFloat(b, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %g\n", name, strings.TrimSuffix(labels, ","), h.sum)
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, strings.TrimSuffix(labels, ","), h.count)
+}
+
+// routeVars matches the variables of the routes, e.g. `{id:[0-9]+}`.
+var routeVars = regexp.MustCompile(`{(\w+)(?::([^}]+))?}`)
+
+// endpoint identifies the requests to a route with a method.
+type endpoint struct {
+	method string
+	route  string
+}
+
+// metrics collects the metrics of the server.
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[endpoint]map[int]uint64 // by status code
+	latencies map[endpoint]*histogram
+	queries   map[string]*histogram // by statement
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  map[endpoint]map[int]uint64{},
+		latencies: map[endpoint]*histogram{},
+		queries:   map[string]*histogram{},
+	}
+}
+
+func (m *metrics) observeRequest(e endpoint, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.requests[e] == nil {
+		m.requests[e] = map[int]uint64{}
+		m.latencies[e] = new(histogram)
+	}
+	m.requests[e][status]++
+	m.latencies[e].observe(elapsed.Seconds())
+}
+
+// observeQuery is the database.QueryObserver of the server.
+func (m *metrics) observeQuery(statement string, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.queries[statement] == nil {
+		m.queries[statement] = new(histogram)
+	}
+	m.queries[statement].observe(elapsed.Seconds())
+}
+
+// labelValue escapes `v` to be used as the value of a label.
+var labelValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+
+// write writes the requests and queries metrics in the Prometheus text
+// format, sorted so that the output is stable.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	endpoints := make([]endpoint, 0, len(m.requests))
+	for e := range m.requests {
+		endpoints = append(endpoints, e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].route != endpoints[j].route {
+			return endpoints[i].route < endpoints[j].route
+		}
+		return endpoints[i].method < endpoints[j].method
+	})
+
+	fmt.Fprintln(w, "# HELP clerk_http_requests_total Requests served, by method, route and status code.")
+	fmt.Fprintln(w, "# TYPE clerk_http_requests_total counter")
+	for _, e := range endpoints {
+		codes := make([]int, 0, len(m.requests[e]))
+		for code := range m.requests[e] {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+
+		for _, code := range codes {
+			fmt.Fprintf(
+				w, "clerk_http_requests_total{method=\"%s\",route=\"%s\",code=\"%d\"} %d\n",
+				labelValue(e.method), labelValue(e.route), code, m.requests[e][code],
+			)
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP clerk_http_request_duration_seconds How long the requests took, by method and route.")
+	fmt.Fprintln(w, "# TYPE clerk_http_request_duration_seconds histogram")
+	for _, e := range endpoints {
+		labels := fmt.Sprintf("method=\"%s\",route=\"%s\",", labelValue(e.method), labelValue(e.route))
+		m.latencies[e].write(w, "clerk_http_request_duration_seconds", labels)
+	}
+
+	statements := make([]string, 0, len(m.queries))
+	for s := range m.queries {
+		statements = append(statements, s)
+	}
+	sort.Strings(statements)
+
+	fmt.Fprintln(w, "# HELP clerk_db_query_duration_seconds How long the database queries took, by statement.")
+	fmt.Fprintln(w, "# TYPE clerk_db_query_duration_seconds histogram")
+	for _, s := range statements {
+		m.queries[s].write(w, "clerk_db_query_duration_seconds", fmt.Sprintf("statement=\"%s\",", labelValue(s)))
+	}
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush lets the event streams flush their responses.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// instrument counts the requests and times them, including the time they
+// wait for the lock, by route rather than path so that there's one series per
+// endpoint, e.g. /tasks/{id}.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		route := r.URL.Path
+		if tpl, err := mux.CurrentRoute(r).GetPathTemplate(); err == nil {
+			route = routeVars.ReplaceAllString(tpl, "{$1}")
+		}
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		s.metrics.observeRequest(endpoint{r.Method, route}, rec.status, time.Since(start))
+	})
+}
+
+// getMetrics serves the metrics of the server in the Prometheus text format.
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	pending, completed, overdue, err := s.countTasks(time.Now())
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintln(w, "# HELP clerk_tasks Tasks outside the trash, by state.")
+	fmt.Fprintln(w, "# TYPE clerk_tasks gauge")
+	fmt.Fprintf(w, "clerk_tasks{state=\"pending\"} %d\n", pending)
+	fmt.Fprintf(w, "clerk_tasks{state=\"completed\"} %d\n", completed)
+	fmt.Fprintf(w, "clerk_tasks{state=\"overdue\"} %d\n", overdue)
+
+	s.metrics.write(w)
+}
+
+// countTasks returns the number of pending, completed and overdue tasks, as
+// models.CountTasks does.
+func (s *Server) countTasks(now time.Time) (int, int, int, error) {
+	if !s.external {
+		return models.CountTasks(s.db, now)
+	}
+
+	tasks, err := s.store.ListTasks()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	pending, completed, overdue := 0, 0, 0
+	for _, t := range tasks {
+		switch {
+		case t.Overdue(now):
+			overdue++
+		case t.CompletedAt.IsZero():
+			pending++
+		default:
+			completed++
+		}
+	}
+
+	return pending, completed, overdue, nil
+}
+
+// checker is implemented by the stores that can check that they're ready.
+type checker interface {
+	Check(ctx context.Context) error
+}
+
+// healthTimeout bounds the checks of the health endpoints.
+const healthTimeout = 2 * time.Second
+
+// HealthResponse is the body of the responses of the health endpoints when
+// the checks pass.
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// unhealthy responds with 503 Service Unavailable.
+func unhealthy(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusServiceUnavailable, err)
+}
+
+// getHealth reports whether the server is alive, i.e. whether it can reach
+// the database.
+func (s *Server) getHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		unhealthy(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &HealthResponse{Status: "ok"})
+}
+
+// getReadiness reports whether the server is ready to serve requests, i.e.
+// whether it can read the tasks and notes from the database.
+func (s *Server) getReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	if err := database.Check(ctx, s.db); err != nil {
+		unhealthy(w, err)
+		return
+	}
+
+	if c, ok := s.store.(checker); ok {
+		if err := c.Check(ctx); err != nil {
+			unhealthy(w, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, &HealthResponse{Status: "ok"})
+}
@@ -0,0 +1,108 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "one"}, nil)
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "two"}, nil)
+	do(t, s, http.MethodPatch, "/tasks/1", map[string]string{"completed_at": "2020-10-11T09:12:45Z"}, nil)
+	do(t, s, http.MethodGet, "/tasks/42", nil, nil)
+
+	// It doesn't require a token
+	w := doAs(t, s, "", http.MethodGet, "/metrics", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	body := w.Body.String()
+	for _, line := range []string{
+		`clerk_tasks{state="pending"} 1`,
+		`clerk_tasks{state="completed"} 1`,
+		`clerk_tasks{state="overdue"} 0`,
+		`clerk_http_requests_total{method="POST",route="/tasks",code="201"} 2`,
+		`clerk_http_requests_total{method="PATCH",route="/tasks/{id}",code="200"} 1`,
+		`clerk_http_requests_total{method="GET",route="/tasks/{id}",code="404"} 1`,
+		`clerk_http_request_duration_seconds_bucket{method="POST",route="/tasks",le="+Inf"} 2`,
+		`clerk_http_request_duration_seconds_count{method="POST",route="/tasks"} 2`,
+		"# TYPE clerk_db_query_duration_seconds histogram",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.Contains(t, body, `clerk_db_query_duration_seconds_count{statement="insert"} `)
+}
+
+func TestMetricsOverdue(t *testing.T) {
+	for name, newServer := range map[string]func(t *testing.T) *Server{
+		"sqlite": newTestServer,
+		"store":  newStoreServer,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newServer(t)
+			past := time.Now().Add(-time.Hour)
+			future := time.Now().Add(time.Hour)
+
+			do(t, s, http.MethodPost, "/tasks", map[string]interface{}{"name": "late", "due": past}, nil)
+			do(t, s, http.MethodPost, "/tasks", map[string]interface{}{"name": "soon", "due": future}, nil)
+			do(t, s, http.MethodPost, "/tasks", map[string]interface{}{"name": "someday"}, nil)
+			do(t, s, http.MethodPost, "/tasks", map[string]interface{}{"name": "done", "due": past}, nil)
+			do(t, s, http.MethodPatch, "/tasks/4", map[string]interface{}{"completed_at": past}, nil)
+
+			w := doAs(t, s, "", http.MethodGet, "/metrics", nil, nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			// Overdue tasks aren't counted as pending.
+			body := w.Body.String()
+			assert.Contains(t, body, `clerk_tasks{state="pending"} 2`+"\n")
+			assert.Contains(t, body, `clerk_tasks{state="completed"} 1`+"\n")
+			assert.Contains(t, body, `clerk_tasks{state="overdue"} 1`+"\n")
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		var res HealthResponse
+		w := doAs(t, s, "", http.MethodGet, path, nil, &res)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, "ok", res.Status, path)
+	}
+
+	s.db.Close()
+	for _, path := range []string{"/healthz", "/readyz"} {
+		var e ErrorResponse
+		w := doAs(t, s, "", http.MethodGet, path, nil, &e)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+		assert.NotEmpty(t, e.Error, path)
+	}
+}
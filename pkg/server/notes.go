@@ -0,0 +1,207 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// noteRequest is the body of the requests that add or rename a note.
+type noteRequest struct {
+	Name     *string  `json:"name"`
+	Contents []string `json:"contents"`
+}
+
+// appendRequest is the body of the requests that append contents to a note.
+type appendRequest struct {
+	Contents string `json:"contents"`
+}
+
+// listNotes lists the notes that the user can access or, given the `ref` query
+// parameter, the ones referred to by a name or an id prefixed by a '#'.
+func (s *Server) listNotes(w http.ResponseWriter, r *http.Request) {
+	var notes []*models.NoteModel
+	var err error
+	if ref, ok := r.URL.Query()["ref"]; ok {
+		notes, err = s.findNotes(r, ref[0])
+	} else {
+		notes, err = s.listNotesOf(r)
+	}
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if notes == nil {
+		notes = []*models.NoteModel{}
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// listNotesOf returns the notes that the user who sent the request can access.
+func (s *Server) listNotesOf(r *http.Request) ([]*models.NoteModel, error) {
+	all, err := s.storeOf(r).ListNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.accessible(r, models.NoteType)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []*models.NoteModel
+	for _, x := range all {
+		if ok[x.Id] {
+			notes = append(notes, x)
+		}
+	}
+
+	return notes, nil
+}
+
+func (s *Server) findNotes(r *http.Request, ref string) ([]*models.NoteModel, error) {
+	ids, err := s.findIds(r, models.NoteType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []*models.NoteModel
+	for _, id := range ids {
+		n, err := s.storeOf(r).GetNote("#" + id)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+
+	return notes, nil
+}
+
+func (s *Server) getNote(w http.ResponseWriter, r *http.Request) {
+	n, err := s.storeOf(r).GetNote(ref(r))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, n)
+}
+
+// addNote adds a note with the first line of contents in the request and
+// appends the rest of them.
+func (s *Server) addNote(w http.ResponseWriter, r *http.Request) {
+	var req noteRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if req.Name == nil {
+		fail(w, badRequest("the name is required"))
+		return
+	}
+
+	var first string
+	if len(req.Contents) > 0 {
+		first = req.Contents[0]
+	}
+
+	store := s.storeOf(r)
+	id, err := store.AddNote(*req.Name, first, time.Now())
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	note := "#" + strconv.FormatInt(id, 10)
+	for i := 1; i < len(req.Contents); i++ {
+		if err := store.AppendNote(note, req.Contents[i]); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	n, err := store.GetNote(note)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/notes/%d", id))
+	writeJSON(w, http.StatusCreated, n)
+}
+
+// updateNote renames a note. The contents of a note can only be appended to,
+// see appendNote.
+func (s *Server) updateNote(w http.ResponseWriter, r *http.Request) {
+	var req noteRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if req.Contents != nil {
+		fail(w, badRequest("the contents of a note can't be replaced, append them instead"))
+		return
+	}
+
+	if req.Name != nil {
+		if err := s.storeOf(r).RenameNote(ref(r), *req.Name); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	s.getNote(w, r)
+}
+
+func (s *Server) appendNote(w http.ResponseWriter, r *http.Request) {
+	var req appendRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := s.storeOf(r).AppendNote(ref(r), req.Contents); err != nil {
+		fail(w, err)
+		return
+	}
+
+	s.getNote(w, r)
+}
+
+func (s *Server) deleteNote(w http.ResponseWriter, r *http.Request) {
+	if err := s.storeOf(r).DeleteNote(ref(r), time.Now()); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotes(t *testing.T) {
+	s := newTestServer(t)
+
+	var note models.NoteModel
+	w := do(t, s, http.MethodPost, "/notes", map[string]interface{}{
+		"name":     "test",
+		"contents": []string{"first", "second"},
+	}, &note)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/notes/1", w.Header().Get("Location"))
+	assert.Equal(t, []string{"first", "second"}, note.Contents)
+
+	w = do(t, s, http.MethodPost, "/notes/1/contents", map[string]string{"contents": "third"}, &note)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"first", "second", "third"}, note.Contents)
+
+	w = do(t, s, http.MethodPatch, "/notes/1", map[string]string{"name": "renamed"}, &note)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "renamed", note.Name)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodPatch, "/notes/1", map[string]interface{}{"contents": []string{"other"}}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var notes []*models.NoteModel
+	w = do(t, s, http.MethodGet, "/notes", nil, &notes)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, notes, 1)
+
+	w = do(t, s, http.MethodDelete, "/notes/1", nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(t, s, http.MethodGet, "/notes", nil, &notes)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, notes)
+
+	w = do(t, s, http.MethodPost, "/notes/1/contents", map[string]string{"contents": "more"}, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "note #1 not found", e.Error)
+}
@@ -0,0 +1,481 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// object is a JSON object of the OpenAPI document.
+type object = map[string]interface{}
+
+// param is a query parameter of an operation.
+type param struct {
+	name        string
+	description string
+}
+
+// operation describes an endpoint of the API in the OpenAPI document. In
+// paths, `{items}` stands for both tasks and notes, and `%s` in the id and
+// the summary for `Task` or `Note`.
+type operation struct {
+	method   string
+	path     string
+	id       string
+	summary  string
+	query    []param
+	headers  []param
+	request  interface{}
+	status   int
+	response interface{}
+	// contentType is the type of the response, JSON unless set.
+	contentType string
+	public      bool
+}
+
+// operations lists the endpoints of the API. See routes, which TestOpenAPI
+// checks against it.
+var operations = []operation{
+	{
+		method: http.MethodGet, path: "/openapi.json", id: "getOpenAPI",
+		summary: "Get this OpenAPI document", status: http.StatusOK, response: object{}, public: true,
+	},
+	{
+		method: http.MethodGet, path: "/metrics", id: "getMetrics",
+		summary: "Get the metrics of the server in the Prometheus text format", status: http.StatusOK,
+		response: "", contentType: "text/plain", public: true,
+	},
+	{
+		method: http.MethodGet, path: "/healthz", id: "getHealth",
+		summary: "Check that the server can reach the database", status: http.StatusOK,
+		response: HealthResponse{}, public: true,
+	},
+	{
+		method: http.MethodGet, path: "/readyz", id: "getReadiness",
+		summary: "Check that the server can read the tasks and notes", status: http.StatusOK,
+		response: HealthResponse{}, public: true,
+	},
+
+	{
+		method: http.MethodGet, path: "/tasks", id: "listTasks",
+		summary: "List the tasks",
+		query: []param{
+			{"pending", "only the pending tasks, if true"},
+			{"ref", "only the tasks referred to by a name or an id prefixed by a '#'"},
+		},
+		status: http.StatusOK, response: []*models.TaskModel{},
+	},
+	{
+		method: http.MethodPost, path: "/tasks", id: "addTask",
+		summary: "Add a task", request: taskRequest{},
+		status: http.StatusCreated, response: models.TaskModel{},
+	},
+	{
+		method: http.MethodGet, path: "/tasks/{id}", id: "getTask",
+		summary: "Get a task", status: http.StatusOK, response: models.TaskModel{},
+	},
+	{
+		method: http.MethodPatch, path: "/tasks/{id}", id: "updateTask",
+		summary: "Rename, edit, schedule or complete a task", request: taskRequest{},
+		status: http.StatusOK, response: models.TaskModel{},
+	},
+	{
+		method: http.MethodDelete, path: "/tasks/{id}", id: "deleteTask",
+		summary: "Move a task to the trash", status: http.StatusNoContent,
+	},
+
+	{
+		method: http.MethodGet, path: "/notes", id: "listNotes",
+		summary: "List the notes",
+		query: []param{
+			{"ref", "only the notes referred to by a name or an id prefixed by a '#'"},
+		},
+		status: http.StatusOK, response: []*models.NoteModel{},
+	},
+	{
+		method: http.MethodPost, path: "/notes", id: "addNote",
+		summary: "Add a note", request: noteRequest{},
+		status: http.StatusCreated, response: models.NoteModel{},
+	},
+	{
+		method: http.MethodGet, path: "/notes/{id}", id: "getNote",
+		summary: "Get a note and its contents", status: http.StatusOK, response: models.NoteModel{},
+	},
+	{
+		method: http.MethodPatch, path: "/notes/{id}", id: "updateNote",
+		summary: "Rename a note", request: noteRequest{},
+		status: http.StatusOK, response: models.NoteModel{},
+	},
+	{
+		method: http.MethodDelete, path: "/notes/{id}", id: "deleteNote",
+		summary: "Move a note to the trash", status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodPost, path: "/notes/{id}/contents", id: "appendNote",
+		summary: "Append contents to a note", request: appendRequest{},
+		status: http.StatusOK, response: models.NoteModel{},
+	},
+
+	{
+		method: http.MethodGet, path: "/{items}/{id}/links", id: "list%sLinks",
+		summary: "List the items linked to a %s", status: http.StatusOK, response: []*models.LinkModel{},
+	},
+	{
+		method: http.MethodPost, path: "/links", id: "addLink",
+		summary: "Link two items", request: linkRequest{}, status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodDelete, path: "/links", id: "deleteLink",
+		summary: "Remove the link between two items",
+		query: []param{
+			{"from", "reference to an item, e.g. #task:3"},
+			{"to", "reference to an item, e.g. note:groceries"},
+		},
+		status: http.StatusNoContent,
+	},
+
+	{
+		method: http.MethodGet, path: "/{items}/{id}/revisions", id: "list%sRevisions",
+		summary: "List the revisions of a %s", status: http.StatusOK, response: []*models.RevisionModel{},
+	},
+	{
+		method: http.MethodGet, path: "/{items}/{id}/revisions/current", id: "current%sRevision",
+		summary: "Get the current version of a %s as a revision", status: http.StatusOK, response: models.RevisionModel{},
+	},
+	{
+		method: http.MethodGet, path: "/{items}/{id}/revisions/{rev}", id: "get%sRevision",
+		summary: "Get a revision of a %s", status: http.StatusOK, response: models.RevisionModel{},
+	},
+	{
+		method: http.MethodPost, path: "/{items}/{id}/revert", id: "revert%s",
+		summary: "Revert a %s to a previous revision", request: revertRequest{}, status: http.StatusNoContent,
+	},
+
+	{
+		method: http.MethodGet, path: "/{items}/{id}/shares", id: "list%sShares",
+		summary: "List the users a %s is shared with", status: http.StatusOK, response: []*models.ShareModel{},
+	},
+	{
+		method: http.MethodPost, path: "/{items}/{id}/shares", id: "share%s",
+		summary: "Share a %s with a user", request: shareRequest{}, status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodDelete, path: "/{items}/{id}/shares/{user}", id: "unshare%s",
+		summary: "Stop sharing a %s with a user", status: http.StatusNoContent,
+	},
+
+	{
+		method: http.MethodGet, path: "/templates", id: "listTemplates",
+		summary: "List the templates, without their contents",
+		query: []param{
+			{"ref", "only the template referred to by a name or an id prefixed by a '#'"},
+		},
+		status: http.StatusOK, response: []*models.TemplateModel{},
+	},
+	{
+		method: http.MethodPost, path: "/templates", id: "addTemplate",
+		summary: "Add a template", request: templateRequest{},
+		status: http.StatusCreated, response: models.TemplateModel{},
+	},
+	{
+		method: http.MethodGet, path: "/templates/{id}", id: "getTemplate",
+		summary: "Get a template", status: http.StatusOK, response: models.TemplateModel{},
+	},
+	{
+		method: http.MethodDelete, path: "/templates/{id}", id: "deleteTemplate",
+		summary: "Delete a template", status: http.StatusNoContent,
+	},
+
+	{
+		method: http.MethodGet, path: "/trash", id: "listTrash",
+		summary: "List the items in the trash", status: http.StatusOK, response: []*models.TrashItemModel{},
+	},
+	{
+		method: http.MethodDelete, path: "/trash", id: "emptyTrash",
+		summary: "Permanently delete the items in the trash",
+		query: []param{
+			{"before", "only the items deleted before this time (RFC 3339)"},
+		},
+		status: http.StatusOK, response: EmptyTrashResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/trash/restore", id: "restoreItem",
+		summary: "Restore an item from the trash", request: restoreRequest{}, status: http.StatusNoContent,
+	},
+
+	{
+		method: http.MethodPost, path: "/undo", id: "undo",
+		summary: "Undo the latest changes", request: replayRequest{},
+		status: http.StatusOK, response: []*models.OperationModel{},
+	},
+	{
+		method: http.MethodPost, path: "/redo", id: "redo",
+		summary: "Redo the latest changes that were undone", request: replayRequest{},
+		status: http.StatusOK, response: []*models.OperationModel{},
+	},
+
+	{
+		method: http.MethodGet, path: "/audit", id: "listAudit",
+		summary: "List the audit log",
+		query: []param{
+			{"since", "only the entries since this time (RFC 3339)"},
+			{"type", "only the entries about this type of entity"},
+			{"entity_id", "only the entries about the entity with this id"},
+			{"user", "only the entries of this user"},
+		},
+		status: http.StatusOK, response: []*models.AuditEntryModel{},
+	},
+
+	{
+		method: http.MethodGet, path: "/search", id: "search",
+		summary: "Search the tasks and notes",
+		query: []param{
+			{"q", "text to search for"},
+		},
+		status: http.StatusOK, response: []*SearchResult{},
+	},
+
+	{
+		method: http.MethodGet, path: "/events", id: "streamEvents",
+		summary: "Stream the changes to the tasks and notes as server-sent events",
+		query: []param{
+			{"last_event_id", "resume after the event with this id, like the Last-Event-ID header"},
+		},
+		headers: []param{
+			{"Last-Event-ID", "resume after the event with this id"},
+		},
+		status: http.StatusOK, response: models.EventModel{}, contentType: "text/event-stream",
+	},
+
+	{
+		method: http.MethodGet, path: "/webhooks", id: "listWebhooks",
+		summary: "List the webhooks of the user", status: http.StatusOK, response: []*models.WebhookModel{},
+	},
+	{
+		method: http.MethodPost, path: "/webhooks", id: "addWebhook",
+		summary: "Add a webhook, which is returned with the secret that signs its deliveries",
+		request: webhookRequest{}, status: http.StatusCreated, response: models.WebhookModel{},
+	},
+	{
+		method: http.MethodGet, path: "/webhooks/{id}", id: "getWebhook",
+		summary: "Get a webhook", status: http.StatusOK, response: models.WebhookModel{},
+	},
+	{
+		method: http.MethodDelete, path: "/webhooks/{id}", id: "deleteWebhook",
+		summary: "Delete a webhook", status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodGet, path: "/webhooks/{id}/deliveries", id: "listDeliveries",
+		summary: "List the deliveries of a webhook", status: http.StatusOK, response: []*models.DeliveryModel{},
+	},
+}
+
+// pathParams matches the path parameters of an operation.
+var pathParams = regexp.MustCompile(`{(\w+)}`)
+
+// openAPI returns the OpenAPI document of the API.
+func openAPI() object {
+	schemas := object{}
+	paths := object{}
+
+	for _, o := range operations {
+		if !strings.Contains(o.path, "{items}") {
+			addOperation(paths, schemas, o)
+			continue
+		}
+
+		for _, entity := range []string{"Task", "Note"} {
+			x := o
+			x.path = strings.Replace(o.path, "{items}", strings.ToLower(entity)+"s", 1)
+			x.id = strings.Replace(o.id, "%s", entity, 1)
+			x.summary = strings.Replace(o.summary, "%s", strings.ToLower(entity), 1)
+			addOperation(paths, schemas, x)
+		}
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "clerk",
+			"description": "REST API of clerk-server, which manages the tasks and notes of clerk.",
+			"version":     "1",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"bearerAuth": object{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []object{{"bearerAuth": []string{}}},
+	}
+}
+
+// addOperation adds the description of `o` to `paths`, and the schemas of
+// the bodies to `schemas`.
+func addOperation(paths object, schemas object, o operation) {
+	var params []object
+	for _, m := range pathParams.FindAllStringSubmatch(o.path, -1) {
+		s := object{"type": "string"}
+		if m[1] != "user" {
+			s = object{"type": "integer", "minimum": 1}
+		}
+
+		params = append(params, object{"name": m[1], "in": "path", "required": true, "schema": s})
+	}
+	for _, ps := range []struct {
+		in     string
+		params []param
+	}{{"query", o.query}, {"header", o.headers}} {
+		for _, p := range ps.params {
+			params = append(params, object{
+				"name":        p.name,
+				"in":          ps.in,
+				"description": p.description,
+				"schema":      object{"type": "string"},
+			})
+		}
+	}
+
+	response := object{"description": http.StatusText(o.status)}
+	if o.response != nil {
+		contentType := o.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+
+		response["content"] = object{
+			contentType: object{"schema": schemaOf(reflect.TypeOf(o.response), schemas)},
+		}
+	}
+
+	op := object{
+		"operationId": o.id,
+		"summary":     o.summary,
+		"responses": object{
+			strconv.Itoa(o.status): response,
+			"default": object{
+				"description": "Error",
+				"content": object{
+					"application/json": object{"schema": schemaOf(reflect.TypeOf(ErrorResponse{}), schemas)},
+				},
+			},
+		},
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if o.request != nil {
+		op["requestBody"] = object{
+			"required": true,
+			"content": object{
+				"application/json": object{"schema": schemaOf(reflect.TypeOf(o.request), schemas)},
+			},
+		}
+	}
+	if o.public {
+		op["security"] = []object{}
+	}
+
+	item, ok := paths[o.path].(object)
+	if !ok {
+		item = object{}
+		paths[o.path] = item
+	}
+	item[strings.ToLower(o.method)] = op
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf returns the JSON schema of the values of type `t`. The schemas
+// of structs are added to `schemas`, named after their type, and referred
+// to.
+func schemaOf(t reflect.Type, schemas object) object {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t == rawType, t.Kind() == reflect.Map, t.Kind() == reflect.Interface:
+		return object{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return object{"type": "integer"}
+	case reflect.Slice:
+		return object{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := strings.TrimSuffix(t.Name(), "Model")
+		name = strings.ToUpper(name[:1]) + name[1:]
+		ref := object{"$ref": "#/components/schemas/" + name}
+		if _, ok := schemas[name]; ok {
+			return ref
+		}
+
+		properties := object{}
+		schemas[name] = object{"type": "object", "properties": properties}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("json"), ",")[0]
+			if f.PkgPath != "" || tag == "-" {
+				continue
+			}
+			if tag == "" {
+				tag = f.Name
+			}
+
+			properties[tag] = schemaOf(f.Type, schemas)
+		}
+
+		return ref
+	}
+
+	return object{}
+}
+
+// openAPIDocument is the OpenAPI document, encoded once.
+var openAPIDocument, _ = json.Marshal(openAPI())
+
+// getOpenAPI serves the OpenAPI document of the API.
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
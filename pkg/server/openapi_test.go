@@ -0,0 +1,162 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// routeEndpoints returns the method and the path of the endpoints served by
+// `s`, as they appear in the OpenAPI document.
+func routeEndpoints(t *testing.T, s *Server) []string {
+	var endpoints []string
+	err := s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetName() == uiRoute {
+			return nil
+		}
+
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		paths := []string{tpl}
+		if strings.HasPrefix(tpl, "/{type:tasks|notes}") {
+			paths = []string{
+				strings.Replace(tpl, "{type:tasks|notes}", "tasks", 1),
+				strings.Replace(tpl, "{type:tasks|notes}", "notes", 1),
+			}
+		}
+
+		for _, p := range paths {
+			p = routeVars.ReplaceAllString(p, "{$1}")
+			for _, m := range methods {
+				endpoints = append(endpoints, m+" "+p)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return endpoints
+}
+
+// refs returns the values of all the `$ref` in `v`.
+func refs(v interface{}) []string {
+	var res []string
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			if s, ok := x.(string); ok && k == "$ref" {
+				res = append(res, s)
+			}
+			res = append(res, refs(x)...)
+		}
+	case []interface{}:
+		for _, x := range v {
+			res = append(res, refs(x)...)
+		}
+	}
+
+	return res
+}
+
+func TestOpenAPI(t *testing.T) {
+	s := newTestServer(t)
+
+	// It doesn't require a token
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	w := doAs(t, s, "", http.MethodGet, "/openapi.json", nil, &doc)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	// It documents exactly the routes of the server
+	var documented []string
+	ids := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+
+			id, _ := op["operationId"].(string)
+			assert.NotEmpty(t, id, "%s %s", method, path)
+			assert.False(t, ids[id], "duplicate operationId %s", id)
+			ids[id] = true
+		}
+	}
+	assert.ElementsMatch(t, routeEndpoints(t, s), documented)
+
+	// All the schemas it refers to are defined
+	for _, ref := range refs(doc.Paths) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		assert.Contains(t, doc.Components.Schemas, name)
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	s := newTestServer(t)
+
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	doAs(t, s, "", http.MethodGet, "/openapi.json", nil, &doc)
+
+	// The properties of the schemas are the fields of the actual responses
+	var task map[string]interface{}
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, &task)
+	for field := range task {
+		assert.Contains(t, doc.Components.Schemas["Task"].Properties, field)
+	}
+
+	var e map[string]interface{}
+	do(t, s, http.MethodGet, "/tasks/42", nil, &e)
+	for field := range e {
+		assert.Contains(t, doc.Components.Schemas["ErrorResponse"].Properties, field)
+	}
+
+	completed, _ := json.Marshal(doc.Components.Schemas["Task"].Properties["completed_at"])
+	assert.JSONEq(t, `{"type": "string", "format": "date-time"}`, string(completed))
+}
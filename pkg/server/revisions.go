@@ -0,0 +1,95 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// revertRequest is the body of the requests that revert an item.
+type revertRequest struct {
+	Rev int `json:"rev"`
+}
+
+// listRevisions lists the history of a task or a note.
+func (s *Server) listRevisions(w http.ResponseWriter, r *http.Request) {
+	revs, err := models.ListRevisions(s.db, entityType(r), ref(r))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if revs == nil {
+		revs = []*models.RevisionModel{}
+	}
+
+	writeJSON(w, http.StatusOK, revs)
+}
+
+func (s *Server) getRevision(w http.ResponseWriter, r *http.Request) {
+	rev, err := strconv.Atoi(mux.Vars(r)["rev"])
+	if err != nil {
+		fail(w, badRequest("invalid revision %q", mux.Vars(r)["rev"]))
+		return
+	}
+
+	res, err := models.GetRevision(s.db, entityType(r), ref(r), rev)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// currentRevision returns the current state of a task or a note as a
+// revision.
+func (s *Server) currentRevision(w http.ResponseWriter, r *http.Request) {
+	res, err := models.CurrentRevision(s.db, entityType(r), ref(r))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// revertItem sets a task or a note back to a previous revision.
+func (s *Server) revertItem(w http.ResponseWriter, r *http.Request) {
+	var req revertRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := models.RevertItem(s.db, actor(r), entityType(r), ref(r), req.Rev); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
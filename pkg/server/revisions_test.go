@@ -0,0 +1,59 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisions(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test", "contents": "first"}, nil)
+	do(t, s, http.MethodPatch, "/tasks/1", map[string]string{"contents": "second"}, nil)
+
+	var revs []*models.RevisionModel
+	w := do(t, s, http.MethodGet, "/tasks/1/revisions", nil, &revs)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, revs, 2)
+
+	var rev models.RevisionModel
+	w = do(t, s, http.MethodGet, "/tasks/1/revisions/1", nil, &rev)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"first"}, rev.Contents)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodGet, "/tasks/1/revisions/9", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(t, s, http.MethodPost, "/tasks/1/revert", map[string]int{"rev": 1}, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(t, s, http.MethodGet, "/tasks/1/revisions/current", nil, &rev)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"first"}, rev.Contents)
+}
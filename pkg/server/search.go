@@ -0,0 +1,75 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// SearchResult is a task or a note that matches a search.
+type SearchResult struct {
+	Type     string   `json:"type"`
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Contents []string `json:"contents"`
+}
+
+// search finds the tasks and notes that the user can access and that
+// contain the `q` query parameter.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		fail(w, badRequest("the q parameter is required"))
+		return
+	}
+
+	results, err := s.storeOf(r).Search(query)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	ok, err := s.accessibleItems(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	res := []*SearchResult{}
+	for _, result := range results {
+		switch x := result.(type) {
+		case *models.TaskModel:
+			if ok[x.Type()][x.Id] {
+				res = append(res, &SearchResult{x.Type(), x.Id, x.Name, []string{x.Contents}})
+			}
+		case *models.NoteModel:
+			if ok[x.Type()][x.Id] {
+				res = append(res, &SearchResult{x.Type(), x.Id, x.Name, x.Contents})
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,54 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test", "contents": "buy milk"}, nil)
+	do(t, s, http.MethodPost, "/notes", map[string]interface{}{"name": "groceries", "contents": []string{"milk", "eggs"}}, nil)
+	do(t, s, http.MethodPost, "/notes", map[string]interface{}{"name": "o'clock", "contents": []string{"it's late"}}, nil)
+
+	var results []*SearchResult
+	w := do(t, s, http.MethodGet, "/search?q=milk", nil, &results)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []*SearchResult{
+		{"task", "1", "test", []string{"buy milk"}},
+		{"note", "1", "groceries", []string{"milk"}},
+	}, results)
+
+	w = do(t, s, http.MethodGet, "/search?q=it's", nil, &results)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, results, 1)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodGet, "/search", nil, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
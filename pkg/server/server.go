@@ -0,0 +1,350 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package server implements the REST API of clerk, which exposes tasks, notes
+// and search over HTTP using the same models as the command-line.
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// Server is an http.Handler that serves the API.
+type Server struct {
+	db     *sql.DB
+	store  models.SharedStore
+	router *mux.Router
+
+	// external is whether the tasks and notes are kept in a store other
+	// than `db`, which only has the tokens and the templates then.
+	external bool
+
+	// mu serializes the changes, since SQLite doesn't allow concurrent
+	// writers.
+	mu sync.RWMutex
+
+	// pollInterval is how often event streams check for new events.
+	pollInterval time.Duration
+
+	metrics *metrics
+}
+
+// New returns a server backed by `db`.
+func New(db *sql.DB) *Server {
+	return newServer(db, models.NewSQLiteStore(db, models.Actor{}), false)
+}
+
+// NewWithStore returns a server that keeps the tasks and notes in `store`,
+// and the tokens and templates in `db`. Users only see their own tasks and
+// notes, through `store.As`, and the features that track them in `db`, such
+// as links, revisions and shares, aren't available. The trash is, if `store`
+// is a models.TrashStore.
+func NewWithStore(db *sql.DB, store models.SharedStore) *Server {
+	return newServer(db, store, true)
+}
+
+func newServer(db *sql.DB, store models.SharedStore, external bool) *Server {
+	s := &Server{
+		db:           db,
+		store:        store,
+		router:       mux.NewRouter(),
+		external:     external,
+		pollInterval: time.Second,
+		metrics:      newMetrics(),
+	}
+	s.routes()
+	database.ObserveQueries(db, s.metrics.observeQuery)
+
+	return s
+}
+
+func (s *Server) routes() {
+	r := s.router
+	r.Use(s.instrument, s.serialize, s.authenticate, s.authorize)
+
+	r.HandleFunc("/openapi.json", s.getOpenAPI).Methods(http.MethodGet).Name(publicRoute)
+	r.HandleFunc("/metrics", s.getMetrics).Methods(http.MethodGet).Name(publicRoute)
+	r.HandleFunc("/healthz", s.getHealth).Methods(http.MethodGet).Name(publicRoute)
+	r.HandleFunc("/readyz", s.getReadiness).Methods(http.MethodGet).Name(publicRoute)
+
+	r.HandleFunc("/", s.getUI).Methods(http.MethodGet, http.MethodHead).Name(uiRoute)
+	r.PathPrefix("/ui/").Handler(uiHandler()).Methods(http.MethodGet, http.MethodHead).Name(uiRoute)
+
+	r.HandleFunc("/tasks", s.listTasks).Methods(http.MethodGet)
+	r.HandleFunc("/tasks", s.addTask).Methods(http.MethodPost)
+	r.HandleFunc("/tasks/{id:[0-9]+}", s.getTask).Methods(http.MethodGet)
+	r.HandleFunc("/tasks/{id:[0-9]+}", s.updateTask).Methods(http.MethodPatch)
+	r.HandleFunc("/tasks/{id:[0-9]+}", s.deleteTask).Methods(http.MethodDelete)
+
+	r.HandleFunc("/notes", s.listNotes).Methods(http.MethodGet)
+	r.HandleFunc("/notes", s.addNote).Methods(http.MethodPost)
+	r.HandleFunc("/notes/{id:[0-9]+}", s.getNote).Methods(http.MethodGet)
+	r.HandleFunc("/notes/{id:[0-9]+}", s.updateNote).Methods(http.MethodPatch)
+	r.HandleFunc("/notes/{id:[0-9]+}", s.deleteNote).Methods(http.MethodDelete)
+	r.HandleFunc("/notes/{id:[0-9]+}/contents", s.appendNote).Methods(http.MethodPost)
+
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/links", s.sqliteOnly(s.listLinks)).Methods(http.MethodGet)
+	r.Handle("/links", s.sqliteOnly(s.addLink)).Methods(http.MethodPost)
+	r.Handle("/links", s.sqliteOnly(s.deleteLink)).Methods(http.MethodDelete)
+
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/revisions", s.sqliteOnly(s.listRevisions)).Methods(http.MethodGet)
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/revisions/current", s.sqliteOnly(s.currentRevision)).Methods(http.MethodGet)
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/revisions/{rev:[0-9]+}", s.sqliteOnly(s.getRevision)).Methods(http.MethodGet)
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/revert", s.sqliteOnly(s.revertItem)).Methods(http.MethodPost)
+
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/shares", s.sqliteOnly(s.listShares)).Methods(http.MethodGet)
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/shares", s.sqliteOnly(s.shareItem)).Methods(http.MethodPost)
+	r.Handle("/{type:tasks|notes}/{id:[0-9]+}/shares/{user}", s.sqliteOnly(s.unshareItem)).Methods(http.MethodDelete)
+
+	r.HandleFunc("/templates", s.listTemplates).Methods(http.MethodGet)
+	r.HandleFunc("/templates", s.addTemplate).Methods(http.MethodPost)
+	r.HandleFunc("/templates/{id:[0-9]+}", s.getTemplate).Methods(http.MethodGet)
+	r.HandleFunc("/templates/{id:[0-9]+}", s.deleteTemplate).Methods(http.MethodDelete)
+
+	r.Handle("/trash", s.withTrash(s.listTrash)).Methods(http.MethodGet)
+	r.Handle("/trash", s.withTrash(s.emptyTrash)).Methods(http.MethodDelete)
+	r.Handle("/trash/restore", s.withTrash(s.restoreItem)).Methods(http.MethodPost)
+
+	r.Handle("/undo", s.sqliteOnly(s.undo)).Methods(http.MethodPost)
+	r.Handle("/redo", s.sqliteOnly(s.redo)).Methods(http.MethodPost)
+
+	r.Handle("/audit", s.sqliteOnly(s.listAudit)).Methods(http.MethodGet)
+
+	r.HandleFunc("/search", s.search).Methods(http.MethodGet)
+
+	r.Handle("/events", s.sqliteOnly(s.events)).Methods(http.MethodGet).Name(streamRoute)
+
+	r.Handle("/webhooks", s.sqliteOnly(s.listWebhooks)).Methods(http.MethodGet)
+	r.Handle("/webhooks", s.sqliteOnly(s.addWebhook)).Methods(http.MethodPost)
+	r.Handle("/webhooks/{id:[0-9]+}", s.sqliteOnly(s.getWebhook)).Methods(http.MethodGet)
+	r.Handle("/webhooks/{id:[0-9]+}", s.sqliteOnly(s.deleteWebhook)).Methods(http.MethodDelete)
+	r.Handle("/webhooks/{id:[0-9]+}/deliveries", s.sqliteOnly(s.listDeliveries)).Methods(http.MethodGet)
+
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint: %s", r.URL.Path))
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed on %s", r.Method, r.URL.Path))
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// streamRoute is the name of the routes that stream their responses, which
+// take the lock themselves when they read.
+const streamRoute = "stream"
+
+// serialize lets the requests that only read run concurrently, but not the
+// ones that make changes.
+func (s *Server) serialize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil && route.GetName() == streamRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+		} else {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// storeOf returns the store that makes the changes on behalf of the user who
+// sent the request.
+func (s *Server) storeOf(r *http.Request) models.Store {
+	return s.store.As(actor(r))
+}
+
+// sqliteOnly responds with 501 Not Implemented instead of calling `h` when
+// the tasks and notes aren't kept in the SQLite database.
+func (s *Server) sqliteOnly(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.external {
+			notImplemented(w, r)
+			return
+		}
+
+		h(w, r)
+	})
+}
+
+// withTrash is like sqliteOnly, but lets the external stores that have a
+// trash through.
+func (s *Server) withTrash(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.external && s.externalTrash(r) == nil {
+			notImplemented(w, r)
+			return
+		}
+
+		h(w, r)
+	})
+}
+
+// externalTrash returns the store of the request if the tasks and notes are
+// kept in an external store that has a trash, or nil.
+func (s *Server) externalTrash(r *http.Request) models.TrashStore {
+	if !s.external {
+		return nil
+	}
+
+	trash, _ := s.storeOf(r).(models.TrashStore)
+
+	return trash
+}
+
+// notImplemented responds with 501 Not Implemented.
+func notImplemented(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusNotImplemented, &ErrorResponse{
+		Error: fmt.Sprintf("%s isn't available with this store", r.URL.Path),
+		Code:  CodeNotImplemented,
+	})
+}
+
+// ref returns the reference to the item whose id is in the path.
+func ref(r *http.Request) string {
+	return "#" + mux.Vars(r)["id"]
+}
+
+// entityType returns the type of the item whose collection is in the path,
+// e.g. `task` for /tasks/3/revisions.
+func entityType(r *http.Request) string {
+	return strings.TrimSuffix(mux.Vars(r)["type"], "s")
+}
+
+// Error codes, which tell clients the kind of error besides the status code.
+const (
+	CodeNotFound       = "not_found"
+	CodeAmbiguous      = "ambiguous"
+	CodeInvalidID      = "invalid_id"
+	CodeInvalidName    = "invalid_name"
+	CodeNameTaken      = "name_taken"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeInvalidWebhook = "invalid_webhook"
+	CodeNotImplemented = "not_implemented"
+	CodeNothingToUndo  = "nothing_to_undo"
+	CodeNothingToRedo  = "nothing_to_redo"
+	CodeInvalidCount   = "invalid_count"
+	CodeConflict       = "conflict"
+)
+
+// ErrorResponse is the body of the responses to failed requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &ErrorResponse{Error: err.Error()})
+}
+
+// fail responds with the status code and the error code that correspond to
+// `err`.
+func fail(w http.ResponseWriter, err error) {
+	status, code := http.StatusInternalServerError, ""
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		status, code = http.StatusNotFound, CodeNotFound
+	case errors.Is(err, models.ErrInvalidID):
+		status, code = http.StatusBadRequest, CodeInvalidID
+	case errors.Is(err, models.ErrInvalidName):
+		status, code = http.StatusBadRequest, CodeInvalidName
+	case errors.Is(err, models.ErrAmbiguous):
+		status, code = http.StatusConflict, CodeAmbiguous
+	case errors.Is(err, models.ErrNameTaken):
+		status, code = http.StatusConflict, CodeNameTaken
+	case errors.Is(err, models.ErrForbidden):
+		status, code = http.StatusForbidden, CodeForbidden
+	case errors.Is(err, models.ErrInvalidWebhook):
+		status, code = http.StatusBadRequest, CodeInvalidWebhook
+	case errors.Is(err, models.ErrNothingToUndo):
+		status, code = http.StatusConflict, CodeNothingToUndo
+	case errors.Is(err, models.ErrNothingToRedo):
+		status, code = http.StatusConflict, CodeNothingToRedo
+	case errors.Is(err, models.ErrConflict):
+		status, code = http.StatusConflict, CodeConflict
+	case errors.Is(err, models.ErrInvalidCount):
+		status, code = http.StatusBadRequest, CodeInvalidCount
+	case errors.Is(err, errBadRequest):
+		status = http.StatusBadRequest
+	}
+
+	writeJSON(w, status, &ErrorResponse{Error: err.Error(), Code: code})
+}
+
+// errBadRequest is wrapped by the errors caused by invalid requests, as
+// opposed to the ones caused by the state of the database.
+var errBadRequest = errors.New("bad request")
+
+// badRequest returns an error that makes `fail` respond with 400 Bad Request.
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{fmt.Sprintf(format, args...)}
+}
+
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func (e *requestError) Is(target error) bool {
+	return target == errBadRequest
+}
+
+// decode reads the JSON body of a request into `v`, rejecting unknown fields.
+func decode(r *http.Request, v interface{}) error {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return badRequest("invalid request body: %s", err)
+	}
+
+	return nil
+}
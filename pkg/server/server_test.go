@@ -0,0 +1,204 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	d "github.com/csixteen/clerk/internal/database"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer returns a server backed by a new database.
+func newTestServer(t *testing.T) *Server {
+	return New(newTestDB(t))
+}
+
+// newStoreServer returns a server that keeps the tasks and the notes in a
+// new MemoryStore.
+func newStoreServer(t *testing.T) *Server {
+	return NewWithStore(newTestDB(t), models.NewMemoryStore())
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := d.SetupDatabase(filepath.Join(t.TempDir(), "clerk.db"))
+	if err != nil {
+		t.Fatalf("An error occurred when creating the database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// token returns a new API token of `user`.
+func token(t *testing.T, s *Server, user string) string {
+	token, err := models.CreateToken(s.db, user, time.Now())
+	if err != nil {
+		t.Fatalf("An error occurred when creating a token: %s", err)
+	}
+
+	return token
+}
+
+// do sends a request as alice, see doAs.
+func do(t *testing.T, s *Server, method string, path string, body interface{}, res interface{}) *httptest.ResponseRecorder {
+	return doAs(t, s, "alice", method, path, body, res)
+}
+
+// doAs sends a request as `user`, unless it's empty, with `body` encoded as
+// JSON, unless it's nil, and decodes the response into `res`, unless it's
+// nil.
+func doAs(t *testing.T, s *Server, user string, method string, path string, body interface{}, res interface{}) *httptest.ResponseRecorder {
+	var b bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &b)
+	if user != "" {
+		req.Header.Set("Authorization", "Bearer "+token(t, s, user))
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	if res != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+			t.Fatalf("invalid response %q: %s", w.Body.String(), err)
+		}
+	}
+
+	return w
+}
+
+func TestNotFound(t *testing.T) {
+	s := newTestServer(t)
+
+	var e ErrorResponse
+	w := do(t, s, http.MethodGet, "/unknown", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "no such endpoint: /unknown", e.Error)
+
+	w = do(t, s, http.MethodPut, "/tasks", nil, &e)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestInvalidBody(t *testing.T) {
+	s := newTestServer(t)
+
+	var e ErrorResponse
+	w := do(t, s, http.MethodPost, "/tasks", map[string]string{"title": "test"}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, e.Error, "invalid request body")
+}
+
+func TestExternalStore(t *testing.T) {
+	s := newStoreServer(t)
+
+	var task models.TaskModel
+	w := do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test", "contents": "buy milk"}, &task)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", task.Id)
+
+	// Users only see their own items, and can use the same names.
+	var e ErrorResponse
+	w = doAs(t, s, "bob", http.MethodGet, "/tasks/1", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeNotFound, e.Code)
+
+	w = doAs(t, s, "bob", http.MethodPatch, "/tasks/1", map[string]string{"contents": "buy eggs"}, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "test", "contents": "buy bread"}, &task)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", task.Id)
+
+	var tasks []*models.TaskModel
+	w = doAs(t, s, "bob", http.MethodGet, "/tasks", nil, &tasks)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "2", tasks[0].Id)
+
+	var note models.NoteModel
+	w = do(t, s, http.MethodPost, "/notes", map[string]interface{}{"name": "groceries", "contents": []string{"milk"}}, &note)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doAs(t, s, "bob", http.MethodPost, "/notes/1/contents", map[string]string{"contents": "eggs"}, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var results []*SearchResult
+	w = do(t, s, http.MethodGet, "/search?q=milk", nil, &results)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, results, 2)
+
+	w = doAs(t, s, "bob", http.MethodGet, "/search?q=milk", nil, &results)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, results, 0)
+
+	w = do(t, s, http.MethodDelete, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(t, s, http.MethodGet, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// The store has a trash, of each user.
+	var items []*models.TrashItemModel
+	w = do(t, s, http.MethodGet, "/trash", nil, &items)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, items, 1)
+
+	w = doAs(t, s, "bob", http.MethodGet, "/trash", nil, &items)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, items, 0)
+
+	w = doAs(t, s, "bob", http.MethodPost, "/trash/restore", map[string]string{"type": "task", "id": "1"}, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(t, s, http.MethodPost, "/trash/restore", map[string]string{"type": "task", "id": "1"}, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(t, s, http.MethodGet, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	do(t, s, http.MethodDelete, "/tasks/1", nil, nil)
+	var res EmptyTrashResponse
+	w = do(t, s, http.MethodDelete, "/trash", nil, &res)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(1), res.Deleted)
+
+	for _, path := range []string{"/notes/1/links", "/events", "/webhooks"} {
+		w = do(t, s, http.MethodGet, path, nil, &e)
+		assert.Equal(t, http.StatusNotImplemented, w.Code, path)
+		assert.Equal(t, CodeNotImplemented, e.Code, path)
+	}
+}
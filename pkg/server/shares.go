@@ -0,0 +1,113 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// shareRequest is the body of the requests that share an item.
+type shareRequest struct {
+	User string `json:"user"`
+}
+
+// listShares lists the users that a task or a note is shared with.
+func (s *Server) listShares(w http.ResponseWriter, r *http.Request) {
+	shares, err := models.ListShares(s.db, entityType(r), ref(r))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if shares == nil {
+		shares = []*models.ShareModel{}
+	}
+
+	writeJSON(w, http.StatusOK, shares)
+}
+
+// checkOwner fails with models.ErrForbidden unless the user who sent the
+// request owns the item in the path.
+func (s *Server) checkOwner(r *http.Request) error {
+	owner, err := models.ItemOwner(s.db, entityType(r), mux.Vars(r)["id"])
+	if err != nil {
+		return err
+	}
+
+	if owner != user(r) {
+		return fmt.Errorf("%w: only the owner of %s %s can change who it's shared with", models.ErrForbidden, entityType(r), ref(r))
+	}
+
+	return nil
+}
+
+// shareItem shares a task or a note with the user in the request. Only the
+// owner of an item can share it.
+func (s *Server) shareItem(w http.ResponseWriter, r *http.Request) {
+	var req shareRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := s.checkOwner(r); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if req.User == "" || req.User == user(r) {
+		fail(w, badRequest("can't share %s %s with %q", entityType(r), ref(r), req.User))
+		return
+	}
+
+	if err := models.ShareItem(s.db, actor(r), entityType(r), ref(r), req.User, time.Now()); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unshareItem stops sharing a task or a note with a user. Besides the owner,
+// users can stop an item from being shared with themselves.
+func (s *Server) unshareItem(w http.ResponseWriter, r *http.Request) {
+	u := mux.Vars(r)["user"]
+	if u != user(r) {
+		if err := s.checkOwner(r); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	if err := models.UnshareItem(s.db, actor(r), entityType(r), ref(r), u); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShares(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/notes", map[string]interface{}{"name": "groceries"}, nil)
+
+	w := do(t, s, http.MethodPost, "/notes/1/shares", map[string]string{"user": "bob"}, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	var shares []*models.ShareModel
+	w = doAs(t, s, "bob", http.MethodGet, "/notes/1/shares", nil, &shares)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, shares, 1)
+	assert.Equal(t, "bob", shares[0].User)
+
+	var notes []*models.NoteModel
+	doAs(t, s, "bob", http.MethodGet, "/notes?ref=groceries", nil, &notes)
+	assert.Len(t, notes, 1)
+
+	var note models.NoteModel
+	w = doAs(t, s, "bob", http.MethodPost, "/notes/1/contents", map[string]string{"contents": "milk"}, &note)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"milk"}, note.Contents)
+
+	var e ErrorResponse
+	w = doAs(t, s, "bob", http.MethodPost, "/notes/1/shares", map[string]string{"user": "carol"}, &e)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, CodeForbidden, e.Code)
+
+	w = do(t, s, http.MethodPost, "/notes/1/shares", map[string]string{"user": "alice"}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doAs(t, s, "carol", http.MethodGet, "/notes/1/shares", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Users can stop an item from being shared with themselves.
+	w = doAs(t, s, "bob", http.MethodDelete, "/notes/1/shares/bob", nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doAs(t, s, "bob", http.MethodGet, "/notes/1", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(t, s, http.MethodDelete, "/notes/1/shares/bob", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "share of note #1 with bob not found", e.Error)
+}
@@ -0,0 +1,253 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// taskRequest is the body of the requests that add or change a task. Only
+// the fields that are set are changed. A zero `due` clears the due date.
+type taskRequest struct {
+	Name        *string    `json:"name"`
+	Contents    *string    `json:"contents"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Due         *time.Time `json:"due"`
+	Priority    *int       `json:"priority"`
+}
+
+// validate checks the fields that the store doesn't.
+func (req *taskRequest) validate() error {
+	if req.Priority != nil && *req.Priority < 0 {
+		return badRequest("invalid priority %d, it can't be negative", *req.Priority)
+	}
+
+	return nil
+}
+
+// schedule sets the due date and the priority of a task in `store`, if
+// they're set in the request.
+func schedule(store models.Store, task string, req *taskRequest) error {
+	if req.Due != nil {
+		due := *req.Due
+		if !due.IsZero() {
+			due = due.Local()
+		}
+		if err := store.SetTaskDue(task, due); err != nil {
+			return err
+		}
+	}
+
+	if req.Priority != nil {
+		return store.SetTaskPriority(task, *req.Priority)
+	}
+
+	return nil
+}
+
+// listTasks lists the tasks that the user can access or, given the `ref` query
+// parameter, the ones referred to by a name or an id prefixed by a '#'.
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	var tasks []*models.TaskModel
+	var err error
+	if ref, ok := r.URL.Query()["ref"]; ok {
+		tasks, err = s.findTasks(r, ref[0])
+	} else {
+		tasks, err = s.listTasksOf(r)
+	}
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("pending") == "true" {
+		pending := []*models.TaskModel{}
+		for _, t := range tasks {
+			if t.CompletedAt.IsZero() {
+				pending = append(pending, t)
+			}
+		}
+		tasks = pending
+	}
+
+	if tasks == nil {
+		tasks = []*models.TaskModel{}
+	}
+
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// listTasksOf returns the tasks that the user who sent the request can access.
+func (s *Server) listTasksOf(r *http.Request) ([]*models.TaskModel, error) {
+	all, err := s.storeOf(r).ListTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.accessible(r, models.TaskType)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*models.TaskModel
+	for _, x := range all {
+		if ok[x.Id] {
+			tasks = append(tasks, x)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (s *Server) findTasks(r *http.Request, ref string) ([]*models.TaskModel, error) {
+	ids, err := s.findIds(r, models.TaskType, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*models.TaskModel
+	for _, id := range ids {
+		t, err := s.storeOf(r).GetTask("#" + id)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, nil
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	t, err := s.storeOf(r).GetTask(ref(r))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) addTask(w http.ResponseWriter, r *http.Request) {
+	var req taskRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if req.Name == nil {
+		fail(w, badRequest("the name is required"))
+		return
+	}
+	if err := req.validate(); err != nil {
+		fail(w, err)
+		return
+	}
+
+	var contents string
+	if req.Contents != nil {
+		contents = *req.Contents
+	}
+
+	store := s.storeOf(r)
+	id, err := store.AddTask(*req.Name, contents, time.Now())
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := schedule(store, "#"+strconv.FormatInt(id, 10), &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	t, err := store.GetTask("#" + strconv.FormatInt(id, 10))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/tasks/%d", id))
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// updateTask renames, edits, schedules and completes a task, depending on
+// the fields set in the request.
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	var req taskRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		fail(w, err)
+		return
+	}
+
+	task, store := ref(r), s.storeOf(r)
+	if _, err := store.GetTask(task); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if req.Name != nil {
+		if err := store.RenameTask(task, *req.Name); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	if req.Contents != nil {
+		if err := store.EditTask(task, *req.Contents); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	if err := schedule(store, task, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if req.CompletedAt != nil {
+		if err := store.CompleteTask(task, req.CompletedAt.Local()); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	s.getTask(w, r)
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	if err := s.storeOf(r).DeleteTask(ref(r), time.Now()); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,156 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTasks(t *testing.T) {
+	s := newTestServer(t)
+
+	var task models.TaskModel
+	w := do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test", "contents": "test contents"}, &task)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/tasks/1", w.Header().Get("Location"))
+	assert.Equal(t, "1", task.Id)
+	assert.Equal(t, "test contents", task.Contents)
+
+	w = do(t, s, http.MethodGet, "/tasks/1", nil, &task)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "test", task.Name)
+
+	completed := time.Date(2020, 10, 11, 9, 0, 0, 0, time.Local)
+	w = do(t, s, http.MethodPatch, "/tasks/1", map[string]interface{}{
+		"name":         "renamed",
+		"contents":     "new contents",
+		"completed_at": completed,
+	}, &task)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "renamed", task.Name)
+	assert.Equal(t, "new contents", task.Contents)
+	assert.True(t, completed.Equal(task.CompletedAt))
+
+	due := time.Date(2020, 10, 12, 18, 0, 0, 0, time.UTC)
+	w = do(t, s, http.MethodPatch, "/tasks/1", map[string]interface{}{"due": due, "priority": 2}, &task)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, due.Equal(task.Due))
+	assert.Equal(t, 2, task.Priority)
+
+	// Unset dates are null, which leaves them untouched when decoding.
+	task = models.TaskModel{}
+	w = do(t, s, http.MethodPatch, "/tasks/1", map[string]interface{}{"due": time.Time{}}, &task)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, task.Due.IsZero())
+	assert.Equal(t, 2, task.Priority)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]interface{}{"name": "other", "due": due, "priority": 1}, &task)
+	assert.True(t, due.Equal(task.Due))
+	assert.Equal(t, 1, task.Priority)
+
+	var tasks []*models.TaskModel
+	w = do(t, s, http.MethodGet, "/tasks", nil, &tasks)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, tasks, 2)
+
+	do(t, s, http.MethodGet, "/tasks?pending=true", nil, &tasks)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, "other", tasks[0].Name)
+
+	w = do(t, s, http.MethodDelete, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(t, s, http.MethodGet, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTaskErrors(t *testing.T) {
+	s := newTestServer(t)
+
+	var e ErrorResponse
+	w := do(t, s, http.MethodPost, "/tasks", map[string]string{"contents": "test contents"}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "the name is required", e.Error)
+
+	w = do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "#1"}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `the name "#1" can't start with a '#'`, e.Error)
+	assert.Equal(t, CodeInvalidName, e.Code)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, nil)
+	w = do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, &e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, `there's already a task called "test"`, e.Error)
+	assert.Equal(t, CodeNameTaken, e.Code)
+
+	w = do(t, s, http.MethodPatch, "/tasks/2", map[string]string{"contents": "new contents"}, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "task #2 not found", e.Error)
+
+	w = do(t, s, http.MethodPatch, "/tasks/1", map[string]int{"priority": -1}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid priority -1, it can't be negative", e.Error)
+
+	w = do(t, s, http.MethodDelete, "/tasks/0", nil, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, CodeInvalidID, e.Code)
+
+	var tasks []*models.TaskModel
+	w = do(t, s, http.MethodGet, "/tasks?ref=test", nil, &tasks)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, tasks, 1)
+
+	w = do(t, s, http.MethodGet, "/tasks?ref=unknown", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeNotFound, e.Code)
+}
+
+func TestTaskNamesPerUser(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "groceries"}, nil)
+
+	// The names of alice's tasks are neither taken for bob nor revealed.
+	var task models.TaskModel
+	w := doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "groceries"}, &task)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "laundry"}, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var e ErrorResponse
+	w = doAs(t, s, "bob", http.MethodPatch, "/tasks/"+task.Id, map[string]string{"name": "laundry"}, &e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, CodeNameTaken, e.Code)
+
+	var tasks []*models.TaskModel
+	w = doAs(t, s, "bob", http.MethodGet, "/tasks?ref=groceries", nil, &tasks)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, task.Id, tasks[0].Id)
+}
@@ -0,0 +1,108 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// templateRequest is the body of the requests that add a template.
+type templateRequest struct {
+	Name     string `json:"name"`
+	Contents string `json:"contents"`
+}
+
+// listTemplates lists all the templates, without their contents, or the one
+// referred to by the `ref` query parameter, which is a name or an id prefixed
+// by a '#'.
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	var templates []*models.TemplateModel
+	if ref, ok := r.URL.Query()["ref"]; ok {
+		t, err := models.GetTemplate(s.db, ref[0])
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		templates = append(templates, t)
+	} else {
+		var err error
+		templates, err = models.ListTemplates(s.db)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	if templates == nil {
+		templates = []*models.TemplateModel{}
+	}
+
+	writeJSON(w, http.StatusOK, templates)
+}
+
+func (s *Server) getTemplate(w http.ResponseWriter, r *http.Request) {
+	t, err := models.GetTemplate(s.db, ref(r))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) addTemplate(w http.ResponseWriter, r *http.Request) {
+	var req templateRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	id, err := models.AddTemplate(s.db, actor(r), req.Name, req.Contents, time.Now())
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	t, err := models.GetTemplate(s.db, "#"+strconv.FormatInt(id, 10))
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/templates/%d", id))
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := models.DeleteTemplate(s.db, actor(r), ref(r)); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,57 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	s := newTestServer(t)
+
+	var tmpl models.TemplateModel
+	w := do(t, s, http.MethodPost, "/templates", map[string]string{"name": "standup", "contents": "# {{name}}"}, &tmpl)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/templates/1", w.Header().Get("Location"))
+	assert.Equal(t, "# {{name}}", tmpl.Contents)
+
+	var templates []*models.TemplateModel
+	do(t, s, http.MethodGet, "/templates?ref=standup", nil, &templates)
+	assert.Len(t, templates, 1)
+	assert.Equal(t, "1", templates[0].Id)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodGet, "/templates?ref=unknown", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "template unknown not found", e.Error)
+
+	w = do(t, s, http.MethodDelete, "/templates/1", nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(t, s, http.MethodGet, "/templates/1", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,142 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// restoreRequest is the body of the requests that restore an item from the
+// trash.
+type restoreRequest struct {
+	Type string `json:"type"`
+	Id   string `json:"id"`
+}
+
+// EmptyTrashResponse is the body of the response to emptying the trash.
+type EmptyTrashResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// listTrash lists the items in the trash that the user can access.
+func (s *Server) listTrash(w http.ResponseWriter, r *http.Request) {
+	if trash := s.externalTrash(r); trash != nil {
+		items, err := trash.ListTrash()
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		if items == nil {
+			items = []*models.TrashItemModel{}
+		}
+
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+
+	all, err := models.ListTrash(s.db)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	ok, err := s.accessibleItems(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	items := []*models.TrashItemModel{}
+	for _, i := range all {
+		if ok[i.Type][i.Id] {
+			items = append(items, i)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) restoreItem(w http.ResponseWriter, r *http.Request) {
+	var req restoreRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	if trash := s.externalTrash(r); trash != nil {
+		if err := trash.RestoreItem(req.Type, req.Id); err != nil {
+			fail(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if req.Type == models.TaskType || req.Type == models.NoteType {
+		if err := s.checkAccess(r, req.Type, strings.TrimPrefix(req.Id, "#")); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	if err := models.RestoreItem(s.db, actor(r), req.Type, req.Id); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// emptyTrash permanently deletes the items in the trash owned by the user,
+// or only those deleted before the time given by the `before` query parameter
+// (RFC 3339).
+func (s *Server) emptyTrash(w http.ResponseWriter, r *http.Request) {
+	var before time.Time
+	if b := r.URL.Query().Get("before"); b != "" {
+		t, err := time.Parse(time.RFC3339, b)
+		if err != nil {
+			fail(w, badRequest("invalid time %q, expected RFC 3339", b))
+			return
+		}
+		before = t.Local()
+	}
+
+	var n int64
+	var err error
+	if trash := s.externalTrash(r); trash != nil {
+		n, err = trash.EmptyTrash(before)
+	} else {
+		n, err = models.EmptyTrashOf(s.db, actor(r), before)
+	}
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &EmptyTrashResponse{Deleted: n})
+}
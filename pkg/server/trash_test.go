@@ -0,0 +1,84 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, nil)
+	do(t, s, http.MethodPost, "/notes", map[string]string{"name": "test"}, nil)
+	do(t, s, http.MethodDelete, "/tasks/1", nil, nil)
+	do(t, s, http.MethodDelete, "/notes/1", nil, nil)
+
+	var items []*models.TrashItemModel
+	w := do(t, s, http.MethodGet, "/trash", nil, &items)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, items, 2)
+
+	w = do(t, s, http.MethodPost, "/trash/restore", map[string]string{"type": "task", "id": "1"}, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodPost, "/trash/restore", map[string]string{"type": "task", "id": "1"}, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "task #1 in the trash not found", e.Error)
+
+	w = do(t, s, http.MethodDelete, "/trash?before=yesterday", nil, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var res EmptyTrashResponse
+	w = do(t, s, http.MethodDelete, "/trash", nil, &res)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(1), res.Deleted)
+}
+
+func TestTrashPerUser(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "alice's task"}, nil)
+	doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "bob's task"}, nil)
+	do(t, s, http.MethodDelete, "/tasks/1", nil, nil)
+	doAs(t, s, "bob", http.MethodDelete, "/tasks/2", nil, nil)
+
+	var items []*models.TrashItemModel
+	doAs(t, s, "bob", http.MethodGet, "/trash", nil, &items)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "bob's task", items[0].Name)
+
+	var res EmptyTrashResponse
+	w := doAs(t, s, "bob", http.MethodDelete, "/trash", nil, &res)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(1), res.Deleted)
+
+	do(t, s, http.MethodGet, "/trash", nil, &items)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "alice's task", items[0].Name)
+}
@@ -0,0 +1,350 @@
+// The web UI of clerk-server. It only uses the REST API, with the API token
+// that the user signs in with, which is kept in the local storage.
+(function () {
+  "use strict";
+
+  const $ = (id) => document.getElementById(id);
+
+  const state = {
+    token: localStorage.getItem("clerk.token") || "",
+    tab: "tasks",
+    query: "",
+    selected: null, // {type, id}
+  };
+
+  // api sends a request to the server and returns the decoded response.
+  async function api(method, path, body) {
+    const headers = { Authorization: "Bearer " + state.token };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+
+    const resp = await fetch(path, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    if (resp.status === 401) {
+      signOut();
+    }
+    if (!resp.ok) {
+      let message = resp.statusText;
+      try {
+        message = (await resp.json()).error || message;
+      } catch (e) {}
+      throw new Error(message);
+    }
+
+    return resp.status === 204 ? null : resp.json();
+  }
+
+  function showError(err) {
+    $("error").textContent = err ? err.message : "";
+    $("error").hidden = !err;
+  }
+
+  // run calls `fn`, showing the error it fails with, if any.
+  async function run(fn) {
+    try {
+      showError(null);
+      await fn();
+    } catch (err) {
+      showError(err);
+    }
+  }
+
+  function completed(task) {
+    return task.completed_at && !task.completed_at.startsWith("0001-");
+  }
+
+  function element(tag, className, text) {
+    const e = document.createElement(tag);
+    if (className) {
+      e.className = className;
+    }
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    return e;
+  }
+
+  // Lists
+
+  async function refresh() {
+    let items;
+    if (state.query) {
+      items = await api("GET", "/search?q=" + encodeURIComponent(state.query));
+    } else if (state.tab === "tasks") {
+      const pending = $("show-completed").checked ? "" : "?pending=true";
+      items = (await api("GET", "/tasks" + pending)).map((t) => Object.assign({ type: "task" }, t));
+    } else {
+      items = (await api("GET", "/notes")).map((n) => Object.assign({ type: "note" }, n));
+    }
+
+    const list = $("items");
+    list.replaceChildren();
+    for (const item of items) {
+      const li = element("li", item.type === "task" && completed(item) ? "completed" : "");
+      if (state.query) {
+        li.append(element("span", "type", item.type));
+      }
+      li.append(element("span", "name", item.name));
+
+      const contents = Array.isArray(item.contents) ? item.contents.join(" · ") : item.contents;
+      if (contents) {
+        li.append(element("span", "contents", contents));
+      }
+
+      li.addEventListener("click", () => run(() => select(item.type, item.id)));
+      list.append(li);
+    }
+
+    if (items.length === 0) {
+      list.append(element("li", "contents", state.query ? "Nothing found." : "Nothing here yet."));
+    }
+
+    $("add").hidden = !!state.query;
+    $("completed-toggle").hidden = !!state.query || state.tab !== "tasks";
+  }
+
+  // Details
+
+  async function select(type, id) {
+    const item = await api("GET", "/" + type + "s/" + id);
+    state.selected = { type, id };
+
+    const form = $("edit");
+    form.elements.name.value = item.name;
+
+    const contents = $("note-contents");
+    contents.replaceChildren();
+    if (type === "task") {
+      form.elements.contents.value = item.contents;
+      form.elements.contents.placeholder = "Contents";
+    } else {
+      for (const c of item.contents || []) {
+        contents.append(element("p", "", c));
+      }
+      form.elements.contents.value = "";
+      form.elements.contents.placeholder = "Append to the note";
+    }
+
+    $("complete").hidden = type !== "task" || completed(item);
+    $("detail").hidden = false;
+  }
+
+  function closeDetail() {
+    state.selected = null;
+    $("detail").hidden = true;
+  }
+
+  // PATCH requests rename items even if the name didn't change, which fails
+  // because the name is taken, so only the changed fields are sent.
+  async function save() {
+    const { type, id } = state.selected;
+    const current = await api("GET", "/" + type + "s/" + id);
+    const form = $("edit");
+    const body = {};
+
+    if (form.elements.name.value !== current.name) {
+      body.name = form.elements.name.value;
+    }
+    if (type === "task" && form.elements.contents.value !== current.contents) {
+      body.contents = form.elements.contents.value;
+    }
+
+    if (Object.keys(body).length > 0) {
+      await api("PATCH", "/" + type + "s/" + id, body);
+    }
+    if (type === "note" && form.elements.contents.value) {
+      await api("POST", "/notes/" + id + "/contents", { contents: form.elements.contents.value });
+    }
+
+    await select(type, id);
+    await refresh();
+  }
+
+  // Live updates
+
+  let stream = null;
+
+  // watch refreshes the list whenever something changes on the server, as
+  // told by the event stream. EventSource can't send the token, so the
+  // stream is read with fetch.
+  async function watch() {
+    if (stream) {
+      stream.abort();
+    }
+    stream = new AbortController();
+    const signal = stream.signal;
+
+    let lastEventId = "";
+    while (!signal.aborted && state.token) {
+      try {
+        const headers = { Authorization: "Bearer " + state.token };
+        if (lastEventId) {
+          headers["Last-Event-ID"] = lastEventId;
+        }
+
+        const resp = await fetch("/events", { headers, signal });
+        if (!resp.ok) {
+          return;
+        }
+
+        const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+        let buffer = "";
+        for (;;) {
+          const { value, done } = await reader.read();
+          if (done) {
+            break;
+          }
+
+          buffer += value;
+          let end;
+          while ((end = buffer.indexOf("\n\n")) >= 0) {
+            const event = buffer.slice(0, end);
+            buffer = buffer.slice(end + 2);
+
+            for (const line of event.split("\n")) {
+              if (line.startsWith("id: ")) {
+                lastEventId = line.slice(4);
+              }
+            }
+            if (event.includes("\ndata: ")) {
+              onChange();
+            }
+          }
+        }
+      } catch (e) {
+        if (signal.aborted) {
+          return;
+        }
+      }
+
+      await new Promise((resolve) => setTimeout(resolve, 1000));
+    }
+  }
+
+  let pending = null;
+
+  // onChange refreshes the list and the selected item, at most once per
+  // burst of events.
+  function onChange() {
+    clearTimeout(pending);
+    pending = setTimeout(() => {
+      run(async () => {
+        await refresh();
+        if (state.selected && !document.activeElement.closest("#edit")) {
+          try {
+            await select(state.selected.type, state.selected.id);
+          } catch (e) {
+            closeDetail();
+          }
+        }
+      });
+    }, 200);
+  }
+
+  // Session
+
+  function signOut() {
+    state.token = "";
+    localStorage.removeItem("clerk.token");
+    if (stream) {
+      stream.abort();
+    }
+    show();
+  }
+
+  function show() {
+    const signedIn = !!state.token;
+    $("signin").hidden = signedIn;
+    for (const id of ["tabs", "search", "signout", "app"]) {
+      $(id).hidden = !signedIn;
+    }
+
+    if (signedIn) {
+      run(refresh);
+      watch();
+    }
+  }
+
+  $("signin").addEventListener("submit", (e) => {
+    e.preventDefault();
+    state.token = e.target.elements.token.value.trim();
+    localStorage.setItem("clerk.token", state.token);
+    e.target.reset();
+    show();
+  });
+
+  $("signout").addEventListener("click", signOut);
+
+  $("tabs").addEventListener("click", (e) => {
+    const tab = e.target.dataset.tab;
+    if (!tab) {
+      return;
+    }
+
+    state.tab = tab;
+    for (const b of $("tabs").querySelectorAll("button")) {
+      b.classList.toggle("active", b.dataset.tab === tab);
+    }
+    closeDetail();
+    run(refresh);
+  });
+
+  $("search").addEventListener("input", (e) => {
+    state.query = e.target.value.trim();
+    run(refresh);
+  });
+
+  $("show-completed").addEventListener("change", () => run(refresh));
+
+  $("add").addEventListener("submit", (e) => {
+    e.preventDefault();
+    const form = e.target;
+    const name = form.elements.name.value;
+    const contents = form.elements.contents.value;
+
+    run(async () => {
+      if (state.tab === "tasks") {
+        await api("POST", "/tasks", { name, contents });
+      } else {
+        await api("POST", "/notes", { name, contents: contents ? [contents] : [] });
+      }
+      form.reset();
+      await refresh();
+    });
+  });
+
+  $("edit").addEventListener("submit", (e) => {
+    e.preventDefault();
+    run(save);
+  });
+
+  $("complete").addEventListener("click", () =>
+    run(async () => {
+      await api("PATCH", "/tasks/" + state.selected.id, { completed_at: new Date().toISOString() });
+      await select("task", state.selected.id);
+      await refresh();
+    })
+  );
+
+  $("delete").addEventListener("click", () =>
+    run(async () => {
+      const { type, id } = state.selected;
+      if (!confirm("Move this " + type + " to the trash?")) {
+        return;
+      }
+
+      await api("DELETE", "/" + type + "s/" + id);
+      closeDetail();
+      await refresh();
+    })
+  );
+
+  $("close").addEventListener("click", closeDetail);
+
+  show();
+})();
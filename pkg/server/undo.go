@@ -0,0 +1,64 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/models"
+)
+
+// replayRequest is the body of the requests that undo or redo changes.
+type replayRequest struct {
+	Count int `json:"count"`
+}
+
+func (s *Server) undo(w http.ResponseWriter, r *http.Request) {
+	s.replay(w, r, models.Undo)
+}
+
+func (s *Server) redo(w http.ResponseWriter, r *http.Request) {
+	s.replay(w, r, models.Redo)
+}
+
+// replay undoes or redoes the number of changes given in the request, one
+// by default, and responds with the operations that were undone or redone.
+func (s *Server) replay(w http.ResponseWriter, r *http.Request, fn func(*sql.DB, models.Actor, int, time.Time) ([]*models.OperationModel, error)) {
+	req := replayRequest{Count: 1}
+	if r.ContentLength != 0 {
+		if err := decode(r, &req); err != nil {
+			fail(w, err)
+			return
+		}
+	}
+
+	ops, err := fn(s.db, actor(r), req.Count, time.Now())
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ops)
+}
@@ -0,0 +1,145 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoRedo(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, nil)
+	do(t, s, http.MethodPatch, "/tasks/1", map[string]string{"name": "renamed"}, nil)
+
+	var ops []*models.OperationModel
+	w := do(t, s, http.MethodPost, "/undo", nil, &ops)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, ops, 1)
+	assert.Equal(t, models.OpRename, ops[0].Operation)
+
+	w = do(t, s, http.MethodPost, "/undo", map[string]int{"count": 5}, &ops)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, ops, 1)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodPost, "/undo", nil, &e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, CodeNothingToUndo, e.Code)
+	assert.Equal(t, "nothing to undo", e.Error)
+
+	w = do(t, s, http.MethodPost, "/redo", map[string]int{"count": 0}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, CodeInvalidCount, e.Code)
+
+	w = do(t, s, http.MethodPost, "/redo", map[string]int{"count": 2}, &ops)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, ops, 2)
+
+	w = do(t, s, http.MethodPost, "/redo", nil, &e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, CodeNothingToRedo, e.Code)
+
+	var task models.TaskModel
+	do(t, s, http.MethodGet, "/tasks/1", nil, &task)
+	assert.Equal(t, "renamed", task.Name)
+
+	var entries []*models.AuditEntryModel
+	w = do(t, s, http.MethodGet, "/audit?type=task&entity_id=1", nil, &entries)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, entries, 6)
+}
+
+func TestUndoPerUser(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "alice's task"}, nil)
+	doAs(t, s, "bob", http.MethodPost, "/tasks", map[string]string{"name": "bob's task"}, nil)
+
+	var ops []*models.OperationModel
+	w := do(t, s, http.MethodPost, "/undo", nil, &ops)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice's task", ops[0].Name)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodPost, "/undo", nil, &e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, CodeNothingToUndo, e.Code)
+
+	var tasks []*models.TaskModel
+	doAs(t, s, "bob", http.MethodGet, "/tasks", nil, &tasks)
+	assert.Len(t, tasks, 1)
+
+	// Redoing a creation gives the item back to its owner.
+	do(t, s, http.MethodPost, "/redo", nil, &ops)
+	w = do(t, s, http.MethodGet, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUndoLegacyOperations(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, nil)
+	if _, err := s.db.Exec(`UPDATE operations SET user = NULL`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Operations without a user can't be undone by anyone through the API.
+	w := doAs(t, s, "bob", http.MethodPost, "/undo", nil, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(t, s, http.MethodGet, "/tasks/1", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUndoConflict(t *testing.T) {
+	s := newTestServer(t)
+
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, nil)
+	do(t, s, http.MethodPost, "/tasks/1/shares", map[string]string{"user": "bob"}, nil)
+	do(t, s, http.MethodPatch, "/tasks/1", map[string]string{"contents": "alice"}, nil)
+	doAs(t, s, "bob", http.MethodPatch, "/tasks/1", map[string]string{"contents": "bob"}, nil)
+
+	// Undoing alice's change would wipe bob's.
+	var e ErrorResponse
+	w := do(t, s, http.MethodPost, "/undo", nil, &e)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, CodeConflict, e.Code)
+
+	var task models.TaskModel
+	do(t, s, http.MethodGet, "/tasks/1", nil, &task)
+	assert.Equal(t, "bob", task.Contents)
+
+	w = doAs(t, s, "bob", http.MethodPost, "/undo", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = do(t, s, http.MethodPost, "/undo", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	do(t, s, http.MethodGet, "/tasks/1", nil, &task)
+	assert.Equal(t, "", task.Contents)
+}
@@ -0,0 +1,161 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// webhookTimeout is how long the receivers of webhooks have to respond.
+const webhookTimeout = 10 * time.Second
+
+// webhookRequest is the body of the requests that add a webhook.
+type webhookRequest struct {
+	Event  string `json:"event"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+// webhook returns the webhook whose id is in the path, unless it belongs to
+// someone else.
+func (s *Server) webhook(r *http.Request) (*models.WebhookModel, error) {
+	id := mux.Vars(r)["id"]
+	wh, err := models.GetWebhook(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if wh.Owner != user(r) {
+		return nil, &models.NotFoundError{Type: "webhook", Ref: "#" + id}
+	}
+
+	return wh, nil
+}
+
+// listWebhooks lists the webhooks of the user.
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	all, err := models.ListWebhooks(s.db)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	webhooks := []*models.WebhookModel{}
+	for _, wh := range all {
+		if wh.Owner == user(r) {
+			webhooks = append(webhooks, wh)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+// addWebhook adds a webhook, and responds with its secret.
+func (s *Server) addWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := decode(r, &req); err != nil {
+		fail(w, err)
+		return
+	}
+
+	wh, err := models.AddWebhook(s.db, actor(r), req.Event, req.URL, req.Secret, time.Now())
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/webhooks/"+wh.Id)
+	writeJSON(w, http.StatusCreated, wh)
+}
+
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, err := s.webhook(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wh)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	wh, err := s.webhook(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if err := models.DeleteWebhook(s.db, wh.Id); err != nil {
+		fail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeliveries lists the deliveries of a webhook.
+func (s *Server) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	wh, err := s.webhook(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	deliveries, err := models.ListDeliveries(s.db, wh.Id)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*models.DeliveryModel{}
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// DeliverWebhooks sends the events to the webhooks that match them, as they
+// happen, until `ctx` is done. Failed deliveries are attempted again later.
+func (s *Server) DeliverWebhooks(ctx context.Context) {
+	c := &http.Client{Timeout: webhookTimeout}
+	poll := time.NewTicker(s.pollInterval)
+	defer poll.Stop()
+
+	for {
+		if err := actions.DeliverWebhooks(ctx, s.db, &s.mu, c, time.Now()); err != nil {
+			log.Printf("Delivering webhooks: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// MIT License
+//
+// Copyright (c) 2020 Pedro Rodrigues
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/csixteen/clerk/pkg/actions"
+	"github.com/csixteen/clerk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks(t *testing.T) {
+	s := newTestServer(t)
+
+	var wh models.WebhookModel
+	w := do(t, s, http.MethodPost, "/webhooks", map[string]string{"event": "task.completed", "url": "http://localhost:9999/hook"}, &wh)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/webhooks/"+wh.Id, w.Header().Get("Location"))
+	assert.Equal(t, "alice", wh.Owner)
+	assert.NotEmpty(t, wh.Secret)
+
+	// The secret isn't returned again
+	var got models.WebhookModel
+	w = do(t, s, http.MethodGet, "/webhooks/"+wh.Id, nil, &got)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, got.Secret)
+
+	var e ErrorResponse
+	w = do(t, s, http.MethodPost, "/webhooks", map[string]string{"event": "task.done", "url": "http://localhost:9999/hook"}, &e)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, CodeInvalidWebhook, e.Code)
+
+	// Only the owner sees it
+	var webhooks []*models.WebhookModel
+	doAs(t, s, "bob", http.MethodGet, "/webhooks", nil, &webhooks)
+	assert.Empty(t, webhooks)
+	w = doAs(t, s, "bob", http.MethodGet, "/webhooks/"+wh.Id+"/deliveries", nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doAs(t, s, "bob", http.MethodDelete, "/webhooks/"+wh.Id, nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	do(t, s, http.MethodGet, "/webhooks", nil, &webhooks)
+	assert.Equal(t, 1, len(webhooks))
+
+	w = do(t, s, http.MethodDelete, "/webhooks/"+wh.Id, nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(t, s, http.MethodGet, "/webhooks/"+wh.Id, nil, &e)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	s := newTestServer(t)
+	s.pollInterval = 10 * time.Millisecond
+
+	type delivery struct {
+		user  string
+		event models.EventModel
+	}
+	received := make(chan delivery, 10)
+	receiver := func(user string, secret string) *httptest.Server {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.True(t, actions.VerifySignature(secret, body, r.Header.Get(actions.SignatureHeader)))
+
+			var d delivery
+			d.user = user
+			assert.NoError(t, json.Unmarshal(body, &d.event))
+			received <- d
+		}))
+		t.Cleanup(ts.Close)
+
+		return ts
+	}
+
+	aliceHook := receiver("alice", "alice's secret")
+	bobHook := receiver("bob", "bob's secret")
+	do(t, s, http.MethodPost, "/webhooks", map[string]string{"event": "task", "url": aliceHook.URL, "secret": "alice's secret"}, nil)
+	doAs(t, s, "bob", http.MethodPost, "/webhooks", map[string]string{"event": "*", "url": bobHook.URL, "secret": "bob's secret"}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.DeliverWebhooks(ctx)
+
+	next := func() delivery {
+		select {
+		case d := <-received:
+			return d
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a delivery")
+			return delivery{}
+		}
+	}
+
+	// bob can't see alice's task until she shares it
+	var task models.TaskModel
+	do(t, s, http.MethodPost, "/tasks", map[string]string{"name": "test"}, &task)
+	d := next()
+	assert.Equal(t, "alice", d.user)
+	assert.Equal(t, "task.created", d.event.Event)
+
+	do(t, s, http.MethodPost, "/tasks/"+task.Id+"/shares", map[string]string{"user": "bob"}, nil)
+	do(t, s, http.MethodPatch, "/tasks/"+task.Id, map[string]string{"contents": "shared"}, nil)
+	users := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		d = next()
+		assert.Equal(t, "task.updated", d.event.Event)
+		users[d.user] = true
+	}
+	assert.Equal(t, map[string]bool{"alice": true, "bob": true}, users)
+
+	// Deliveries are logged
+	var webhooks []*models.WebhookModel
+	do(t, s, http.MethodGet, "/webhooks", nil, &webhooks)
+	var deliveries []*models.DeliveryModel
+	do(t, s, http.MethodGet, "/webhooks/"+webhooks[0].Id+"/deliveries", nil, &deliveries)
+	assert.Equal(t, 2, len(deliveries))
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+}